@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/cowpeatechnology/slg-game-server/internal/config"
+	"github.com/cowpeatechnology/slg-game-server/internal/game"
+	"github.com/cowpeatechnology/slg-game-server/internal/storage"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+	"github.com/go-redis/redis/v8"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+func main() {
+	configPath := flag.String("config", "config/config.json", "配置文件，用于连接 Redis")
+	battleID := flag.String("battle", "", "从 Redis 读取的战斗ID")
+	file := flag.String("file", "", "从 JSON 文件读取战报（包含 input）")
+	verbose := flag.Bool("v", false, "输出逐回合战报")
+	flag.Parse()
+
+	var (
+		battle *pb.BattleResult
+		err    error
+	)
+	switch {
+	case *file != "":
+		battle, err = loadFile(*file)
+	case *battleID != "":
+		battle, err = loadRedis(*configPath, *battleID)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("Failed to load battle: %v", err)
+	}
+
+	replay, err := game.ReplayBattle(battle)
+	if err != nil {
+		log.Fatalf("Failed to replay battle: %v", err)
+	}
+
+	fmt.Printf("Battle %s (seed %d)\n", battle.BattleId, battle.Seed)
+	printSummary("stored", battle)
+	printSummary("replay", replay)
+	if *verbose {
+		printRounds(replay)
+	}
+
+	if !game.SameOutcome(battle, replay) {
+		fmt.Println("\nResult: MISMATCH")
+		os.Exit(1)
+	}
+	fmt.Println("\nResult: identical")
+}
+
+// loadFile 读取 JSON 格式的战报
+func loadFile(path string) (*pb.BattleResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var battle pb.BattleResult
+	if err := json.Unmarshal(data, &battle); err != nil {
+		return nil, err
+	}
+	return &battle, nil
+}
+
+// loadRedis 从 Redis 读取 StorageActor 保存的战报
+func loadRedis(configPath, battleID string) (*pb.BattleResult, error) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Address,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer client.Close()
+
+	data, err := client.Get(context.Background(), storage.BattleKey(battleID)).Bytes()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("battle not found: %s", battleID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var battle pb.BattleResult
+	if err := protobuf.Unmarshal(data, &battle); err != nil {
+		return nil, err
+	}
+	return &battle, nil
+}
+
+// printSummary 输出战报摘要和双方伤亡
+func printSummary(title string, battle *pb.BattleResult) {
+	fmt.Printf("  %s: winner=%s, rounds=%d, damage=%d, attacker_hp=%d, defender_hp=%d\n",
+		title, battle.WinnerId, battle.Rounds, battle.DamageDealt, battle.AttackerHp, battle.DefenderHp)
+	for _, stack := range append(battle.AttackerStacks, battle.DefenderStacks...) {
+		fmt.Printf("    %s %-9s %d -> %d (lost %d)\n",
+			stack.OwnerId, stack.TroopType, stack.Initial, stack.Remaining, stack.Lost)
+	}
+}
+
+// printRounds 输出逐回合的行动记录
+func printRounds(battle *pb.BattleResult) {
+	for _, round := range battle.RoundReports {
+		fmt.Printf("\nRound %d: attacker_hp=%d, defender_hp=%d\n", round.Round, round.AttackerHp, round.DefenderHp)
+		for _, action := range round.Actions {
+			if action.SkillId != "" && action.TargetId == "" {
+				fmt.Printf("  %s uses %s\n", action.ActorId, action.SkillId)
+				continue
+			}
+			fmt.Printf("  %s %s -> %s %s: damage=%d, kills=%d, remaining=%d %v\n",
+				action.ActorId, action.TroopType, action.TargetId, action.TargetTroopType,
+				action.Damage, action.Kills, action.TargetRemaining, action.Modifiers)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/cowpeatechnology/slg-game-server/internal/game"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// sideDefinition 一方的配置：玩家属性、部队和统帅英雄
+type sideDefinition struct {
+	Player pb.PlayerData    `json:"player"`
+	Army   map[string]int32 `json:"army"` // 进攻方的出征部队或防守方的驻守部队
+	Hero   *heroDefinition  `json:"hero"` // 可为空
+}
+
+// heroDefinition 统帅英雄配置，技能和稀有度取自英雄模板
+type heroDefinition struct {
+	TemplateID string                   `json:"template_id"`
+	Level      int32                    `json:"level"`
+	Equipment  map[string]*pb.Equipment `json:"equipment"`
+}
+
+// sideStats 一方在所有模拟中的统计
+type sideStats struct {
+	wins       int
+	damage     []int64          // 每场战斗造成的总伤害
+	casualties map[string]int64 // 各兵种累计损失
+}
+
+func main() {
+	attackerPath := flag.String("attacker", "cmd/battlesim/examples/attacker.json", "进攻方配置文件")
+	defenderPath := flag.String("defender", "cmd/battlesim/examples/defender.json", "防守方配置文件")
+	runs := flag.Int("n", 1000, "模拟次数")
+	seed := flag.Int64("seed", 1, "起始随机种子，第i次模拟使用 seed+i")
+	flag.Parse()
+
+	if *runs <= 0 {
+		log.Fatalf("Invalid simulation count: %d", *runs)
+	}
+
+	attacker, err := loadSide(*attackerPath, "attacker")
+	if err != nil {
+		log.Fatalf("Failed to load attacker: %v", err)
+	}
+	defender, err := loadSide(*defenderPath, "defender")
+	if err != nil {
+		log.Fatalf("Failed to load defender: %v", err)
+	}
+	if attacker.Player.Id == defender.Player.Id {
+		log.Fatalf("Attacker and defender must have different ids: %s", attacker.Player.Id)
+	}
+
+	// 防守方的部队作为驻守部队参战
+	defender.Player.Troops = defender.Army
+
+	stats := map[string]*sideStats{
+		attacker.Player.Id: {casualties: make(map[string]int64)},
+		defender.Player.Id: {casualties: make(map[string]int64)},
+	}
+	totalRounds := 0
+	for i := 0; i < *runs; i++ {
+		result := game.ResolveBattle(&pb.BattleInput{
+			Attacker:     &attacker.Player,
+			Defender:     &defender.Player,
+			AttackerArmy: attacker.Army,
+			Seed:         *seed + int64(i),
+			AttackerHero: buildHero(attacker.Hero),
+			DefenderHero: buildHero(defender.Hero),
+		})
+
+		stats[result.WinnerId].wins++
+		totalRounds += int(result.Rounds)
+		for id, damage := range damageByPlayer(result) {
+			stats[id].damage = append(stats[id].damage, damage)
+		}
+		for _, stack := range append(result.AttackerStacks, result.DefenderStacks...) {
+			stats[stack.OwnerId].casualties[stack.TroopType] += int64(stack.Lost)
+		}
+	}
+
+	fmt.Printf("Simulations: %d (seed %d..%d), average rounds: %.2f\n\n",
+		*runs, *seed, *seed+int64(*runs)-1, float64(totalRounds)/float64(*runs))
+	printSide("Attacker", attacker, stats[attacker.Player.Id], *runs)
+	printSide("Defender", defender, stats[defender.Player.Id], *runs)
+}
+
+// loadSide 读取一方的配置，未设置ID时使用默认ID
+func loadSide(path, defaultID string) (*sideDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var side sideDefinition
+	if err := json.Unmarshal(data, &side); err != nil {
+		return nil, err
+	}
+	if side.Player.Id == "" {
+		side.Player.Id = defaultID
+	}
+	if side.Hero != nil && game.NewHero("", side.Hero.TemplateID) == nil {
+		return nil, fmt.Errorf("unknown hero template: %s", side.Hero.TemplateID)
+	}
+	return &side, nil
+}
+
+// buildHero 按模板创建英雄并应用配置的等级和装备，每次模拟使用新的实例
+func buildHero(def *heroDefinition) *pb.Hero {
+	if def == nil {
+		return nil
+	}
+	hero := game.NewHero("hero", def.TemplateID)
+	if def.Level > 0 {
+		hero.Level = def.Level
+	}
+	for slot, equip := range def.Equipment {
+		hero.Equipment[slot] = equip
+	}
+	return hero
+}
+
+// damageByPlayer 统计双方在一场战斗中造成的总伤害
+func damageByPlayer(result *pb.BattleResult) map[string]int64 {
+	damage := map[string]int64{result.AttackerId: 0, result.DefenderId: 0}
+	for _, round := range result.RoundReports {
+		for _, action := range round.Actions {
+			damage[action.ActorId] += int64(action.Damage)
+		}
+	}
+	return damage
+}
+
+// printSide 输出一方的胜率、平均伤亡和伤害分布
+func printSide(title string, side *sideDefinition, stats *sideStats, runs int) {
+	fmt.Printf("%s %s\n", title, side.Player.Id)
+	fmt.Printf("  win rate:   %.2f%% (%d/%d)\n", float64(stats.wins)*100/float64(runs), stats.wins, runs)
+
+	fmt.Println("  average casualties:")
+	for _, troopType := range game.TroopTypes {
+		sent := side.Army[troopType]
+		if sent <= 0 {
+			continue
+		}
+		lost := float64(stats.casualties[troopType]) / float64(runs)
+		fmt.Printf("    %-9s %8.2f / %d (%.2f%%)\n", troopType, lost, sent, lost*100/float64(sent))
+	}
+
+	damage := stats.damage
+	sort.Slice(damage, func(i, j int) bool { return damage[i] < damage[j] })
+	total := int64(0)
+	for _, d := range damage {
+		total += d
+	}
+	fmt.Println("  damage dealt:")
+	fmt.Printf("    mean %.2f, min %d, p25 %d, p50 %d, p75 %d, p95 %d, max %d\n\n",
+		float64(total)/float64(len(damage)), damage[0], percentile(damage, 25), percentile(damage, 50),
+		percentile(damage, 75), percentile(damage, 95), damage[len(damage)-1])
+}
+
+// percentile 返回已排序数据的第p百分位数
+func percentile(sorted []int64, p int) int64 {
+	index := (len(sorted) - 1) * p / 100
+	return sorted[index]
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,6 +15,8 @@ import (
 	"github.com/cowpeatechnology/slg-game-server/internal/config"
 	"github.com/cowpeatechnology/slg-game-server/internal/game"
 	"github.com/cowpeatechnology/slg-game-server/internal/gateway"
+	"github.com/cowpeatechnology/slg-game-server/internal/storage"
+	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/websocket"
 )
 
@@ -29,27 +33,71 @@ func main() {
 		log.Fatalf("Failed to create actor engine: %v", err)
 	}
 
+	// Initialize Redis client
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Address,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer redisClient.Close()
+
+	// 会话令牌签名密钥，未配置时随机生成，服务重启后之前签发的令牌失效
+	sessionSecret := []byte(cfg.Server.SessionSecret)
+	if len(sessionSecret) == 0 {
+		sessionSecret = make([]byte, 32)
+		if _, err := rand.Read(sessionSecret); err != nil {
+			log.Fatalf("Failed to generate session secret: %v", err)
+		}
+		log.Println("server.sessionSecret not set, session tokens will not survive restarts")
+	}
+
 	// Initialize actors
+	storageActor := engine.Spawn(storage.NewStorageActor(redisClient), "storage")
 	gameActor := engine.Spawn(game.NewGameActor(), "game")
-	combatActor := engine.Spawn(game.NewCombatActor(), "combat")
-	gatewayActor := engine.Spawn(gateway.NewGatewayActor(), "gateway")
+	combatActor := engine.Spawn(game.NewCombatActor(time.Duration(cfg.Game.BattleTimeout)*time.Second), "combat")
+	mapActor := engine.Spawn(game.NewMapActor(cfg.World.Width, cfg.World.Height, cfg.World.Seed), "map")
+	gatewayActor := engine.Spawn(gateway.NewGatewayActor(
+		time.Duration(cfg.Server.ReconnectGrace)*time.Second,
+		cfg.Game.MessageQueueSize,
+		gateway.SlowClientPolicy(cfg.Game.SlowClientPolicy),
+	), "gateway")
+	allianceActor := engine.Spawn(game.NewAllianceActor(), "alliance")
+	accountActor := engine.Spawn(game.NewAccountActor(sessionSecret, time.Duration(cfg.Server.SessionTTL)*time.Second), "account")
 
 	// 等待Actor完全启动
 	time.Sleep(100 * time.Millisecond)
-	log.Printf("Actor PIDs started - Game: %v, Combat: %v, Gateway: %v",
-		gameActor, combatActor, gatewayActor)
+	log.Printf("Actor PIDs started - Game: %v, Combat: %v, Map: %v, Gateway: %v, Storage: %v",
+		gameActor, combatActor, mapActor, gatewayActor, storageActor)
 
 	// 设置Actor之间的PID引用
 	// 首先发送 GameActor 的 PID 给其他 Actor
-	engine.Send(gatewayActor, gameActor) // Gateway 需要知道 Game 的 PID
-	engine.Send(combatActor, gameActor)  // Combat 需要知道 Game 的 PID
+	engine.Send(gatewayActor, gameActor)  // Gateway 需要知道 Game 的 PID
+	engine.Send(combatActor, gameActor)   // Combat 需要知道 Game 的 PID
+	engine.Send(mapActor, gameActor)      // Map 需要知道 Game 的 PID
+	engine.Send(allianceActor, gameActor) // Alliance 需要知道 Game 的 PID
+	engine.Send(accountActor, gameActor)  // Account 需要知道 Game 的 PID
+
+	// 战斗、地图、联盟和账号的回复直接经 Gateway 发给玩家
+	engine.Send(combatActor, gatewayActor)
+	engine.Send(mapActor, gatewayActor)
+	engine.Send(allianceActor, gatewayActor)
+	engine.Send(accountActor, gatewayActor)
 
 	// 然后发送其他 Actor 的 PID 给 GameActor
-	engine.Send(gameActor, gatewayActor) // Game 需要知道 Gateway 的 PID
-	engine.Send(gameActor, combatActor)  // Game 需要知道 Combat 的 PID
+	engine.Send(gameActor, gatewayActor)  // Game 需要知道 Gateway 的 PID
+	engine.Send(gameActor, combatActor)   // Game 需要知道 Combat 的 PID
+	engine.Send(gameActor, mapActor)      // Game 需要知道 Map 的 PID
+	engine.Send(gameActor, allianceActor) // Game 需要知道 Alliance 的 PID
+	engine.Send(gameActor, accountActor)  // Game 需要知道 Account 的 PID
+
+	// 最后发送 StorageActor 的 PID 给需要持久化数据的 Actor
+	engine.Send(combatActor, storageActor)   // Combat 需要保存战报
+	engine.Send(gameActor, storageActor)     // Game 需要保存玩家数据
+	engine.Send(allianceActor, storageActor) // Alliance 需要加载和保存联盟数据
+	engine.Send(accountActor, storageActor)  // Account 需要读写账号和加载玩家数据
 
-	log.Printf("Actor PIDs exchanged - Game: %v, Combat: %v, Gateway: %v",
-		gameActor, combatActor, gatewayActor)
+	log.Printf("Actor PIDs exchanged - Game: %v, Combat: %v, Map: %v, Gateway: %v",
+		gameActor, combatActor, mapActor, gatewayActor)
 
 	// Initialize HTTP server
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -89,6 +137,32 @@ func main() {
 		}
 	}()
 
+	// 调试接口使用独立的监听地址，不对玩家开放
+	var adminServer *http.Server
+	if cfg.Server.AdminAddr != "" {
+		adminMux := http.NewServeMux()
+		// 各连接出站队列的深度和丢弃统计
+		adminMux.HandleFunc("/debug/queues", func(w http.ResponseWriter, r *http.Request) {
+			stats, err := engine.Request(gatewayActor, &gateway.QueueStatsRequest{}, time.Second).Result()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(stats)
+		})
+		adminServer = &http.Server{
+			Addr:    cfg.Server.AdminAddr,
+			Handler: adminMux,
+		}
+		log.Printf("Starting admin service on %s", cfg.Server.AdminAddr)
+		go func() {
+			if err := adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Admin server error: %v", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -97,4 +171,7 @@ func main() {
 	// Graceful shutdown
 	log.Println("Shutting down server...")
 	server.Close()
+	if adminServer != nil {
+		adminServer.Close()
+	}
 }
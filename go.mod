@@ -6,6 +6,7 @@ require (
 	github.com/anthdm/hollywood v1.0.5
 	github.com/go-redis/redis/v8 v8.11.5
 	github.com/gorilla/websocket v1.5.1
+	golang.org/x/crypto v0.17.0
 	google.golang.org/protobuf v1.32.0
 )
 
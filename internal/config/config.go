@@ -8,8 +8,12 @@ import (
 // Config represents the server configuration
 type Config struct {
 	Server struct {
-		Host string `json:"host"`
-		Port int    `json:"port"`
+		Host           string `json:"host"`
+		Port           int    `json:"port"`
+		SessionSecret  string `json:"sessionSecret"`  // 会话令牌签名密钥，为空时启动时随机生成
+		SessionTTL     int    `json:"sessionTTL"`     // 会话令牌有效期（秒）
+		ReconnectGrace int    `json:"reconnectGrace"` // 断线后保留玩家绑定并缓存消息的时间（秒）
+		AdminAddr      string `json:"adminAddr"`      // 调试接口的监听地址，与游戏端口分开，为空时不启动
 	} `json:"server"`
 	Redis struct {
 		Address  string `json:"address"`
@@ -17,10 +21,16 @@ type Config struct {
 		DB       int    `json:"db"`
 	} `json:"redis"`
 	Game struct {
-		MaxPlayers       int `json:"maxPlayers"`
-		BattleTimeout    int `json:"battleTimeout"`
-		MessageQueueSize int `json:"messageQueueSize"`
+		MaxPlayers       int    `json:"maxPlayers"`
+		BattleTimeout    int    `json:"battleTimeout"`
+		MessageQueueSize int    `json:"messageQueueSize"` // 每个连接的出站消息队列长度
+		SlowClientPolicy string `json:"slowClientPolicy"` // 出站队列已满时的处理方式：disconnect 或 drop
 	} `json:"game"`
+	World struct {
+		Width  int32 `json:"width"`
+		Height int32 `json:"height"`
+		Seed   int64 `json:"seed"`
+	} `json:"world"`
 }
 
 // LoadConfig loads the configuration from a JSON file
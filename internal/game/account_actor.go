@@ -0,0 +1,407 @@
+package game
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/storage"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	minUsernameLength      = 3
+	maxUsernameLength      = 20
+	minPasswordLength      = 6
+	maxPasswordLength      = 64
+	passwordSaltSize       = 16     // 随机盐的字节数
+	passwordHashSize       = 32     // 密码哈希的字节数
+	passwordHashIterations = 100000 // PBKDF2 迭代次数
+)
+
+// errInvalidCredentials 用户名不存在和密码错误返回相同的提示
+const errInvalidCredentials = "invalid username or password"
+
+// pendingLogin 等待 StorageActor 返回或密码哈希计算完成的注册、登录或重连请求
+type pendingLogin struct {
+	sessionID string
+	username  string
+	password  string
+	playerID  string // 重连时会话令牌中的玩家ID
+	register  bool   // 注册请求，用户名未被占用时才创建账号
+	account   *pb.Account
+}
+
+// passwordHashed 后台协程完成密码哈希计算后发回 AccountActor 的结果：
+// 注册时 account 为新建的账号，登录时 verified 表示密码是否正确
+type passwordHashed struct {
+	login    *pendingLogin
+	account  *pb.Account
+	verified bool
+	err      error
+}
+
+// accountLogin 账号验证通过，AccountActor 通知 GameActor 在该连接上登录玩家；
+// player 为存储中的玩家数据，新注册的账号为空
+type accountLogin struct {
+	sessionID string
+	account   *pb.Account
+	player    *pb.PlayerData
+	token     string // 新签发的会话令牌
+}
+
+// AccountActor 处理账号注册、登录和断线重连：校验用户名和密码或会话令牌，
+// 通过 StorageActor 读写账号和玩家数据。密码哈希在后台协程中计算，不阻塞 AccountActor 和 GameActor
+type AccountActor struct {
+	engine     *actor.Engine
+	gamePID    *actor.PID // 游戏Actor的引用
+	gatewayPID *actor.PID // 网关Actor的引用，登录失败直接回复发起请求的连接
+	storagePID *actor.PID // 存储Actor的引用
+
+	tokenSecret []byte        // 会话令牌的签名密钥
+	tokenTTL    time.Duration // 会话令牌的有效期
+
+	pending    map[string]*pendingLogin // 等待存储结果的请求，key为请求ID
+	requestSeq int64                    // 请求序号，用于生成请求ID
+	hashSlots  chan struct{}            // 限制同时计算密码哈希的协程数量
+}
+
+// NewAccountActor creates a new Account Actor
+func NewAccountActor(tokenSecret []byte, tokenTTL time.Duration) actor.Producer {
+	if tokenTTL <= 0 {
+		tokenTTL = defaultSessionTTL
+	}
+	return func() actor.Receiver {
+		return &AccountActor{
+			tokenSecret: tokenSecret,
+			tokenTTL:    tokenTTL,
+			pending:     make(map[string]*pendingLogin),
+			hashSlots:   make(chan struct{}, runtime.NumCPU()),
+		}
+	}
+}
+
+// Receive handles incoming messages
+func (a *AccountActor) Receive(ctx *actor.Context) {
+	switch msg := ctx.Message().(type) {
+	case actor.Started:
+		log.Println("[AccountActor] Started")
+		a.engine = ctx.Engine()
+
+	case actor.Stopped:
+		log.Println("[AccountActor] Stopped")
+
+	case *actor.PID:
+		if msg.ID != "" && strings.Contains(msg.ID, "game/") {
+			a.gamePID = msg
+			log.Printf("[AccountActor] Received GameActor PID: %v", msg)
+		}
+		if msg.ID != "" && strings.Contains(msg.ID, "gateway/") {
+			a.gatewayPID = msg
+			log.Printf("[AccountActor] Received GatewayActor PID: %v", msg)
+		}
+		if msg.ID != "" && strings.Contains(msg.ID, "storage/") {
+			a.storagePID = msg
+			log.Printf("[AccountActor] Received StorageActor PID: %v", msg)
+		}
+
+	case *storage.StorageResponseMessage:
+		a.handleStorageResponse(ctx, msg)
+
+	case *passwordHashed:
+		a.handlePasswordHashed(ctx, msg)
+
+	case *pb.GameMessage:
+		a.handleAccountMessage(ctx, msg)
+	}
+}
+
+// handleAccountMessage 处理 register、login 和 resume 请求
+func (a *AccountActor) handleAccountMessage(ctx *actor.Context, msg *pb.GameMessage) {
+	if msg.Type == "resume" {
+		a.handleResume(ctx, msg)
+		return
+	}
+
+	var req pb.LoginRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		a.fail(ctx, msg.SessionId, "invalid login request format")
+		return
+	}
+	if a.storagePID == nil {
+		a.fail(ctx, msg.SessionId, "account service not available")
+		return
+	}
+	username := strings.ToLower(strings.TrimSpace(req.Username))
+
+	switch msg.Type {
+	case "register":
+		if err := validateCredentials(username, req.Password); err != nil {
+			a.fail(ctx, msg.SessionId, err.Error())
+			return
+		}
+		// 先确认用户名未被占用，再计算密码哈希
+		a.request(ctx, &pendingLogin{sessionID: msg.SessionId, username: username, password: req.Password, register: true},
+			"get_account", username, nil)
+
+	case "login":
+		if username == "" || req.Password == "" {
+			a.fail(ctx, msg.SessionId, errInvalidCredentials)
+			return
+		}
+		a.request(ctx, &pendingLogin{sessionID: msg.SessionId, username: username, password: req.Password},
+			"get_account", username, nil)
+
+	default:
+		a.fail(ctx, msg.SessionId, fmt.Sprintf("未知的消息类型: %s", msg.Type))
+	}
+}
+
+// handleResume 校验会话令牌，账号仍然存在时在新连接上恢复令牌对应的玩家
+func (a *AccountActor) handleResume(ctx *actor.Context, msg *pb.GameMessage) {
+	var req pb.ResumeRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		a.fail(ctx, msg.SessionId, "invalid resume request format")
+		return
+	}
+	if a.storagePID == nil {
+		a.fail(ctx, msg.SessionId, "account service not available")
+		return
+	}
+	claims, err := parseSessionToken(a.tokenSecret, req.SessionToken, time.Now())
+	if err != nil {
+		a.fail(ctx, msg.SessionId, err.Error())
+		return
+	}
+	a.request(ctx, &pendingLogin{sessionID: msg.SessionId, username: claims.Username, playerID: claims.PlayerID},
+		"get_account", claims.Username, nil)
+}
+
+// request 记录等待中的请求并发给 StorageActor，使用 ctx.Send 以便 StorageActor 回复
+func (a *AccountActor) request(ctx *actor.Context, login *pendingLogin, requestType, key string, data interface{}) {
+	a.requestSeq++
+	requestID := fmt.Sprintf("account_%d", a.requestSeq)
+	a.pending[requestID] = login
+	ctx.Send(a.storagePID, &storage.StorageRequestMessage{
+		Type:      requestType,
+		Key:       key,
+		Data:      data,
+		RequestID: requestID,
+	})
+}
+
+// handleStorageResponse 按存储结果继续注册或登录流程
+func (a *AccountActor) handleStorageResponse(ctx *actor.Context, msg *storage.StorageResponseMessage) {
+	login, ok := a.pending[msg.RequestID]
+	if !ok {
+		return
+	}
+	delete(a.pending, msg.RequestID)
+
+	switch msg.Type {
+	case "create_account":
+		if errors.Is(msg.Error, storage.ErrAccountExists) {
+			a.fail(ctx, login.sessionID, "username already taken")
+			return
+		}
+		if msg.Error != nil {
+			log.Printf("[AccountActor] Failed to create account %s: %v", login.username, msg.Error)
+			a.fail(ctx, login.sessionID, "failed to create account")
+			return
+		}
+		log.Printf("[AccountActor] Account %s registered as %s", login.username, login.account.PlayerId)
+		a.complete(ctx, login, nil)
+
+	case "get_account":
+		if login.register {
+			a.checkUsernameFree(ctx, login, msg.Error)
+			return
+		}
+		if errors.Is(msg.Error, storage.ErrNotFound) {
+			a.fail(ctx, login.sessionID, errInvalidCredentials)
+			return
+		}
+		if msg.Error != nil {
+			log.Printf("[AccountActor] Failed to load account %s: %v", login.username, msg.Error)
+			a.fail(ctx, login.sessionID, "account service not available")
+			return
+		}
+		account, ok := msg.Data.(*pb.Account)
+		if !ok {
+			a.fail(ctx, login.sessionID, errInvalidCredentials)
+			return
+		}
+		login.account = account
+		// 重连时令牌已验证身份，只需确认账号仍对应令牌中的玩家
+		if login.playerID != "" {
+			if account.PlayerId != login.playerID {
+				a.fail(ctx, login.sessionID, errInvalidSessionToken.Error())
+				return
+			}
+			a.request(ctx, login, "get_player", account.PlayerId, nil)
+			return
+		}
+		a.hashPassword(ctx, login)
+
+	case "get_player":
+		// 账号存在但没有保存过玩家数据时由 GameActor 创建新玩家；
+		// 其他读取错误时拒绝登录，避免新玩家数据覆盖已保存的数据
+		player, _ := msg.Data.(*pb.PlayerData)
+		if msg.Error != nil && !errors.Is(msg.Error, storage.ErrNotFound) {
+			log.Printf("[AccountActor] Failed to load player %s: %v", login.account.PlayerId, msg.Error)
+			a.fail(ctx, login.sessionID, "account service not available")
+			return
+		}
+		a.complete(ctx, login, player)
+	}
+}
+
+// checkUsernameFree 注册时用户名不存在才计算密码哈希并创建账号
+func (a *AccountActor) checkUsernameFree(ctx *actor.Context, login *pendingLogin, err error) {
+	switch {
+	case err == nil:
+		a.fail(ctx, login.sessionID, "username already taken")
+	case errors.Is(err, storage.ErrNotFound):
+		a.hashPassword(ctx, login)
+	default:
+		log.Printf("[AccountActor] Failed to check username %s: %v", login.username, err)
+		a.fail(ctx, login.sessionID, "account service not available")
+	}
+}
+
+// hashPassword 在后台协程中为注册创建账号或校验登录密码，完成后以 passwordHashed 发回自身
+func (a *AccountActor) hashPassword(ctx *actor.Context, login *pendingLogin) {
+	pid := ctx.PID()
+	engine := ctx.Engine()
+	password := login.password
+	login.password = ""
+	go func() {
+		a.hashSlots <- struct{}{}
+		defer func() { <-a.hashSlots }()
+
+		result := &passwordHashed{login: login}
+		if login.register {
+			result.account, result.err = newAccount(login.username, password)
+		} else {
+			result.verified = verifyPassword(login.account, password)
+		}
+		engine.Send(pid, result)
+	}()
+}
+
+// handlePasswordHashed 密码哈希计算完成：注册时保存账号，登录时密码正确则加载玩家数据
+func (a *AccountActor) handlePasswordHashed(ctx *actor.Context, msg *passwordHashed) {
+	login := msg.login
+	if login.register {
+		if msg.err != nil {
+			log.Printf("[AccountActor] Failed to create account: %v", msg.err)
+			a.fail(ctx, login.sessionID, "failed to create account")
+			return
+		}
+		login.account = msg.account
+		// 并发注册同一用户名时由 create_account 保证只有一个成功
+		a.request(ctx, login, "create_account", login.username, msg.account)
+		return
+	}
+	if !msg.verified {
+		a.fail(ctx, login.sessionID, errInvalidCredentials)
+		return
+	}
+	a.request(ctx, login, "get_player", login.account.PlayerId, nil)
+}
+
+// complete 验证通过，签发新的会话令牌并交给 GameActor 登录玩家
+func (a *AccountActor) complete(ctx *actor.Context, login *pendingLogin, player *pb.PlayerData) {
+	if a.gamePID == nil {
+		return
+	}
+	token, err := signSessionToken(a.tokenSecret, &sessionClaims{
+		Username:  login.account.Username,
+		PlayerID:  login.account.PlayerId,
+		ExpiresAt: time.Now().Add(a.tokenTTL).Unix(),
+	})
+	if err != nil {
+		log.Printf("[AccountActor] Failed to sign session token: %v", err)
+		a.fail(ctx, login.sessionID, "failed to create session")
+		return
+	}
+	ctx.Engine().Send(a.gamePID, &accountLogin{
+		sessionID: login.sessionID,
+		account:   login.account,
+		player:    player,
+		token:     token,
+	})
+}
+
+// fail 回复登录失败，GatewayActor 按 session_id 发给发起请求的连接
+func (a *AccountActor) fail(ctx *actor.Context, sessionID, message string) {
+	if a.gatewayPID == nil {
+		log.Printf("[AccountActor] 无法发送消息: gatewayPID为空")
+		return
+	}
+	data, err := json.Marshal(&pb.LoginResponse{Success: false, Message: message})
+	if err != nil {
+		log.Printf("[AccountActor] Failed to marshal login response: %v", err)
+		return
+	}
+	ctx.Engine().Send(a.gatewayPID, &pb.GameMessage{
+		Type:      "login_response",
+		SessionId: sessionID,
+		Payload:   data,
+	})
+}
+
+// validateCredentials 检查注册的用户名和密码格式：用户名只包含小写字母、数字和下划线
+func validateCredentials(username, password string) error {
+	if n := len(username); n < minUsernameLength || n > maxUsernameLength {
+		return fmt.Errorf("username must be %d-%d characters", minUsernameLength, maxUsernameLength)
+	}
+	for _, r := range username {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_') {
+			return fmt.Errorf("username may only contain letters, digits and underscores")
+		}
+	}
+	if n := utf8.RuneCountInString(password); n < minPasswordLength || n > maxPasswordLength {
+		return fmt.Errorf("password must be %d-%d characters", minPasswordLength, maxPasswordLength)
+	}
+	return nil
+}
+
+// newAccount 生成随机盐和玩家ID，保存密码的加盐哈希
+func newAccount(username, password string) (*pb.Account, error) {
+	salt := make([]byte, passwordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	playerID, err := newPlayerID()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Account{
+		Username:       username,
+		PlayerId:       playerID,
+		Salt:           salt,
+		PasswordHash:   pbkdf2.Key([]byte(password), salt, passwordHashIterations, passwordHashSize, sha256.New),
+		HashIterations: passwordHashIterations,
+		CreatedAt:      time.Now().Unix(),
+	}, nil
+}
+
+// verifyPassword 按账号保存的盐和迭代次数计算哈希，以固定时间比较
+func verifyPassword(account *pb.Account, password string) bool {
+	if account.HashIterations <= 0 || len(account.PasswordHash) == 0 {
+		return false
+	}
+	hash := pbkdf2.Key([]byte(password), account.Salt, int(account.HashIterations), len(account.PasswordHash), sha256.New)
+	return hmac.Equal(hash, account.PasswordHash)
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"log"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// defaultBattleTimeout 未配置 Game.BattleTimeout 时使用的战斗超时时间
+const defaultBattleTimeout = 30 * time.Second
+
+// activeBattle 进行中的战斗，从受理开始直到 GameActor 确认结果已应用
+type activeBattle struct {
+	battleID   string
+	attackerID string
+	defenderID string
+	startedAt  time.Time
+	status     pb.BattleStatus
+	timer      *time.Timer
+}
+
+// battleSettled GameActor 应用完战斗结果后发给 CombatActor 的确认
+type battleSettled struct {
+	battleID string
+}
+
+// battleTimeout 战斗超时计时结束时 CombatActor 发给自身的消息
+type battleTimeout struct {
+	battleID string
+}
+
+// startBattle 登记一场新的战斗并开始超时计时
+func (a *CombatActor) startBattle(ctx *actor.Context, attackerID, defenderID string) *activeBattle {
+	battle := &activeBattle{
+		battleID:   a.nextBattleID(),
+		attackerID: attackerID,
+		defenderID: defenderID,
+		startedAt:  time.Now(),
+		status:     pb.BattleStatus_BATTLE_STATUS_PENDING,
+	}
+
+	pid := ctx.PID()
+	engine := ctx.Engine()
+	battle.timer = time.AfterFunc(a.battleTimeout, func() {
+		engine.Send(pid, &battleTimeout{battleID: battle.battleID})
+	})
+
+	a.activeBattles[battle.battleID] = battle
+	a.activePlayers[attackerID] = battle.battleID
+	a.activePlayers[defenderID] = battle.battleID
+	return battle
+}
+
+// finishBattle 结束战斗并移除进行中的记录
+func (a *CombatActor) finishBattle(battle *activeBattle, status pb.BattleStatus) {
+	battle.status = status
+	battle.timer.Stop()
+	delete(a.activeBattles, battle.battleID)
+	for _, id := range []string{battle.attackerID, battle.defenderID} {
+		if a.activePlayers[id] == battle.battleID {
+			delete(a.activePlayers, id)
+		}
+	}
+}
+
+// inBattle 检查玩家是否有进行中的战斗
+func (a *CombatActor) inBattle(playerID string) bool {
+	_, ok := a.activePlayers[playerID]
+	return ok
+}
+
+// handleBattleSettled 处理 GameActor 对战斗结果已应用的确认
+func (a *CombatActor) handleBattleSettled(ctx *actor.Context, msg *battleSettled) {
+	battle, ok := a.activeBattles[msg.battleID]
+	if !ok {
+		// 已超时的战斗
+		return
+	}
+	a.finishBattle(battle, pb.BattleStatus_BATTLE_STATUS_SETTLED)
+	if result, ok := a.battles[battle.battleID]; ok {
+		result.Status = pb.BattleStatus_BATTLE_STATUS_SETTLED
+		a.updateBattle(ctx, result)
+	}
+	log.Printf("[CombatActor] Battle settled: %s, duration=%v", battle.battleID, time.Since(battle.startedAt))
+}
+
+// handleBattleTimeout 战斗超时仍未完成时，通知双方玩家
+func (a *CombatActor) handleBattleTimeout(ctx *actor.Context, msg *battleTimeout) {
+	battle, ok := a.activeBattles[msg.battleID]
+	if !ok {
+		return
+	}
+	log.Printf("[CombatActor] Battle timed out: %s, status=%v, attacker=%s, defender=%s",
+		battle.battleID, battle.status, battle.attackerID, battle.defenderID)
+	a.finishBattle(battle, pb.BattleStatus_BATTLE_STATUS_TIMEOUT)
+
+	// 已结算的战报同步标记为超时，未结算的只记录双方和时间
+	result, ok := a.battles[battle.battleID]
+	if ok {
+		result.Status = pb.BattleStatus_BATTLE_STATUS_TIMEOUT
+		a.updateBattle(ctx, result)
+	} else {
+		result = &pb.BattleResult{
+			BattleId:   battle.battleID,
+			AttackerId: battle.attackerID,
+			DefenderId: battle.defenderID,
+			Timestamp:  time.Now().Unix(),
+			Status:     pb.BattleStatus_BATTLE_STATUS_TIMEOUT,
+		}
+		a.recordBattle(ctx, result)
+	}
+
+	if a.gamePID != nil {
+		ctx.Engine().Send(a.gamePID, &battleTimedOut{result: &pb.BattleResult{
+			BattleId:   battle.battleID,
+			AttackerId: battle.attackerID,
+			DefenderId: battle.defenderID,
+			Timestamp:  result.Timestamp,
+			Status:     pb.BattleStatus_BATTLE_STATUS_TIMEOUT,
+		}})
+	}
+}
@@ -0,0 +1,480 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/storage"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	maxAllianceMembers    = 50  // 联盟成员数量上限
+	maxAllianceNameLength = 20  // 联盟名称最大字符数
+	maxAllianceTagLength  = 4   // 联盟简称最大字符数
+	maxAnnouncementLength = 500 // 联盟公告最大字符数
+	maxAllianceChatLength = 200 // 联盟聊天单条消息最大字符数
+	minAllianceNameLength = 2   // 联盟名称、简称最小字符数
+	allianceIDPrefix      = "alliance_"
+	allianceLoadRequestID = "alliance_load" // 启动时加载联盟数据的请求ID
+)
+
+// allianceRequiredRank 各联盟操作所需的最低职位，未列出的操作所有人可用
+var allianceRequiredRank = map[string]pb.AllianceRank{
+	"alliance_kick":     pb.AllianceRank_ALLIANCE_RANK_OFFICER,
+	"alliance_announce": pb.AllianceRank_ALLIANCE_RANK_OFFICER,
+	"alliance_promote":  pb.AllianceRank_ALLIANCE_RANK_LEADER,
+}
+
+// allianceCommand GameActor 转发的联盟请求，附带发起者的名字
+type allianceCommand struct {
+	msg  *pb.GameMessage
+	name string
+}
+
+// allianceChanged 玩家加入或离开联盟，AllianceActor 通知 GameActor 更新玩家数据；
+// allianceID 为空表示离开联盟
+type allianceChanged struct {
+	playerID   string
+	allianceID string
+}
+
+// allianceReconcile GameActor 从存储加载玩家后发给 AllianceActor，
+// 玩家离线期间被踢出或联盟解散时，保存的 allianceID 已经过期
+type allianceReconcile struct {
+	playerID   string
+	allianceID string
+}
+
+// AllianceActor 持有全部联盟数据，是联盟成员、职位和公告的唯一修改者。
+// 联盟变化后交给 StorageActor 持久化，启动时从 StorageActor 加载
+type AllianceActor struct {
+	engine     *actor.Engine
+	gamePID    *actor.PID // 游戏Actor的引用
+	gatewayPID *actor.PID // 网关Actor的引用，联盟消息直接发给玩家
+	storagePID *actor.PID // 存储Actor的引用
+
+	alliances map[string]*pb.Alliance // 全部联盟，key为联盟ID
+	memberOf  map[string]string       // 玩家所在的联盟，key为玩家ID
+	seq       int64                   // 联盟序号，用于生成联盟ID
+
+	loaded     bool                 // 是否已从 StorageActor 加载联盟数据
+	reconciles []*allianceReconcile // 加载完成前收到的核对请求
+	commands   []*allianceCommand   // 加载完成前收到的联盟请求
+}
+
+// NewAllianceActor creates a new Alliance Actor
+func NewAllianceActor() actor.Producer {
+	return func() actor.Receiver {
+		return &AllianceActor{
+			alliances: make(map[string]*pb.Alliance),
+			memberOf:  make(map[string]string),
+		}
+	}
+}
+
+// Receive handles incoming messages
+func (a *AllianceActor) Receive(ctx *actor.Context) {
+	switch msg := ctx.Message().(type) {
+	case actor.Started:
+		log.Println("[AllianceActor] Started")
+		a.engine = ctx.Engine()
+
+	case actor.Stopped:
+		log.Println("[AllianceActor] Stopped")
+
+	case *actor.PID:
+		if msg.ID != "" && strings.Contains(msg.ID, "game/") {
+			a.gamePID = msg
+			log.Printf("[AllianceActor] Received GameActor PID: %v", msg)
+		}
+		if msg.ID != "" && strings.Contains(msg.ID, "gateway/") {
+			a.gatewayPID = msg
+			log.Printf("[AllianceActor] Received GatewayActor PID: %v", msg)
+		}
+		if msg.ID != "" && strings.Contains(msg.ID, "storage/") {
+			first := a.storagePID == nil
+			a.storagePID = msg
+			log.Printf("[AllianceActor] Received StorageActor PID: %v", msg)
+			// 使用 ctx.Send 以便 StorageActor 回复加载结果
+			if first {
+				ctx.Send(a.storagePID, &storage.StorageRequestMessage{
+					Type:      "list_alliances",
+					RequestID: allianceLoadRequestID,
+				})
+			}
+		}
+
+	case *storage.StorageResponseMessage:
+		a.handleStorageResponse(ctx, msg)
+
+	case *allianceReconcile:
+		a.handleAllianceReconcile(ctx, msg)
+
+	case *allianceCommand:
+		a.handleAllianceCommand(ctx, msg)
+	}
+}
+
+// handleStorageResponse 加载 StorageActor 返回的联盟数据
+func (a *AllianceActor) handleStorageResponse(ctx *actor.Context, msg *storage.StorageResponseMessage) {
+	if msg.RequestID != allianceLoadRequestID {
+		return
+	}
+	if msg.Error != nil {
+		log.Printf("[AllianceActor] 加载联盟数据失败: %v", msg.Error)
+		return
+	}
+	alliances, _ := msg.Data.([]*pb.Alliance)
+	for _, alliance := range alliances {
+		a.alliances[alliance.Id] = alliance
+		for _, member := range alliance.Members {
+			a.memberOf[member.PlayerId] = alliance.Id
+		}
+	}
+	log.Printf("[AllianceActor] Loaded %d alliances", len(alliances))
+
+	a.loaded = true
+	for _, msg := range a.reconciles {
+		a.handleAllianceReconcile(ctx, msg)
+	}
+	a.reconciles = nil
+	for _, cmd := range a.commands {
+		a.handleAllianceCommand(ctx, cmd)
+	}
+	a.commands = nil
+}
+
+// handleAllianceReconcile 核对玩家保存的联盟与实际所在的联盟，不一致时通知 GameActor 更新
+func (a *AllianceActor) handleAllianceReconcile(ctx *actor.Context, msg *allianceReconcile) {
+	if !a.loaded {
+		a.reconciles = append(a.reconciles, msg)
+		return
+	}
+	if actual := a.memberOf[msg.playerID]; actual != msg.allianceID {
+		log.Printf("[AllianceActor] Player %s alliance %q is stale, now %q", msg.playerID, msg.allianceID, actual)
+		a.notifyGame(ctx, msg.playerID, actual)
+	}
+}
+
+// handleAllianceCommand 处理联盟相关的请求。加载完成前联盟数据不完整，
+// 名称检查和成员关系都可能出错，请求在加载完成后按顺序处理
+func (a *AllianceActor) handleAllianceCommand(ctx *actor.Context, cmd *allianceCommand) {
+	if !a.loaded {
+		a.commands = append(a.commands, cmd)
+		return
+	}
+	msg := cmd.msg
+	var req pb.AllianceRequest
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, &req); err != nil {
+			reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid alliance request format"))
+			return
+		}
+	}
+
+	var err error
+	switch msg.Type {
+	case "alliance_create":
+		err = a.createAlliance(ctx, msg.Id, cmd.name, &req)
+	case "alliance_join":
+		err = a.joinAlliance(ctx, msg.Id, cmd.name, req.AllianceId)
+	case "alliance_list":
+		sendToPlayer(ctx, a.gatewayPID, msg.Id, "alliance_list_response", a.allianceList())
+	default:
+		err = a.handleMemberCommand(ctx, msg.Id, msg.Type, &req)
+	}
+	if err != nil {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, err)
+	}
+}
+
+// handleMemberCommand 处理需要已加入联盟的请求，先检查职位权限
+func (a *AllianceActor) handleMemberCommand(ctx *actor.Context, playerID, msgType string, req *pb.AllianceRequest) error {
+	alliance, member := a.membership(playerID)
+	if alliance == nil {
+		return newGameError(ErrCodeNotInAlliance, "not in an alliance")
+	}
+	if required, ok := allianceRequiredRank[msgType]; ok && member.Rank < required {
+		return newGameError(ErrCodePermissionDenied, "%s requires rank %s", msgType, required)
+	}
+
+	switch msgType {
+	case "alliance_leave":
+		a.removeMember(ctx, alliance, playerID)
+	case "alliance_kick":
+		return a.kickMember(ctx, alliance, member, req.PlayerId)
+	case "alliance_promote":
+		return a.promoteMember(ctx, alliance, member, req.PlayerId, req.Rank)
+	case "alliance_members":
+		sendToPlayer(ctx, a.gatewayPID, playerID, "alliance_members_response", alliance)
+	case "alliance_announce":
+		return a.announce(ctx, alliance, member, req.Content)
+	case "alliance_chat":
+		return a.chat(ctx, alliance, member, req.Content)
+	default:
+		return fmt.Errorf("未知的消息类型: %s", msgType)
+	}
+	return nil
+}
+
+// createAlliance 创建联盟，创建者成为盟主
+func (a *AllianceActor) createAlliance(ctx *actor.Context, playerID, name string, req *pb.AllianceRequest) error {
+	if _, joined := a.memberOf[playerID]; joined {
+		return newGameError(ErrCodeAlreadyInAlliance, "already in an alliance")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Tag = strings.TrimSpace(req.Tag)
+	if n := utf8.RuneCountInString(req.Name); n < minAllianceNameLength || n > maxAllianceNameLength {
+		return newGameError(ErrCodeInvalidRequest, "alliance name must be %d-%d characters",
+			minAllianceNameLength, maxAllianceNameLength)
+	}
+	if n := utf8.RuneCountInString(req.Tag); n < minAllianceNameLength || n > maxAllianceTagLength {
+		return newGameError(ErrCodeInvalidRequest, "alliance tag must be %d-%d characters",
+			minAllianceNameLength, maxAllianceTagLength)
+	}
+	for _, other := range a.alliances {
+		if strings.EqualFold(other.Name, req.Name) || strings.EqualFold(other.Tag, req.Tag) {
+			return newGameError(ErrCodeAllianceNameTaken, "alliance name or tag already taken")
+		}
+	}
+
+	now := time.Now().Unix()
+	a.seq++
+	alliance := &pb.Alliance{
+		Id:        fmt.Sprintf("%s%d_%d", allianceIDPrefix, now, a.seq),
+		Name:      req.Name,
+		Tag:       req.Tag,
+		LeaderId:  playerID,
+		CreatedAt: now,
+	}
+	a.alliances[alliance.Id] = alliance
+	a.addMember(ctx, alliance, playerID, name, pb.AllianceRank_ALLIANCE_RANK_LEADER)
+	log.Printf("[AllianceActor] Player %s created alliance %s [%s]", playerID, alliance.Id, alliance.Tag)
+	return nil
+}
+
+// joinAlliance 加入联盟，成为普通成员
+func (a *AllianceActor) joinAlliance(ctx *actor.Context, playerID, name, allianceID string) error {
+	if _, joined := a.memberOf[playerID]; joined {
+		return newGameError(ErrCodeAlreadyInAlliance, "already in an alliance")
+	}
+	alliance, ok := a.alliances[allianceID]
+	if !ok {
+		return newGameError(ErrCodeAllianceNotFound, "alliance not found: %s", allianceID)
+	}
+	if len(alliance.Members) >= maxAllianceMembers {
+		return newGameError(ErrCodeAllianceFull, "alliance is full: max %d members", maxAllianceMembers)
+	}
+	a.addMember(ctx, alliance, playerID, name, pb.AllianceRank_ALLIANCE_RANK_MEMBER)
+	log.Printf("[AllianceActor] Player %s joined alliance %s", playerID, alliance.Id)
+	return nil
+}
+
+// kickMember 踢出职位低于自己的成员
+func (a *AllianceActor) kickMember(ctx *actor.Context, alliance *pb.Alliance, kicker *pb.AllianceMember, targetID string) error {
+	target := findAllianceMember(alliance, targetID)
+	if target == nil {
+		return newGameError(ErrCodePlayerNotFound, "player %s is not a member", targetID)
+	}
+	if target.Rank >= kicker.Rank {
+		return newGameError(ErrCodePermissionDenied, "can only kick members with a lower rank")
+	}
+	a.removeMember(ctx, alliance, targetID)
+	log.Printf("[AllianceActor] Player %s kicked %s from alliance %s", kicker.PlayerId, targetID, alliance.Id)
+	return nil
+}
+
+// promoteMember 盟主将成员提升为官员或任命为新盟主，任命新盟主时转让盟主，原盟主成为官员
+func (a *AllianceActor) promoteMember(ctx *actor.Context, alliance *pb.Alliance, leader *pb.AllianceMember, targetID string, rank pb.AllianceRank) error {
+	target := findAllianceMember(alliance, targetID)
+	if target == nil || target == leader {
+		return newGameError(ErrCodePlayerNotFound, "player %s is not a member", targetID)
+	}
+	if rank != pb.AllianceRank_ALLIANCE_RANK_OFFICER && rank != pb.AllianceRank_ALLIANCE_RANK_LEADER {
+		return newGameError(ErrCodeInvalidRequest, "can only promote to officer or leader")
+	}
+	if rank <= target.Rank {
+		return newGameError(ErrCodeInvalidRequest, "player %s is already %s", targetID, target.Rank)
+	}
+
+	if rank == pb.AllianceRank_ALLIANCE_RANK_LEADER {
+		leader.Rank = pb.AllianceRank_ALLIANCE_RANK_OFFICER
+		alliance.LeaderId = target.PlayerId
+	}
+	target.Rank = rank
+	log.Printf("[AllianceActor] Player %s set %s to %s in alliance %s", leader.PlayerId, targetID, rank, alliance.Id)
+	a.saveAlliance(ctx, alliance)
+	a.broadcastUpdate(ctx, alliance)
+	return nil
+}
+
+// announce 发布联盟公告并推送给全部成员
+func (a *AllianceActor) announce(ctx *actor.Context, alliance *pb.Alliance, member *pb.AllianceMember, content string) error {
+	if utf8.RuneCountInString(content) > maxAnnouncementLength {
+		return newGameError(ErrCodeInvalidRequest, "announcement too long: max %d characters", maxAnnouncementLength)
+	}
+	now := time.Now().Unix()
+	alliance.Announcement = content
+	alliance.AnnouncementAt = now
+	a.saveAlliance(ctx, alliance)
+	a.broadcast(ctx, alliance, "alliance_announcement", &pb.AllianceChat{
+		AllianceId: alliance.Id,
+		SenderId:   member.PlayerId,
+		SenderName: member.Name,
+		Content:    content,
+		Timestamp:  now,
+	})
+	return nil
+}
+
+// chat 联盟频道聊天，消息只发给联盟成员，不做持久化
+func (a *AllianceActor) chat(ctx *actor.Context, alliance *pb.Alliance, member *pb.AllianceMember, content string) error {
+	if content == "" || utf8.RuneCountInString(content) > maxAllianceChatLength {
+		return newGameError(ErrCodeInvalidRequest, "chat message must be 1-%d characters", maxAllianceChatLength)
+	}
+	a.broadcast(ctx, alliance, "alliance_chat_message", &pb.AllianceChat{
+		AllianceId: alliance.Id,
+		SenderId:   member.PlayerId,
+		SenderName: member.Name,
+		Content:    content,
+		Timestamp:  time.Now().Unix(),
+	})
+	return nil
+}
+
+// addMember 将玩家加入联盟并通知全部成员
+func (a *AllianceActor) addMember(ctx *actor.Context, alliance *pb.Alliance, playerID, name string, rank pb.AllianceRank) {
+	alliance.Members = append(alliance.Members, &pb.AllianceMember{
+		PlayerId: playerID,
+		Name:     name,
+		Rank:     rank,
+		JoinedAt: time.Now().Unix(),
+	})
+	alliance.MemberCount = int32(len(alliance.Members))
+	a.memberOf[playerID] = alliance.Id
+	a.notifyGame(ctx, playerID, alliance.Id)
+	a.saveAlliance(ctx, alliance)
+	a.broadcastUpdate(ctx, alliance)
+}
+
+// removeMember 将玩家移出联盟。盟主离开时由职位最高、加入最早的成员继任，
+// 最后一名成员离开时解散联盟
+func (a *AllianceActor) removeMember(ctx *actor.Context, alliance *pb.Alliance, playerID string) {
+	for i, member := range alliance.Members {
+		if member.PlayerId == playerID {
+			alliance.Members = append(alliance.Members[:i], alliance.Members[i+1:]...)
+			break
+		}
+	}
+	alliance.MemberCount = int32(len(alliance.Members))
+	delete(a.memberOf, playerID)
+	a.notifyGame(ctx, playerID, "")
+	sendToPlayer(ctx, a.gatewayPID, playerID, "alliance_left", &pb.Alliance{Id: alliance.Id, Name: alliance.Name, Tag: alliance.Tag})
+
+	if len(alliance.Members) == 0 {
+		delete(a.alliances, alliance.Id)
+		if a.storagePID != nil {
+			ctx.Engine().Send(a.storagePID, &storage.StorageRequestMessage{
+				Type: "delete_alliance",
+				Key:  alliance.Id,
+			})
+		}
+		log.Printf("[AllianceActor] Alliance %s disbanded", alliance.Id)
+		return
+	}
+
+	if alliance.LeaderId == playerID {
+		successor := alliance.Members[0]
+		for _, member := range alliance.Members[1:] {
+			if member.Rank > successor.Rank || (member.Rank == successor.Rank && member.JoinedAt < successor.JoinedAt) {
+				successor = member
+			}
+		}
+		successor.Rank = pb.AllianceRank_ALLIANCE_RANK_LEADER
+		alliance.LeaderId = successor.PlayerId
+		log.Printf("[AllianceActor] %s is the new leader of alliance %s", successor.PlayerId, alliance.Id)
+	}
+	a.saveAlliance(ctx, alliance)
+	a.broadcastUpdate(ctx, alliance)
+}
+
+// membership 玩家所在的联盟和成员信息，未加入联盟时返回 nil
+func (a *AllianceActor) membership(playerID string) (*pb.Alliance, *pb.AllianceMember) {
+	alliance, ok := a.alliances[a.memberOf[playerID]]
+	if !ok {
+		return nil, nil
+	}
+	return alliance, findAllianceMember(alliance, playerID)
+}
+
+// findAllianceMember 按玩家ID查找联盟成员
+func findAllianceMember(alliance *pb.Alliance, playerID string) *pb.AllianceMember {
+	for _, member := range alliance.Members {
+		if member.PlayerId == playerID {
+			return member
+		}
+	}
+	return nil
+}
+
+// allianceList 全部联盟的概要，不包含成员明细，按成员数量从多到少排列
+func (a *AllianceActor) allianceList() *pb.AllianceList {
+	list := &pb.AllianceList{}
+	for _, alliance := range a.alliances {
+		list.Alliances = append(list.Alliances, &pb.Alliance{
+			Id:          alliance.Id,
+			Name:        alliance.Name,
+			Tag:         alliance.Tag,
+			LeaderId:    alliance.LeaderId,
+			MemberCount: alliance.MemberCount,
+			CreatedAt:   alliance.CreatedAt,
+		})
+	}
+	sort.Slice(list.Alliances, func(i, j int) bool {
+		if list.Alliances[i].MemberCount != list.Alliances[j].MemberCount {
+			return list.Alliances[i].MemberCount > list.Alliances[j].MemberCount
+		}
+		return list.Alliances[i].CreatedAt < list.Alliances[j].CreatedAt
+	})
+	return list
+}
+
+// saveAlliance 将联盟快照交给 StorageActor 持久化
+func (a *AllianceActor) saveAlliance(ctx *actor.Context, alliance *pb.Alliance) {
+	if a.storagePID == nil {
+		return
+	}
+	ctx.Engine().Send(a.storagePID, &storage.StorageRequestMessage{
+		Type: "save_alliance",
+		Key:  alliance.Id,
+		Data: proto.Clone(alliance),
+	})
+}
+
+// notifyGame 通知 GameActor 玩家所属联盟的变化
+func (a *AllianceActor) notifyGame(ctx *actor.Context, playerID, allianceID string) {
+	if a.gamePID == nil {
+		return
+	}
+	ctx.Engine().Send(a.gamePID, &allianceChanged{playerID: playerID, allianceID: allianceID})
+}
+
+// broadcastUpdate 将联盟的最新信息推送给全部成员
+func (a *AllianceActor) broadcastUpdate(ctx *actor.Context, alliance *pb.Alliance) {
+	a.broadcast(ctx, alliance, "alliance_update", alliance)
+}
+
+// broadcast 将消息推送给联盟的全部成员
+func (a *AllianceActor) broadcast(ctx *actor.Context, alliance *pb.Alliance, msgType string, payload interface{}) {
+	for _, member := range alliance.Members {
+		sendToPlayer(ctx, a.gatewayPID, member.PlayerId, msgType, payload)
+	}
+}
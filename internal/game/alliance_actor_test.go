@@ -0,0 +1,78 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/storage"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// TestAllianceCommandsWaitForLoad 加载完成前收到的联盟请求在加载后处理，
+// 名称检查和成员关系包括已保存的联盟
+func TestAllianceCommandsWaitForLoad(t *testing.T) {
+	engine, err := actor.NewEngine(actor.NewEngineConfig())
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	failures := make(chan *requestFailed, 8)
+	gamePID := engine.SpawnFunc(func(ctx *actor.Context) {
+		if msg, ok := ctx.Message().(*requestFailed); ok {
+			failures <- msg
+		}
+	}, "game")
+	a := NewAllianceActor()().(*AllianceActor)
+	a.gamePID = gamePID
+
+	command := func(playerID, msgType string, req *pb.AllianceRequest) *allianceCommand {
+		payload, _ := json.Marshal(req)
+		return &allianceCommand{msg: &pb.GameMessage{Type: msgType, Id: playerID, Payload: payload}, name: playerID}
+	}
+	saved := &pb.Alliance{
+		Id:       "alliance_saved",
+		Name:     "Wolves",
+		Tag:      "WLF",
+		LeaderId: "p2",
+		Members:  []*pb.AllianceMember{{PlayerId: "p2", Rank: pb.AllianceRank_ALLIANCE_RANK_LEADER}},
+	}
+
+	runInEngine(t, engine, func(ctx *actor.Context) {
+		a.handleAllianceCommand(ctx, command("p1", "alliance_create", &pb.AllianceRequest{Name: "wolves", Tag: "NEW"}))
+		a.handleAllianceCommand(ctx, command("p2", "alliance_create", &pb.AllianceRequest{Name: "Bears", Tag: "BRS"}))
+		a.handleAllianceCommand(ctx, command("p3", "alliance_create", &pb.AllianceRequest{Name: "Foxes", Tag: "FOX"}))
+	})
+	if len(a.alliances) != 0 || len(a.memberOf) != 0 {
+		t.Fatalf("commands handled before alliances were loaded: %v", a.alliances)
+	}
+
+	runInEngine(t, engine, func(ctx *actor.Context) {
+		a.handleStorageResponse(ctx, &storage.StorageResponseMessage{
+			Type:      "list_alliances",
+			RequestID: allianceLoadRequestID,
+			Data:      []*pb.Alliance{saved},
+		})
+	})
+
+	want := map[string]string{"p1": ErrCodeAllianceNameTaken, "p2": ErrCodeAlreadyInAlliance}
+	for range want {
+		select {
+		case failure := <-failures:
+			if code := errorCode(failure.err); code != want[failure.playerID] {
+				t.Errorf("%s failed with %q, want %q", failure.playerID, code, want[failure.playerID])
+			}
+		case <-time.After(time.Second):
+			t.Fatal("queued commands were not rejected after loading")
+		}
+	}
+	if a.memberOf["p2"] != saved.Id {
+		t.Errorf("p2 is in %q, want %s", a.memberOf["p2"], saved.Id)
+	}
+	if id := a.memberOf["p3"]; id == "" || a.alliances[id].Name != "Foxes" {
+		t.Errorf("p3 did not create Foxes after loading")
+	}
+	if len(a.commands) != 0 {
+		t.Errorf("%d commands still queued", len(a.commands))
+	}
+}
@@ -0,0 +1,436 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+const (
+	maxBattleRounds       = 20   // 单场战斗最大回合数
+	commanderAttackBonus  = 0.01 // 玩家每点攻击力提供的部队攻击加成
+	commanderDefenseBonus = 0.01 // 玩家每点防御力提供的部队防御加成
+	defenseMitigation     = 50.0 // 防御减伤常数：实际伤害 = 伤害 * k / (k + 防御)
+	damageVariance        = 0.1  // 伤害浮动范围（±10%）
+	critChance            = 0.1  // 暴击概率
+	critMultiplier        = 1.5  // 暴击伤害倍率
+	minDamage             = 1    // 每次攻击的最小伤害
+	troopDamageScale      = 0.3  // 部队伤害系数，控制每回合的伤亡比例
+)
+
+// troopStack 战斗中的一支同兵种部队
+type troopStack struct {
+	ownerID   string
+	troopType string
+	attack    float64 // 单兵攻击力（含加成）
+	defense   float64 // 单兵防御力（含加成）
+	unitHp    int64   // 单兵生命值
+	initial   int32
+	count     int32
+	hp        int64 // 部队剩余总生命值
+	acting    int32 // 本回合开始时的数量，决定本回合的输出
+
+	roundAttack      float64 // 本回合技能带来的攻击倍率
+	roundDamageTaken float64 // 本回合受到伤害的倍率
+}
+
+func newTroopStack(ownerID, troopType string, count int32, attack, defense float64, unitHp int64) *troopStack {
+	return &troopStack{
+		ownerID:   ownerID,
+		troopType: troopType,
+		attack:    attack,
+		defense:   defense,
+		unitHp:    unitHp,
+		initial:   count,
+		count:     count,
+		hp:        int64(count) * unitHp,
+	}
+}
+
+func (s *troopStack) alive() bool {
+	return s.count > 0
+}
+
+// battleSide 战斗中的一方，保存战斗开始时的属性快照和各兵种部队
+type battleSide struct {
+	playerID     string
+	playerHp     int32 // 玩家战斗开始时的生命值
+	lord         bool  // 没有部队时由玩家本人迎战
+	heroID       string
+	activeSkills []string // 统帅的主动技能
+	stacks       []*troopStack
+	maxHp        int64 // 战斗开始时部队总生命值，包含援军
+	ownMaxHp     int64 // 战斗开始时玩家自己部队的总生命值
+	damageDealt  int64
+}
+
+// newBattleSide 按兵种顺序组建部队。玩家和统帅英雄的攻击、防御作为加成作用于全部部队，
+// 统帅的被动技能按兵种提供额外加成
+func newBattleSide(player *pb.PlayerData, troops map[string]int32, hero *pb.Hero) *battleSide {
+	side := &battleSide{playerID: player.Id, playerHp: player.Hp}
+	attack, defense := player.Attack, player.Defense
+	var passives []skillDef
+	if hero != nil {
+		heroAttack, heroDefense := heroStats(hero)
+		attack += heroAttack
+		defense += heroDefense
+		side.heroID = hero.Id
+		for _, skillID := range hero.Skills {
+			skill, ok := skillTable[skillID]
+			if !ok {
+				continue
+			}
+			if skill.kind == skillPassive {
+				passives = append(passives, skill)
+			} else {
+				side.activeSkills = append(side.activeSkills, skillID)
+			}
+		}
+	}
+	side.addStacks(player.Id, troops, attack, defense, passives)
+
+	if len(side.stacks) == 0 && player.Hp > 0 {
+		side.lord = true
+		side.stacks = append(side.stacks, newTroopStack(player.Id, troopLord, 1,
+			float64(player.Attack), float64(player.Defense), int64(player.Hp)))
+	}
+
+	side.maxHp = side.totalHp()
+	side.ownMaxHp = side.maxHp
+	return side
+}
+
+// addStacks 按兵种顺序加入一名玩家的部队，attack、defense 为统帅加成，passives 为统帅的被动技能
+func (s *battleSide) addStacks(ownerID string, troops map[string]int32, attack, defense int32, passives []skillDef) {
+	attackBonus := 1 + commanderAttackBonus*float64(attack)
+	defenseBonus := 1 + commanderDefenseBonus*float64(defense)
+
+	for _, troopType := range TroopTypes {
+		count := troops[troopType]
+		if count <= 0 {
+			continue
+		}
+		stats := troopStatTable[troopType]
+		troopAttack, troopDefense := stats.attack*attackBonus, stats.defense*defenseBonus
+		for _, skill := range passives {
+			if skill.troopType != "" && skill.troopType != troopType {
+				continue
+			}
+			switch skill.effect {
+			case effectAttack:
+				troopAttack *= 1 + skill.value
+			case effectDefense:
+				troopDefense *= 1 + skill.value
+			}
+		}
+		s.stacks = append(s.stacks, newTroopStack(ownerID, troopType, count,
+			troopAttack*troopDamageScale, troopDefense, stats.hp))
+	}
+}
+
+// reinforce 盟友援军加入防守方，以援军所属玩家的攻击、防御作为加成。
+// 有援军时玩家本人不再迎战
+func (s *battleSide) reinforce(reinforcements []*pb.Reinforcement) {
+	own := len(s.stacks)
+	for _, r := range reinforcements {
+		s.addStacks(r.OwnerId, r.Army, r.Attack, r.Defense, nil)
+	}
+	if len(s.stacks) == own {
+		return
+	}
+	if s.lord {
+		s.lord = false
+		s.stacks = s.stacks[own:]
+		s.ownMaxHp = 0
+	}
+	s.maxHp = s.totalHp()
+}
+
+// fortify 为全部部队提供防御加成
+func (s *battleSide) fortify(bonus float64) {
+	if bonus <= 0 {
+		return
+	}
+	for _, stack := range s.stacks {
+		stack.defense *= 1 + bonus
+	}
+}
+
+// beginRound 记录回合开始时各部队的数量，双方在同一回合内按此数量同时输出；
+// 同时清除上一回合技能带来的效果
+func (s *battleSide) beginRound() {
+	for _, stack := range s.stacks {
+		stack.acting = stack.count
+		stack.roundAttack = 1
+		stack.roundDamageTaken = 1
+	}
+}
+
+// triggerSkills 按概率触发统帅的主动技能，返回技能的行动记录
+func (s *battleSide) triggerSkills(rng *rand.Rand, enemy *battleSide) []*pb.BattleAction {
+	var actions []*pb.BattleAction
+	for _, skillID := range s.activeSkills {
+		skill := skillTable[skillID]
+		if rng.Float64() >= skill.chance {
+			continue
+		}
+
+		switch skill.effect {
+		case effectAttack:
+			for _, stack := range s.stacks {
+				stack.roundAttack *= 1 + skill.value
+			}
+			actions = append(actions, &pb.BattleAction{ActorId: s.playerID, SkillId: skillID, Modifiers: []string{skill.effect}})
+		case effectDamageTake:
+			for _, stack := range s.stacks {
+				stack.roundDamageTaken *= 1 - skill.value
+			}
+			actions = append(actions, &pb.BattleAction{ActorId: s.playerID, SkillId: skillID, Modifiers: []string{skill.effect}})
+		case effectDamage:
+			for _, stack := range enemy.stacks {
+				if !stack.alive() {
+					continue
+				}
+				action := damageStack(stack, int64(float64(stack.hp)*skill.value))
+				action.ActorId = s.playerID
+				action.SkillId = skillID
+				s.damageDealt += int64(action.Damage)
+				actions = append(actions, action)
+			}
+		}
+	}
+	return actions
+}
+
+func (s *battleSide) alive() bool {
+	for _, stack := range s.stacks {
+		if stack.alive() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *battleSide) totalHp() int64 {
+	total := int64(0)
+	for _, stack := range s.stacks {
+		total += stack.hp
+	}
+	return total
+}
+
+// ownHp 玩家自己部队的剩余总生命值，不包含援军
+func (s *battleSide) ownHp() int64 {
+	total := int64(0)
+	for _, stack := range s.stacks {
+		if stack.ownerID == s.playerID {
+			total += stack.hp
+		}
+	}
+	return total
+}
+
+// remainingPlayerHp 玩家战后的生命值：本人迎战时为剩余生命值，
+// 否则按自己部队的剩余生命比例折算，部队全灭时为0；
+// 没有自己的部队参战（只有援军守城）时生命值不变
+func (s *battleSide) remainingPlayerHp() int32 {
+	if s.lord {
+		return clampInt32(s.totalHp())
+	}
+	if s.ownMaxHp == 0 {
+		return s.playerHp
+	}
+	hp := (int64(s.playerHp)*s.ownHp() + s.ownMaxHp - 1) / s.ownMaxHp
+	return clampInt32(hp)
+}
+
+// stackResults 返回各兵种的伤亡统计，不包含玩家本人
+func (s *battleSide) stackResults() []*pb.BattleStack {
+	var results []*pb.BattleStack
+	for _, stack := range s.stacks {
+		if stack.troopType == troopLord {
+			continue
+		}
+		results = append(results, &pb.BattleStack{
+			OwnerId:   stack.ownerID,
+			TroopType: stack.troopType,
+			Initial:   stack.initial,
+			Lost:      stack.initial - stack.count,
+			Remaining: stack.count,
+		})
+	}
+	return results
+}
+
+// ResolveBattle 根据双方部队、属性和随机种子结算战斗。
+// 结果只取决于输入，相同输入总能得到相同结果，便于复盘。
+func ResolveBattle(input *pb.BattleInput) *pb.BattleResult {
+	rng := rand.New(rand.NewSource(input.Seed))
+	atk := newBattleSide(input.Attacker, input.AttackerArmy, input.AttackerHero)
+	defenderArmy := input.Defender.Troops
+	if len(input.DefenderArmy) > 0 {
+		defenderArmy = input.DefenderArmy
+	}
+	def := newBattleSide(input.Defender, defenderArmy, input.DefenderHero)
+	def.reinforce(input.Reinforcements)
+	if len(input.DefenderArmy) == 0 {
+		// 守城时城墙按剩余耐久提供防御加成
+		def.fortify(wallDefenseBonus(input.Defender.City))
+	}
+
+	var reports []*pb.BattleRound
+	for len(reports) < maxBattleRounds && atk.alive() && def.alive() {
+		round := &pb.BattleRound{Round: int32(len(reports) + 1)}
+		// 双方按回合开始时的部队数量同时输出，行动记录中进攻方在前
+		atk.beginRound()
+		def.beginRound()
+		round.Actions = append(round.Actions, atk.triggerSkills(rng, def)...)
+		round.Actions = append(round.Actions, def.triggerSkills(rng, atk)...)
+		round.Actions = append(round.Actions, attackSide(rng, atk, def)...)
+		round.Actions = append(round.Actions, attackSide(rng, def, atk)...)
+		round.AttackerHp = clampInt32(atk.totalHp())
+		round.DefenderHp = clampInt32(def.totalHp())
+		reports = append(reports, round)
+	}
+
+	winner, loser := decideWinner(atk, def)
+	return &pb.BattleResult{
+		WinnerId:       winner.playerID,
+		LoserId:        loser.playerID,
+		DamageDealt:    clampInt32(winner.damageDealt),
+		Seed:           input.Seed,
+		Rounds:         int32(len(reports)),
+		AttackerId:     atk.playerID,
+		DefenderId:     def.playerID,
+		RoundReports:   reports,
+		AttackerHp:     atk.remainingPlayerHp(),
+		DefenderHp:     def.remainingPlayerHp(),
+		AttackerStacks: atk.stackResults(),
+		DefenderStacks: def.stackResults(),
+		AttackerHeroId: atk.heroID,
+		DefenderHeroId: def.heroID,
+	}
+}
+
+// attackSide 一方回合开始时存活的部队依次攻击对方
+func attackSide(rng *rand.Rand, from, to *battleSide) []*pb.BattleAction {
+	var actions []*pb.BattleAction
+	for _, stack := range from.stacks {
+		if stack.acting <= 0 {
+			continue
+		}
+		target := chooseTarget(stack, to)
+		if target == nil {
+			break
+		}
+		action := strike(rng, stack, target)
+		from.damageDealt += int64(action.Damage)
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// chooseTarget 优先攻击克制系数最高的敌方部队，系数相同时按兵种顺序
+func chooseTarget(from *troopStack, enemy *battleSide) *troopStack {
+	var target *troopStack
+	best := 0.0
+	for _, stack := range enemy.stacks {
+		if !stack.alive() {
+			continue
+		}
+		if factor := counterFactor(from.troopType, stack.troopType); target == nil || factor > best {
+			target, best = stack, factor
+		}
+	}
+	return target
+}
+
+// strike 计算一次攻击造成的伤害和击杀数，扣除目标生命值并返回行动记录
+func strike(rng *rand.Rand, from, to *troopStack) *pb.BattleAction {
+	action := &pb.BattleAction{
+		ActorId:         from.ownerID,
+		TargetId:        to.ownerID,
+		TroopType:       from.troopType,
+		TargetTroopType: to.troopType,
+	}
+
+	damage := float64(from.acting) * from.attack * from.roundAttack
+	counter := counterFactor(from.troopType, to.troopType)
+	damage *= counter
+	if counter > 1 {
+		action.Modifiers = append(action.Modifiers, "counter")
+	} else if counter < 1 {
+		action.Modifiers = append(action.Modifiers, "countered")
+	}
+	damage *= 1 - damageVariance + 2*damageVariance*rng.Float64()
+	if rng.Float64() < critChance {
+		damage *= critMultiplier
+		action.Modifiers = append(action.Modifiers, "critical")
+	}
+	damage *= defenseMitigation / (defenseMitigation + to.defense)
+	damage *= to.roundDamageTaken
+
+	dealt := int64(damage)
+	if dealt < minDamage {
+		dealt = minDamage
+		action.Modifiers = append(action.Modifiers, "minimum_damage")
+	}
+
+	hit := damageStack(to, dealt)
+	action.Damage = hit.Damage
+	action.Kills = hit.Kills
+	action.TargetHp = hit.TargetHp
+	action.TargetRemaining = hit.TargetRemaining
+	return action
+}
+
+// damageStack 扣除部队生命值并按剩余生命值计算剩余数量，受伤的士兵仍可作战
+func damageStack(stack *troopStack, damage int64) *pb.BattleAction {
+	if damage < minDamage {
+		damage = minDamage
+	}
+	if damage > stack.hp {
+		damage = stack.hp
+	}
+	stack.hp -= damage
+
+	remaining := int32((stack.hp + stack.unitHp - 1) / stack.unitHp)
+	action := &pb.BattleAction{
+		TargetId:        stack.ownerID,
+		TargetTroopType: stack.troopType,
+		Damage:          clampInt32(damage),
+		Kills:           stack.count - remaining,
+		TargetHp:        clampInt32(stack.hp),
+		TargetRemaining: remaining,
+	}
+	stack.count = remaining
+	return action
+}
+
+// decideWinner 判定胜负：一方全灭则另一方获胜，同归于尽时防守方获胜；
+// 回合耗尽时剩余生命比例高者获胜，比例相同时防守方获胜
+func decideWinner(atk, def *battleSide) (winner, loser *battleSide) {
+	if !atk.alive() {
+		return def, atk
+	}
+	if !def.alive() {
+		return atk, def
+	}
+	// 以浮点数交叉相乘比较比例，大规模部队的生命值相乘不会溢出
+	if float64(atk.totalHp())*float64(def.maxHp) > float64(def.totalHp())*float64(atk.maxHp) {
+		return atk, def
+	}
+	return def, atk
+}
+
+// clampInt32 将生命值、伤害等统计值截断到 int32 范围内，避免写入战报时溢出成负数
+func clampInt32(v int64) int32 {
+	if v > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	if v < math.MinInt32 {
+		return math.MinInt32
+	}
+	return int32(v)
+}
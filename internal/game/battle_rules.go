@@ -0,0 +1,280 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+const (
+	attackCooldown   = 30 * time.Second // 两次发起攻击的最小间隔
+	defeatProtection = 10 * time.Minute // 战败后的保护时间
+)
+
+// PlayerOfflineMessage 由 GatewayActor 在玩家连接断开时发送
+type PlayerOfflineMessage struct {
+	PlayerID string
+}
+
+// playerRemoved GameActor 移除临时玩家后通知 MapActor 和 CombatActor 释放该玩家的数据
+type playerRemoved struct {
+	playerID string
+}
+
+// handleBattleRequest 校验战斗请求，通过后派出部队行军，到达目标后才交给 CombatActor 结算
+func (a *GameActor) handleBattleRequest(ctx *actor.Context, msg *pb.GameMessage) {
+	if a.combatPID == nil {
+		log.Printf("[GameActor] CombatActor PID not available")
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeServiceUnavailable, "combat service not available"))
+		return
+	}
+
+	var battleReq pb.BattleRequest
+	if err := json.Unmarshal(msg.Payload, &battleReq); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid battle request format"))
+		return
+	}
+
+	now := time.Now()
+	if err := a.validateBattleRequest(msg.Id, &battleReq, now); err != nil {
+		log.Printf("[GameActor] Battle request rejected: attacker=%s, defender=%s, err=%v",
+			battleReq.AttackerId, battleReq.DefenderId, err)
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, err)
+		return
+	}
+
+	attacker := a.players[battleReq.AttackerId]
+	defender := a.players[battleReq.DefenderId]
+
+	// 未指定出征部队时派出全部驻守部队
+	if len(battleReq.Army) == 0 {
+		battleReq.Army = copyTroops(attacker.Troops)
+	}
+	if err := validateArmy(battleReq.Army, attacker.Troops); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, err)
+		return
+	}
+	if err := a.validateMarchHero(attacker, battleReq.HeroId); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, err)
+		return
+	}
+
+	// 主动发起攻击会解除自身的保护
+	attacker.ProtectedUntil = 0
+	a.attackCooldowns[attacker.Id] = now.Add(attackCooldown)
+	a.startMarch(ctx, attacker, &pb.March{
+		TargetId: defender.Id,
+		Army:     battleReq.Army,
+		HeroId:   battleReq.HeroId,
+	}, defender.Home)
+}
+
+// validateBattleRequest 检查发起者身份、双方状态、冷却和保护期
+func (a *GameActor) validateBattleRequest(senderID string, req *pb.BattleRequest, now time.Time) error {
+	if req.AttackerId == "" || req.DefenderId == "" {
+		return newGameError(ErrCodeInvalidRequest, "attacker and defender are required")
+	}
+	if req.AttackerId != senderID {
+		return newGameError(ErrCodeNotAuthorized, "cannot attack on behalf of another player")
+	}
+	if req.AttackerId == req.DefenderId {
+		return newGameError(ErrCodeSelfAttack, "cannot attack yourself")
+	}
+
+	attacker, ok := a.players[req.AttackerId]
+	if !ok {
+		return newGameError(ErrCodePlayerNotFound, "player not found: %s", req.AttackerId)
+	}
+	defender, ok := a.players[req.DefenderId]
+	if !ok {
+		return newGameError(ErrCodePlayerNotFound, "player not found: %s", req.DefenderId)
+	}
+	if !a.online[defender.Id] {
+		return newGameError(ErrCodePlayerOffline, "player is offline: %s", defender.Id)
+	}
+	if attacker.Home == nil || defender.Home == nil {
+		return newGameError(ErrCodeServiceUnavailable, "city location not assigned yet")
+	}
+	if a.marchCount(attacker.Id) >= maxMarches {
+		return newGameError(ErrCodeMarchLimit, "too many marches: max %d", maxMarches)
+	}
+	if attacker.Status == pb.PlayerStatus_PLAYER_STATUS_RECOVERING {
+		return newGameError(ErrCodePlayerRecovering, "you are recovering until %d", attacker.RecoverAt)
+	}
+	if opponentID, busy := a.battling[attacker.Id]; busy {
+		return newGameError(ErrCodeInBattle, "you are already in a battle with %s", opponentID)
+	}
+	if readyAt, ok := a.attackCooldowns[attacker.Id]; ok && now.Before(readyAt) {
+		return newGameError(ErrCodeAttackCooldown, "attack on cooldown for %d seconds",
+			int64(readyAt.Sub(now).Seconds())+1)
+	}
+	if defender.ProtectedUntil > now.Unix() {
+		return newGameError(ErrCodeTargetProtected, "target is protected until %d", defender.ProtectedUntil)
+	}
+	return nil
+}
+
+// validateMarchHero 检查统帅英雄存在且没有在其他行军中，heroID 为空时不带统帅
+func (a *GameActor) validateMarchHero(player *pb.PlayerData, heroID string) error {
+	if heroID == "" {
+		return nil
+	}
+	if findHero(player, heroID) == nil {
+		return newGameError(ErrCodeHeroNotFound, "hero not found: %s", heroID)
+	}
+	if a.marchingHeroes(player.Id)[heroID] {
+		return newGameError(ErrCodeHeroBusy, "hero is marching: %s", heroID)
+	}
+	return nil
+}
+
+// battleResolved CombatActor 结算完成的战斗结果。战斗结果会修改双方的玩家数据，
+// 只能以内部消息类型在 Actor 之间传递，客户端发送的 GameMessage 无法伪造
+type battleResolved struct {
+	result *pb.BattleResult // 去除战斗输入快照的公开战报
+}
+
+// handleBattleResolved 将战报推送给双方后结算生命值、经验和状态，幸存部队返回主城。
+// 只结算 GameActor 发起且仍在等待结果的战斗，已超时或不存在的战斗结果被忽略
+func (a *GameActor) handleBattleResolved(ctx *actor.Context, msg *battleResolved) {
+	result := msg.result
+	if _, ok := a.fighting[result.AttackerId]; !ok || a.battling[result.AttackerId] != result.DefenderId {
+		log.Printf("[GameActor] Ignoring result of battle %s: no battle in progress between %s and %s",
+			result.BattleId, result.AttackerId, result.DefenderId)
+		return
+	}
+	a.endBattle(result.AttackerId)
+	a.sendBattleResult(ctx, result)
+	// 掠夺需要读取进攻方的行军，必须在行军结束战斗之前结算
+	a.settleBattle(ctx, result)
+	a.endMarchBattle(ctx, result.AttackerId, result)
+}
+
+// sendBattleResult 将战报推送给胜利者和失败者
+func (a *GameActor) sendBattleResult(ctx *actor.Context, result *pb.BattleResult) {
+	// 确保两个玩家都在线
+	_, winnerExists := a.players[result.WinnerId]
+	_, loserExists := a.players[result.LoserId]
+	if !winnerExists || !loserExists {
+		log.Printf("[GameActor] One or both players not found: winner=%s, loser=%s",
+			result.WinnerId, result.LoserId)
+		return
+	}
+
+	// 分别发送战斗结果给胜利者和失败者
+	sendToPlayer(ctx, a.gatewayPID, result.WinnerId, "battle_result", result)
+	sendToPlayer(ctx, a.gatewayPID, result.LoserId, "battle_result", result)
+	log.Printf("[GameActor] Sent battle result: winner=%s, loser=%s", result.WinnerId, result.LoserId)
+}
+
+// settleBattle 应用战斗结果，结算守城的援军，进攻方攻城胜利时进行掠夺，
+// 并通知 CombatActor 该战斗已完成
+func (a *GameActor) settleBattle(ctx *actor.Context, result *pb.BattleResult) {
+	a.applyBattleResult(ctx, result)
+	if result.NodeId == "" {
+		a.settleReinforcements(ctx, result)
+	}
+	if result.NodeId == "" && result.WinnerId == result.AttackerId {
+		a.plunderCity(ctx, result)
+	}
+
+	if a.combatPID == nil {
+		return
+	}
+	ctx.Engine().Send(a.combatPID, &battleSettled{battleID: result.BattleId})
+}
+
+// battleTimedOut CombatActor 在战斗超时仍未完成时发给 GameActor 的通知
+type battleTimedOut struct {
+	result *pb.BattleResult
+}
+
+// handleBattleTimeout 清除超时战斗的战斗中标记，并通知双方玩家
+func (a *GameActor) handleBattleTimeout(ctx *actor.Context, msg *battleTimedOut) {
+	result := msg.result
+	if a.battling[result.AttackerId] == result.DefenderId {
+		a.endBattle(result.AttackerId)
+		a.endMarchBattle(ctx, result.AttackerId, nil)
+	}
+	for _, id := range []string{result.AttackerId, result.DefenderId} {
+		sendToPlayer(ctx, a.gatewayPID, id, "battle_timeout", result)
+	}
+	log.Printf("[GameActor] Battle timed out: %s, attacker=%s, defender=%s",
+		result.BattleId, result.AttackerId, result.DefenderId)
+}
+
+// handleRequestFailed 转发其他 Actor 返回的错误，战斗请求失败时结束行军的战斗状态
+func (a *GameActor) handleRequestFailed(ctx *actor.Context, msg *requestFailed) {
+	if msg.requestType == "battle_request" {
+		a.endBattle(msg.playerID)
+		a.endMarchBattle(ctx, msg.playerID, nil)
+	}
+	a.sendError(ctx, msg.playerID, "", msg.requestType, msg.err)
+}
+
+// endBattle 清除玩家及其对手的战斗中标记
+func (a *GameActor) endBattle(playerID string) {
+	if opponentID, ok := a.battling[playerID]; ok {
+		delete(a.battling, opponentID)
+	}
+	delete(a.battling, playerID)
+}
+
+// handlePlayerOffline 标记玩家离线并取消其地图订阅，玩家数据保留；
+// 临时玩家无法再次登录，直接移除
+func (a *GameActor) handlePlayerOffline(ctx *actor.Context, msg *PlayerOfflineMessage) {
+	player, ok := a.players[msg.PlayerID]
+	if !ok {
+		return
+	}
+	delete(a.online, msg.PlayerID)
+	if a.mapPID != nil {
+		ctx.Engine().Send(a.mapPID, msg)
+	}
+	log.Printf("[GameActor] Player went offline: %s", msg.PlayerID)
+	if a.guests[player.Id] {
+		a.removeGuest(ctx, player)
+	}
+}
+
+// removeGuest 移除离线的临时玩家：取消其行军和建造、训练计时，遣返前往或驻守其主城的援军，
+// 释放地图上的主城。进行中的战斗照常结算，已不存在的玩家不会被修改
+func (a *GameActor) removeGuest(ctx *actor.Context, player *pb.PlayerData) {
+	a.cancelMarches(ctx, player.Id)
+	now := time.Now()
+	for _, m := range a.marches {
+		if !m.march.Reinforce || m.march.TargetId != player.Id {
+			continue
+		}
+		if m.march.Status == pb.MarchStatus_MARCH_STATUS_OUTBOUND || m.march.Status == pb.MarchStatus_MARCH_STATUS_STATIONED {
+			a.returnMarch(ctx, m, now)
+		}
+	}
+	for _, task := range player.City.Queue {
+		if timer, ok := a.buildTimers[task.Id]; ok {
+			timer.Stop()
+			delete(a.buildTimers, task.Id)
+		}
+	}
+	for _, task := range player.City.Training {
+		if timer, ok := a.trainTimers[task.Id]; ok {
+			timer.Stop()
+			delete(a.trainTimers, task.Id)
+		}
+	}
+	delete(a.players, player.Id)
+	delete(a.guests, player.Id)
+	delete(a.attackCooldowns, player.Id)
+
+	removed := &playerRemoved{playerID: player.Id}
+	if a.mapPID != nil {
+		ctx.Engine().Send(a.mapPID, removed)
+	}
+	if a.combatPID != nil {
+		ctx.Engine().Send(a.combatPID, removed)
+	}
+	log.Printf("[GameActor] Guest player removed: %s", player.Id)
+}
@@ -0,0 +1,213 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// errorCode 返回业务错误的错误码，nil 和非业务错误返回空字符串
+func errorCode(err error) string {
+	var gerr *gameError
+	if errors.As(err, &gerr) {
+		return gerr.code
+	}
+	return ""
+}
+
+func TestValidateBattleRequest(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		sender string
+		req    *pb.BattleRequest
+		setup  func(a *GameActor)
+		want   string
+	}{
+		{
+			name:   "valid",
+			sender: "atk",
+			req:    &pb.BattleRequest{AttackerId: "atk", DefenderId: "def"},
+		},
+		{
+			name:   "missing defender",
+			sender: "atk",
+			req:    &pb.BattleRequest{AttackerId: "atk"},
+			want:   ErrCodeInvalidRequest,
+		},
+		{
+			name:   "on behalf of another player",
+			sender: "def",
+			req:    &pb.BattleRequest{AttackerId: "atk", DefenderId: "def"},
+			want:   ErrCodeNotAuthorized,
+		},
+		{
+			name:   "self attack",
+			sender: "atk",
+			req:    &pb.BattleRequest{AttackerId: "atk", DefenderId: "atk"},
+			want:   ErrCodeSelfAttack,
+		},
+		{
+			name:   "unknown attacker",
+			sender: "ghost",
+			req:    &pb.BattleRequest{AttackerId: "ghost", DefenderId: "def"},
+			want:   ErrCodePlayerNotFound,
+		},
+		{
+			name:   "unknown defender",
+			sender: "atk",
+			req:    &pb.BattleRequest{AttackerId: "atk", DefenderId: "ghost"},
+			want:   ErrCodePlayerNotFound,
+		},
+		{
+			name:   "defender offline",
+			sender: "atk",
+			req:    &pb.BattleRequest{AttackerId: "atk", DefenderId: "def"},
+			setup:  func(a *GameActor) { delete(a.online, "def") },
+			want:   ErrCodePlayerOffline,
+		},
+		{
+			name:   "city not placed",
+			sender: "atk",
+			req:    &pb.BattleRequest{AttackerId: "atk", DefenderId: "def"},
+			setup:  func(a *GameActor) { a.players["def"].Home = nil },
+			want:   ErrCodeServiceUnavailable,
+		},
+		{
+			name:   "march limit",
+			sender: "atk",
+			req:    &pb.BattleRequest{AttackerId: "atk", DefenderId: "def"},
+			setup: func(a *GameActor) {
+				for i := 0; i < maxMarches; i++ {
+					id := fmt.Sprintf("march_%d", i)
+					a.marches[id] = &activeMarch{march: &pb.March{Id: id, OwnerId: "atk"}}
+				}
+			},
+			want: ErrCodeMarchLimit,
+		},
+		{
+			name:   "attacker recovering",
+			sender: "atk",
+			req:    &pb.BattleRequest{AttackerId: "atk", DefenderId: "def"},
+			setup: func(a *GameActor) {
+				a.players["atk"].Status = pb.PlayerStatus_PLAYER_STATUS_RECOVERING
+			},
+			want: ErrCodePlayerRecovering,
+		},
+		{
+			name:   "already in battle",
+			sender: "atk",
+			req:    &pb.BattleRequest{AttackerId: "atk", DefenderId: "def"},
+			setup:  func(a *GameActor) { a.battling["atk"] = "other" },
+			want:   ErrCodeInBattle,
+		},
+		{
+			name:   "attack cooldown",
+			sender: "atk",
+			req:    &pb.BattleRequest{AttackerId: "atk", DefenderId: "def"},
+			setup:  func(a *GameActor) { a.attackCooldowns["atk"] = now.Add(time.Second) },
+			want:   ErrCodeAttackCooldown,
+		},
+		{
+			name:   "cooldown expired",
+			sender: "atk",
+			req:    &pb.BattleRequest{AttackerId: "atk", DefenderId: "def"},
+			setup:  func(a *GameActor) { a.attackCooldowns["atk"] = now.Add(-time.Second) },
+		},
+		{
+			name:   "target protected",
+			sender: "atk",
+			req:    &pb.BattleRequest{AttackerId: "atk", DefenderId: "def"},
+			setup:  func(a *GameActor) { a.players["def"].ProtectedUntil = now.Add(time.Minute).Unix() },
+			want:   ErrCodeTargetProtected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestGameActor()
+			addTestPlayer(a, "atk", 0)
+			addTestPlayer(a, "def", 10)
+			if tt.setup != nil {
+				tt.setup(a)
+			}
+			err := a.validateBattleRequest(tt.sender, tt.req, now)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if got := errorCode(err); got != tt.want {
+				t.Errorf("error code = %q (%v), want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestHandlePlayerOffline 临时玩家离线后被移除，其行军、计时和占据的资源点被释放；账号玩家的数据保留
+func TestHandlePlayerOffline(t *testing.T) {
+	tests := []struct {
+		name  string
+		guest bool
+	}{
+		{"account player", false},
+		{"guest", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestGameActor()
+			player := addTestPlayer(a, "p1", 0)
+			addTestPlayer(a, "p2", 10)
+			a.guests["p1"] = tt.guest
+			a.attackCooldowns["p1"] = time.Now().Add(time.Minute)
+
+			node := &pb.ResourceNode{Id: "node_1", Amount: 1000, OccupantId: "p1", MarchId: "march_1"}
+			a.nodes[node.Id] = node
+			a.marches["march_1"] = &activeMarch{march: &pb.March{Id: "march_1", OwnerId: "p1", NodeId: node.Id}}
+			a.marches["march_2"] = &activeMarch{march: &pb.March{Id: "march_2", OwnerId: "p2", TargetId: "p1"}}
+			stationed := &activeMarch{march: &pb.March{Id: "march_3", OwnerId: "p2", TargetId: "p1", Reinforce: true,
+				Army: map[string]int32{TroopInfantry: 10}, Status: pb.MarchStatus_MARCH_STATUS_STATIONED,
+				From: &pb.Coord{X: 10}, To: &pb.Coord{X: 0}}}
+			a.marches["march_3"] = stationed
+			t.Cleanup(func() {
+				if stationed.timer != nil {
+					stationed.timer.Stop()
+				}
+			})
+			player.City.Queue = []*pb.BuildTask{{Id: "build_1"}}
+			a.buildTimers["build_1"] = time.NewTimer(time.Hour)
+
+			runInActor(t, func(ctx *actor.Context) {
+				a.handlePlayerOffline(ctx, &PlayerOfflineMessage{PlayerID: "p1"})
+			})
+
+			if a.online["p1"] {
+				t.Errorf("player still online")
+			}
+			_, kept := a.players["p1"]
+			_, marching := a.marches["march_1"]
+			_, timed := a.buildTimers["build_1"]
+			if kept == tt.guest || marching == tt.guest || timed == tt.guest {
+				t.Errorf("player kept %v, march kept %v, build timer kept %v, want %v", kept, marching, timed, !tt.guest)
+			}
+			if tt.guest && (a.guests["p1"] || node.MarchId != "" || node.OccupantId != "") {
+				t.Errorf("guest flag %v, node occupant %q march %q", a.guests["p1"], node.OccupantId, node.MarchId)
+			}
+			if _, ok := a.marches["march_2"]; !ok {
+				t.Errorf("march of another player was cancelled")
+			}
+			// 驻守在临时玩家主城的援军返回，驻守在账号玩家主城的援军保持驻守
+			wantStatus := pb.MarchStatus_MARCH_STATUS_STATIONED
+			if tt.guest {
+				wantStatus = pb.MarchStatus_MARCH_STATUS_RETURNING
+			}
+			if stationed.march.Status != wantStatus {
+				t.Errorf("reinforcement status %v, want %v", stationed.march.Status, wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package game
+
+import (
+	"math"
+	"testing"
+
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// testBattleInput 两名初始玩家攻城的战斗输入，进攻方带初始部队和统帅
+func testBattleInput(seed int64) *pb.BattleInput {
+	attacker := newPlayerData("attacker", "Attacker")
+	defender := newPlayerData("defender", "Defender")
+	return &pb.BattleInput{
+		Attacker:     attacker,
+		Defender:     defender,
+		AttackerArmy: copyTroops(initialTroops),
+		AttackerHero: attacker.Heroes[0],
+		Seed:         seed,
+	}
+}
+
+func TestResolveBattleDeterministic(t *testing.T) {
+	tests := []struct {
+		name string
+		seed int64
+	}{
+		{"zero seed", 0},
+		{"small seed", 42},
+		{"negative seed", -7},
+		{"large seed", math.MaxInt64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := ResolveBattle(testBattleInput(tt.seed))
+			second := ResolveBattle(testBattleInput(tt.seed))
+			if !proto.Equal(first, second) {
+				t.Fatalf("same seed produced different results:\n%v\n%v", first, second)
+			}
+
+			if first.Seed != tt.seed {
+				t.Errorf("Seed = %d, want %d", first.Seed, tt.seed)
+			}
+			if first.Rounds != int32(len(first.RoundReports)) || first.Rounds > maxBattleRounds {
+				t.Errorf("Rounds = %d with %d reports", first.Rounds, len(first.RoundReports))
+			}
+			sides := map[string]bool{"attacker": true, "defender": true}
+			if !sides[first.WinnerId] || !sides[first.LoserId] || first.WinnerId == first.LoserId {
+				t.Errorf("winner %q, loser %q", first.WinnerId, first.LoserId)
+			}
+		})
+	}
+}
+
+func TestReplayBattle(t *testing.T) {
+	input := testBattleInput(2024)
+	battle := ResolveBattle(input)
+	battle.BattleId = "battle_1"
+	battle.Timestamp = 1700000000
+	battle.Status = pb.BattleStatus_BATTLE_STATUS_SETTLED
+	battle.Input = input
+
+	replay, err := ReplayBattle(battle)
+	if err != nil {
+		t.Fatalf("ReplayBattle: %v", err)
+	}
+	if replay.BattleId != battle.BattleId || replay.Timestamp != battle.Timestamp || replay.Status != battle.Status {
+		t.Errorf("replay did not keep the battle ID, time and status: %v", replay)
+	}
+	if !SameOutcome(battle, replay) {
+		t.Errorf("replay differs from the original battle")
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(*pb.BattleResult)
+		same   bool
+	}{
+		{"battle ID ignored", func(b *pb.BattleResult) { b.BattleId = "other" }, true},
+		{"input ignored", func(b *pb.BattleResult) { b.Input = nil }, true},
+		{"node ignored", func(b *pb.BattleResult) { b.NodeId = "node_1" }, true},
+		{"reinforcement owners ignored", func(b *pb.BattleResult) { b.ReinforcementIds = []string{"ally"} }, true},
+		{"winner changed", func(b *pb.BattleResult) { b.WinnerId, b.LoserId = b.LoserId, b.WinnerId }, false},
+		{"hp changed", func(b *pb.BattleResult) { b.AttackerHp++ }, false},
+		{"round dropped", func(b *pb.BattleResult) { b.RoundReports = b.RoundReports[1:] }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := proto.Clone(battle).(*pb.BattleResult)
+			tt.tamper(tampered)
+			if got := SameOutcome(tampered, replay); got != tt.same {
+				t.Errorf("SameOutcome = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+// TestReplayReinforcedBattle 援军参与守城的战报与 CombatActor 一样记录援军玩家，重放结果仍然相同
+func TestReplayReinforcedBattle(t *testing.T) {
+	input := testBattleInput(7)
+	input.Reinforcements = []*pb.Reinforcement{
+		{MarchId: "march_1", OwnerId: "ally", Army: copyTroops(initialTroops), Attack: 10, Defense: 10},
+		{MarchId: "march_2", OwnerId: "ally", Army: map[string]int32{TroopInfantry: 20}, Attack: 10, Defense: 10},
+	}
+	battle := ResolveBattle(input)
+	battle.Input = input
+	battle.ReinforcementIds = reinforcementOwners(input.Reinforcements)
+	battle.Status = pb.BattleStatus_BATTLE_STATUS_SETTLED
+
+	replay, err := ReplayBattle(battle)
+	if err != nil {
+		t.Fatalf("ReplayBattle: %v", err)
+	}
+	if !SameOutcome(battle, replay) {
+		t.Errorf("replay of a reinforced battle differs from the original battle")
+	}
+	if SameOutcome(battle, ResolveBattle(testBattleInput(7))) {
+		t.Errorf("reinforcements did not change the battle")
+	}
+}
+
+func TestReplayBattleWithoutInput(t *testing.T) {
+	battle := ResolveBattle(testBattleInput(1))
+	if _, err := ReplayBattle(battle); err == nil {
+		t.Errorf("ReplayBattle without input should fail")
+	}
+}
+
+// testSide 只有一支部队的战斗方
+func testSide(id string, count int32, hp, maxHp int64) *battleSide {
+	return &battleSide{
+		playerID: id,
+		stacks:   []*troopStack{{ownerID: id, count: count, hp: hp}},
+		maxHp:    maxHp,
+	}
+}
+
+func TestDecideWinner(t *testing.T) {
+	const huge = int64(1) << 36 // 两方生命值相乘超出 int64
+	tests := []struct {
+		name     string
+		atk, def *battleSide
+		want     string
+	}{
+		{"defender wiped out", testSide("atk", 5, 50, 100), testSide("def", 0, 0, 100), "atk"},
+		{"attacker wiped out", testSide("atk", 0, 0, 100), testSide("def", 5, 50, 100), "def"},
+		{"mutual destruction", testSide("atk", 0, 0, 100), testSide("def", 0, 0, 100), "def"},
+		{"attacker higher ratio", testSide("atk", 5, 60, 100), testSide("def", 5, 100, 200), "atk"},
+		{"defender higher ratio", testSide("atk", 5, 40, 100), testSide("def", 5, 100, 200), "def"},
+		{"equal ratio", testSide("atk", 5, 50, 100), testSide("def", 5, 100, 200), "def"},
+		{"large armies", testSide("atk", 5, 3*huge, 4*huge), testSide("def", 5, huge, 4*huge), "atk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			winner, loser := decideWinner(tt.atk, tt.def)
+			if winner.playerID != tt.want || loser == winner {
+				t.Errorf("winner = %s, want %s", winner.playerID, tt.want)
+			}
+		})
+	}
+}
+
+func TestClampInt32(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want int32
+	}{
+		{0, 0},
+		{-5, -5},
+		{math.MaxInt32, math.MaxInt32},
+		{math.MaxInt32 + 1, math.MaxInt32},
+		{math.MinInt32 - 1, math.MinInt32},
+		{math.MaxInt64, math.MaxInt32},
+	}
+	for _, tt := range tests {
+		if got := clampInt32(tt.in); got != tt.want {
+			t.Errorf("clampInt32(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
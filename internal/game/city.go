@@ -0,0 +1,357 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/world"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// 建筑类型
+const (
+	BuildingTownHall  = "town_hall" // 主城大厅，决定其他建筑的等级上限
+	BuildingBarracks  = "barracks"  // 兵营
+	BuildingFarm      = "farm"      // 农场
+	BuildingSawmill   = "sawmill"   // 伐木场
+	BuildingQuarry    = "quarry"    // 采石场
+	BuildingIronMine  = "iron_mine" // 铁矿
+	BuildingMarket    = "market"    // 市场
+	BuildingWarehouse = "warehouse" // 仓库
+	BuildingWall      = "wall"      // 城墙
+)
+
+const (
+	maxBuildQueue       = 2         // 同时进行的建造任务数量上限
+	initialSpeedupTime  = time.Hour // 新玩家获得的加速时间
+	buildTaskPrefix     = "build_"  // 建造任务ID前缀
+	townHallGatingLevel = 2         // 大厅达到该等级后，大厅升级需要仓库和城墙
+)
+
+// buildingDef 建筑定义：建造 n 级耗时 = baseDuration * n * n，消耗 = baseCost * n * n
+type buildingDef struct {
+	maxLevel     int32
+	baseDuration time.Duration
+	baseCost     map[string]int64
+	requires     map[string]int32 // 建造1级所需的其他建筑等级
+}
+
+var buildingTable = map[string]buildingDef{
+	BuildingTownHall: {maxLevel: 10, baseDuration: 60 * time.Second,
+		baseCost: map[string]int64{ResourceWood: 200, ResourceStone: 150, ResourceGold: 25}},
+	BuildingBarracks: {maxLevel: 10, baseDuration: 30 * time.Second,
+		baseCost: map[string]int64{ResourceWood: 200, ResourceStone: 100},
+		requires: map[string]int32{BuildingTownHall: 2}},
+	BuildingFarm: {maxLevel: 10, baseDuration: 20 * time.Second,
+		baseCost: map[string]int64{ResourceWood: 100}},
+	BuildingSawmill: {maxLevel: 10, baseDuration: 20 * time.Second,
+		baseCost: map[string]int64{ResourceFood: 50, ResourceWood: 50}},
+	BuildingQuarry: {maxLevel: 10, baseDuration: 20 * time.Second,
+		baseCost: map[string]int64{ResourceFood: 50, ResourceWood: 100},
+		requires: map[string]int32{BuildingSawmill: 1}},
+	BuildingIronMine: {maxLevel: 10, baseDuration: 30 * time.Second,
+		baseCost: map[string]int64{ResourceWood: 150, ResourceStone: 100},
+		requires: map[string]int32{BuildingQuarry: 2}},
+	BuildingMarket: {maxLevel: 10, baseDuration: 30 * time.Second,
+		baseCost: map[string]int64{ResourceWood: 200, ResourceStone: 150, ResourceIron: 50},
+		requires: map[string]int32{BuildingTownHall: 3}},
+	BuildingWarehouse: {maxLevel: 10, baseDuration: 20 * time.Second,
+		baseCost: map[string]int64{ResourceWood: 150, ResourceStone: 50}},
+	BuildingWall: {maxLevel: 10, baseDuration: 45 * time.Second,
+		baseCost: map[string]int64{ResourceStone: 200, ResourceIron: 50},
+		requires: map[string]int32{BuildingBarracks: 1}},
+}
+
+// initialBuildings 新玩家主城的初始建筑
+var initialBuildings = map[string]int32{
+	BuildingTownHall:  1,
+	BuildingFarm:      1,
+	BuildingSawmill:   1,
+	BuildingWarehouse: 1,
+}
+
+// buildComplete 建造计时结束时 GameActor 发给自身的消息；
+// finishAt 用于忽略加速之前的旧消息
+type buildComplete struct {
+	playerID string
+	taskID   string
+	finishAt int64
+}
+
+// newCity 创建新玩家的主城
+func newCity() *pb.City {
+	buildings := make(map[string]int32, len(initialBuildings))
+	for building, level := range initialBuildings {
+		buildings[building] = level
+	}
+	city := &pb.City{
+		Buildings:           buildings,
+		SpeedupSeconds:      int64(initialSpeedupTime / time.Second),
+		DurabilityUpdatedAt: time.Now().UnixMilli(),
+	}
+	city.Durability = maxDurability(city)
+	return city
+}
+
+// buildDuration 建造指定等级所需的时间
+func buildDuration(building string, level int32) time.Duration {
+	return buildingTable[building].baseDuration * time.Duration(level) * time.Duration(level)
+}
+
+// buildCost 建造指定等级所需的资源
+func buildCost(building string, level int32) map[string]int64 {
+	cost := make(map[string]int64)
+	for resource, amount := range buildingTable[building].baseCost {
+		cost[resource] = amount * int64(level) * int64(level)
+	}
+	return cost
+}
+
+// checkBuildable 检查建筑能否升到指定等级：
+// 除大厅外的建筑等级不能超过大厅等级，大厅升级需要仓库和城墙达到前一级
+func checkBuildable(city *pb.City, building string, level int32) error {
+	def, ok := buildingTable[building]
+	if !ok {
+		return newGameError(ErrCodeInvalidRequest, "unknown building: %s", building)
+	}
+	if level > def.maxLevel {
+		return newGameError(ErrCodeMaxLevel, "%s is already at max level %d", building, def.maxLevel)
+	}
+
+	requires := make(map[string]int32)
+	for required, requiredLevel := range def.requires {
+		requires[required] = requiredLevel
+	}
+	if building == BuildingTownHall {
+		if level > townHallGatingLevel {
+			requires[BuildingWarehouse] = level - 1
+			requires[BuildingWall] = level - 1
+		}
+	} else if requires[BuildingTownHall] < level {
+		requires[BuildingTownHall] = level
+	}
+
+	// 按建筑类型排序，保证错误信息稳定
+	required := make([]string, 0, len(requires))
+	for name := range requires {
+		required = append(required, name)
+	}
+	sort.Strings(required)
+	for _, name := range required {
+		if city.Buildings[name] < requires[name] {
+			return newGameError(ErrCodePrerequisiteNotMet, "%s level %d requires %s level %d",
+				building, level, name, requires[name])
+		}
+	}
+	return nil
+}
+
+// handleBuildMessage 处理建造相关的请求
+func (a *GameActor) handleBuildMessage(ctx *actor.Context, msg *pb.GameMessage) {
+	player, ok := a.players[msg.Id]
+	if !ok {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodePlayerNotFound, "player not found"))
+		return
+	}
+	var req pb.BuildRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid build request format"))
+		return
+	}
+
+	var err error
+	switch msg.Type {
+	case "build_start":
+		err = a.startBuild(ctx, player, req.Building)
+	case "build_speedup":
+		err = a.speedupBuild(ctx, player, req.TaskId, req.Seconds)
+	case "build_cancel":
+		err = a.cancelBuild(ctx, player, req.TaskId)
+	}
+	if err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, err)
+		return
+	}
+	a.savePlayer(ctx, player)
+	a.pushCityUpdate(ctx, player)
+	a.pushResourcesUpdate(ctx, player)
+}
+
+// startBuild 扣除建造资源，将建筑升级加入建造队列
+func (a *GameActor) startBuild(ctx *actor.Context, player *pb.PlayerData, building string) error {
+	city := player.City
+	if len(city.Queue) >= maxBuildQueue {
+		return newGameError(ErrCodeBuildQueueFull, "build queue is full: max %d", maxBuildQueue)
+	}
+	for _, task := range city.Queue {
+		if task.Building == building {
+			return newGameError(ErrCodeAlreadyBuilding, "%s is already under construction", building)
+		}
+	}
+	level := city.Buildings[building] + 1
+	if err := checkBuildable(city, building, level); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	settleResources(player, now)
+	if err := spendResources(player, buildCost(building, level)); err != nil {
+		return err
+	}
+
+	a.buildSeq++
+	task := &pb.BuildTask{
+		Id:       fmt.Sprintf("%s%d", buildTaskPrefix, a.buildSeq),
+		Building: building,
+		Level:    level,
+		StartAt:  now.UnixMilli(),
+		FinishAt: now.Add(buildDuration(building, level)).UnixMilli(),
+	}
+	city.Queue = append(city.Queue, task)
+	a.scheduleBuild(ctx, player.Id, task)
+	log.Printf("[GameActor] Player %s started building %s level %d, task=%s", player.Id, building, level, task.Id)
+	return nil
+}
+
+// speedupBuild 使用加速时间缩短建造任务，seconds 为0时加速到完成
+func (a *GameActor) speedupBuild(ctx *actor.Context, player *pb.PlayerData, taskID string, seconds int64) error {
+	task := findBuildTask(player.City, taskID)
+	if task == nil {
+		return newGameError(ErrCodeTaskNotFound, "build task not found: %s", taskID)
+	}
+	if seconds < 0 {
+		return newGameError(ErrCodeInvalidRequest, "invalid speedup seconds: %d", seconds)
+	}
+
+	now := time.Now().UnixMilli()
+	remaining := (task.FinishAt - now + 999) / 1000
+	if remaining < 0 {
+		remaining = 0
+	}
+	if seconds == 0 || seconds > remaining {
+		seconds = remaining
+	}
+	if seconds > player.City.SpeedupSeconds {
+		return newGameError(ErrCodeNotEnoughSpeedup, "not enough speedup time: have %d, need %d",
+			player.City.SpeedupSeconds, seconds)
+	}
+
+	player.City.SpeedupSeconds -= seconds
+	task.FinishAt -= seconds * 1000
+	if task.FinishAt <= now {
+		a.completeBuild(ctx, player, task)
+		return nil
+	}
+	a.scheduleBuild(ctx, player.Id, task)
+	return nil
+}
+
+// cancelBuild 取消建造任务并全额返还建造资源，已使用的加速时间不退还
+func (a *GameActor) cancelBuild(ctx *actor.Context, player *pb.PlayerData, taskID string) error {
+	task := findBuildTask(player.City, taskID)
+	if task == nil {
+		return newGameError(ErrCodeTaskNotFound, "build task not found: %s", taskID)
+	}
+	a.removeBuildTask(player.City, taskID)
+	settleResources(player, time.Now())
+	addResources(player, buildCost(task.Building, task.Level))
+	log.Printf("[GameActor] Player %s cancelled build task %s", player.Id, taskID)
+	return nil
+}
+
+// scheduleBuild 设置建造任务的完成计时，替换之前的计时
+func (a *GameActor) scheduleBuild(ctx *actor.Context, playerID string, task *pb.BuildTask) {
+	if timer, ok := a.buildTimers[task.Id]; ok {
+		timer.Stop()
+	}
+	pid := ctx.PID()
+	engine := ctx.Engine()
+	msg := &buildComplete{playerID: playerID, taskID: task.Id, finishAt: task.FinishAt}
+	delay := time.Until(time.UnixMilli(task.FinishAt))
+	a.buildTimers[task.Id] = time.AfterFunc(delay, func() {
+		engine.Send(pid, msg)
+	})
+}
+
+// handleBuildComplete 建造计时结束，提升建筑等级
+func (a *GameActor) handleBuildComplete(ctx *actor.Context, msg *buildComplete) {
+	player, ok := a.players[msg.playerID]
+	if !ok {
+		return
+	}
+	task := findBuildTask(player.City, msg.taskID)
+	if task == nil || task.FinishAt != msg.finishAt {
+		return
+	}
+	a.completeBuild(ctx, player, task)
+	a.savePlayer(ctx, player)
+	a.pushCityUpdate(ctx, player)
+	a.pushResourcesUpdate(ctx, player)
+}
+
+// completeBuild 完成建造任务并推送 build_complete
+func (a *GameActor) completeBuild(ctx *actor.Context, player *pb.PlayerData, task *pb.BuildTask) {
+	a.removeBuildTask(player.City, task.Id)
+	// 产量、仓库容量和城防耐久上限随建筑等级变化，先按旧等级结算
+	now := time.Now()
+	settleResources(player, now)
+	settleDurability(player.City, now)
+	player.City.Buildings[task.Building] = task.Level
+	sendToPlayer(ctx, a.gatewayPID, player.Id, "build_complete", task)
+	log.Printf("[GameActor] Player %s finished building %s level %d", player.Id, task.Building, task.Level)
+
+	// 大厅等级即地图上主城的等级
+	if task.Building == BuildingTownHall {
+		a.updateCityEntity(ctx, player)
+	}
+}
+
+// removeBuildTask 从建造队列中移除任务并停止计时
+func (a *GameActor) removeBuildTask(city *pb.City, taskID string) {
+	if timer, ok := a.buildTimers[taskID]; ok {
+		timer.Stop()
+		delete(a.buildTimers, taskID)
+	}
+	for i, task := range city.Queue {
+		if task.Id == taskID {
+			city.Queue = append(city.Queue[:i], city.Queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// findBuildTask 按ID查找建造任务
+func findBuildTask(city *pb.City, taskID string) *pb.BuildTask {
+	for _, task := range city.Queue {
+		if task.Id == taskID {
+			return task
+		}
+	}
+	return nil
+}
+
+// updateCityEntity 更新地图上的主城实体
+func (a *GameActor) updateCityEntity(ctx *actor.Context, player *pb.PlayerData) {
+	if a.mapPID == nil || player.Home == nil {
+		return
+	}
+	ctx.Engine().Send(a.mapPID, &mapEntityUpdate{entity: &pb.MapEntity{
+		Id:      cityEntityPrefix + player.Id,
+		Kind:    world.EntityCity,
+		X:       player.Home.X,
+		Y:       player.Home.Y,
+		OwnerId: player.Id,
+		Name:    player.Name,
+		Level:   player.City.Buildings[BuildingTownHall],
+	}})
+}
+
+// pushCityUpdate 将主城的最新状态推送给玩家
+func (a *GameActor) pushCityUpdate(ctx *actor.Context, player *pb.PlayerData) {
+	settleDurability(player.City, time.Now())
+	sendToPlayer(ctx, a.gatewayPID, player.Id, "city_update", player.City)
+}
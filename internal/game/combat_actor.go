@@ -2,29 +2,71 @@ package game
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/storage"
 	pb "github.com/cowpeatechnology/slg-game-server/proto"
+	"google.golang.org/protobuf/proto"
 )
 
 // CombatActor handles battle logic
 type CombatActor struct {
 	engine     *actor.Engine
-	combatData sync.Map   // 战斗数据，key为id
-	gamePID    *actor.PID // 游戏Actor的引用
-	battles    map[string]*pb.BattleResult
+	combatData sync.Map                    // 战斗数据，key为id
+	gamePID    *actor.PID                  // 游戏Actor的引用
+	gatewayPID *actor.PID                  // 网关Actor的引用，查询结果直接发给玩家
+	storagePID *actor.PID                  // 存储Actor的引用
+	battles    map[string]*pb.BattleResult // 最近战报缓存，key为战斗ID
+	battleIDs  []string                    // 缓存中的战斗ID，按结算先后排列
+	battleSeq  int64                       // 战斗序号，用于生成战斗ID
+
+	battleTimeout time.Duration            // 战斗超时时间
+	activeBattles map[string]*activeBattle // 进行中的战斗，key为战斗ID
+	activePlayers map[string]string        // 战斗中的玩家，value为战斗ID
+
+	queries  map[string]*battleQuery // 等待 StorageActor 返回的查询，key为请求ID
+	querySeq int64                   // 查询序号，用于生成请求ID
+}
+
+// battleQueryKind 玩家查询战报的方式，决定 StorageActor 返回结果后的处理
+type battleQueryKind int
+
+const (
+	queryBattleReport  battleQueryKind = iota // battle_history 指定战斗ID，返回单场战报
+	queryBattleHistory                        // battle_history 分页返回战斗摘要
+	queryBattleReplay                         // battle_replay 复盘单场战斗
+)
+
+// battleQuery 等待 StorageActor 返回结果的玩家查询
+type battleQuery struct {
+	playerID    string
+	requestType string
+	kind        battleQueryKind
 }
 
+// maxCachedBattles 内存中缓存的战报数量上限
+const maxCachedBattles = 1000
+
 // NewCombatActor creates a new Combat Actor
-func NewCombatActor() actor.Producer {
+func NewCombatActor(battleTimeout time.Duration) actor.Producer {
+	if battleTimeout <= 0 {
+		battleTimeout = defaultBattleTimeout
+	}
 	return func() actor.Receiver {
 		return &CombatActor{
-			battles: make(map[string]*pb.BattleResult),
+			battles:       make(map[string]*pb.BattleResult),
+			battleTimeout: battleTimeout,
+			activeBattles: make(map[string]*activeBattle),
+			activePlayers: make(map[string]string),
+
+			queries: make(map[string]*battleQuery),
 		}
 	}
 }
@@ -46,11 +88,35 @@ func (a *CombatActor) Receive(ctx *actor.Context) {
 			a.gamePID = msg
 			log.Printf("[CombatActor] Received GameActor PID: %v", msg)
 		}
+		if msg.ID != "" && strings.Contains(msg.ID, "gateway/") {
+			a.gatewayPID = msg
+			log.Printf("[CombatActor] Received GatewayActor PID: %v", msg)
+		}
+		if msg.ID != "" && strings.Contains(msg.ID, "storage/") {
+			a.storagePID = msg
+			log.Printf("[CombatActor] Received StorageActor PID: %v", msg)
+		}
+
+	case *pb.PlayerData:
+		// GameActor 同步过来的玩家属性快照，用于战斗结算
+		a.combatData.Store(msg.Id, msg)
+
+	case *playerRemoved:
+		a.combatData.Delete(msg.playerID)
+
+	case *storage.StorageResponseMessage:
+		a.handleStorageResponse(ctx, msg)
+
+	case *battleSettled:
+		a.handleBattleSettled(ctx, msg)
+
+	case *battleTimeout:
+		a.handleBattleTimeout(ctx, msg)
 
 	case *pb.GameMessage:
 		if err := a.validateMessage(msg); err != nil {
 			log.Printf("[CombatActor] 消息验证失败: %v", err)
-			a.sendError(ctx, msg.Id, msg.Type, err)
+			reportFailure(ctx, a.gamePID, msg.Id, msg.Type, err)
 			return
 		}
 		log.Printf("[CombatActor] 收到消息: type=%s, id=%s", msg.Type, msg.Id)
@@ -72,74 +138,277 @@ func (a *CombatActor) validateMessage(msg *pb.GameMessage) error {
 	return nil
 }
 
-// sendError 发送错误消息给GameActor
-func (a *CombatActor) sendError(ctx *actor.Context, id string, msgType string, err error) {
-	if a.gamePID == nil {
-		log.Printf("[CombatActor] 无法发送错误消息: gamePID为空")
+// handleCombatMessage processes combat-related messages
+func (a *CombatActor) handleCombatMessage(ctx *actor.Context, msg *pb.GameMessage) {
+	switch msg.Type {
+	case "battle_request":
+		a.handleBattleRequest(ctx, msg)
+
+	case "battle_history":
+		a.handleBattleHistory(ctx, msg)
+
+	case "battle_replay":
+		a.handleBattleReplay(ctx, msg)
+
+	default:
+		err := fmt.Errorf("未知的消息类型: %s", msg.Type)
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, err)
+	}
+}
+
+// handleBattleRequest 受理战斗请求，结算后将结果发送给 GameActor
+func (a *CombatActor) handleBattleRequest(ctx *actor.Context, msg *pb.GameMessage) {
+	var battleReq pb.BattleRequest
+	if err := json.Unmarshal(msg.Payload, &battleReq); err != nil {
+		log.Printf("[CombatActor] Failed to unmarshal battle request: %v", err)
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid battle request format"))
 		return
 	}
+	log.Printf("[CombatActor] Received battle request: attacker=%s, defender=%s, army=%s",
+		battleReq.AttackerId, battleReq.DefenderId, formatTroops(battleReq.Army))
 
-	errMsg := &pb.GameMessage{
-		Type:    msgType + "_error",
-		Id:      id,
-		Payload: []byte(err.Error()),
+	// 查找双方的战斗属性
+	attacker, ok := a.getCombatData(battleReq.AttackerId)
+	if !ok {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodePlayerNotFound, "attacker not found: %s", battleReq.AttackerId))
+		return
+	}
+	defender, ok := a.getCombatData(battleReq.DefenderId)
+	if !ok {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodePlayerNotFound, "defender not found: %s", battleReq.DefenderId))
+		return
+	}
+	if a.inBattle(attacker.Id) || a.inBattle(defender.Id) {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeInBattle, "player is already in a battle"))
+		return
 	}
 
-	ctx.Engine().Send(a.gamePID, errMsg)
+	battle := a.startBattle(ctx, attacker.Id, defender.Id)
+
+	// 每场战斗使用独立的随机种子，战报保存种子和输入快照，可通过 battle_replay 复现
+	input := &pb.BattleInput{
+		Attacker:       attacker,
+		Defender:       defender,
+		AttackerArmy:   battleReq.Army,
+		Seed:           rand.Int63(),
+		AttackerHero:   findHero(attacker, battleReq.HeroId),
+		DefenderHero:   findHero(defender, battleReq.DefenderHeroId),
+		DefenderArmy:   battleReq.DefenderArmy,
+		Reinforcements: battleReq.Reinforcements,
+	}
+	result := ResolveBattle(input)
+	result.Input = input
+	result.NodeId = battleReq.NodeId
+	result.ReinforcementIds = reinforcementOwners(battleReq.Reinforcements)
+	result.BattleId = battle.battleID
+	result.Timestamp = time.Now().Unix()
+	result.Status = pb.BattleStatus_BATTLE_STATUS_RESOLVED
+	battle.status = result.Status
+	a.recordBattle(ctx, result)
+
+	// Send battle result to GameActor
+	if a.gamePID != nil {
+		log.Printf("[CombatActor] Sending battle result: winner=%s, loser=%s, damage=%d, rounds=%d, seed=%d",
+			result.WinnerId, result.LoserId, result.DamageDealt, result.Rounds, result.Seed)
+		ctx.Engine().Send(a.gamePID, &battleResolved{result: publicReport(result)})
+	} else {
+		// 结果无法送达，等待超时通知双方
+		log.Printf("[CombatActor] Cannot send battle result: GameActor PID not available")
+	}
 }
 
-// handleCombatMessage processes combat-related messages
-func (a *CombatActor) handleCombatMessage(ctx *actor.Context, msg *pb.GameMessage) {
-	switch msg.Type {
-	case "battle_request":
-		var battleReq struct {
-			AttackerID string `json:"attacker_id"`
-			DefenderID string `json:"defender_id"`
+// nextBattleID 生成新的战斗ID
+func (a *CombatActor) nextBattleID() string {
+	a.battleSeq++
+	return fmt.Sprintf("battle_%d_%d", time.Now().Unix(), a.battleSeq)
+}
+
+// recordBattle 缓存战报并通过 StorageActor 持久化
+func (a *CombatActor) recordBattle(ctx *actor.Context, result *pb.BattleResult) {
+	a.battles[result.BattleId] = result
+	a.battleIDs = append(a.battleIDs, result.BattleId)
+	if len(a.battleIDs) > maxCachedBattles {
+		delete(a.battles, a.battleIDs[0])
+		a.battleIDs = a.battleIDs[1:]
+	}
+
+	if a.storagePID == nil {
+		log.Printf("[CombatActor] 无法保存战报: storagePID为空")
+		return
+	}
+	ctx.Engine().Send(a.storagePID, &storage.StorageRequestMessage{
+		Type: "save_battle",
+		Key:  result.BattleId,
+		Data: proto.Clone(result),
+	})
+}
+
+// updateBattle 更新已保存战报的状态，不改变战斗历史索引
+func (a *CombatActor) updateBattle(ctx *actor.Context, result *pb.BattleResult) {
+	if a.storagePID == nil {
+		return
+	}
+	ctx.Engine().Send(a.storagePID, &storage.StorageRequestMessage{
+		Type: "update_battle",
+		Key:  result.BattleId,
+		Data: proto.Clone(result),
+	})
+}
+
+// handleBattleHistory 处理战斗历史查询：指定战斗ID时返回单场战报，否则分页返回战斗摘要
+func (a *CombatActor) handleBattleHistory(ctx *actor.Context, msg *pb.GameMessage) {
+	var query pb.BattleHistoryRequest
+	if len(msg.Payload) > 0 {
+		if err := json.Unmarshal(msg.Payload, &query); err != nil {
+			reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid battle history request format"))
+			return
 		}
-		if err := json.Unmarshal(msg.Payload, &battleReq); err != nil {
-			log.Printf("[CombatActor] Failed to unmarshal battle request: %v", err)
-			a.sendError(ctx, msg.Id, msg.Type, fmt.Errorf("invalid battle request format"))
+	}
+
+	if query.BattleId != "" {
+		// 优先从缓存读取
+		if battle, ok := a.battles[query.BattleId]; ok {
+			a.sendBattleReport(ctx, msg.Id, battle)
 			return
 		}
-		log.Printf("[CombatActor] Received battle request: attacker=%s, defender=%s",
-			battleReq.AttackerID, battleReq.DefenderID)
-
-		// Simple battle logic: random winner and damage
-		var result pb.BattleResult
-		if rand.Float32() > 0.5 {
-			result.WinnerId = battleReq.AttackerID
-			result.LoserId = battleReq.DefenderID
-		} else {
-			result.WinnerId = battleReq.DefenderID
-			result.LoserId = battleReq.AttackerID
+		if a.storagePID == nil {
+			reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeBattleNotFound, "battle not found: %s", query.BattleId))
+			return
 		}
-		result.DamageDealt = int32(rand.Intn(50) + 10)
+		a.query(ctx, &battleQuery{playerID: msg.Id, requestType: msg.Type, kind: queryBattleReport}, "get_battle", query.BattleId, nil)
+		return
+	}
 
-		// Serialize battle result
-		resultBytes, err := json.Marshal(&result)
-		if err != nil {
-			log.Printf("[CombatActor] Failed to marshal battle result: %v", err)
-			return
+	if a.storagePID == nil {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeServiceUnavailable, "storage service not available"))
+		return
+	}
+	a.query(ctx, &battleQuery{playerID: msg.Id, requestType: msg.Type, kind: queryBattleHistory}, "list_battles", msg.Id, &query)
+}
+
+// query 记录等待中的查询并发给 StorageActor，每个查询使用独立的请求ID，
+// 同一玩家对同一战斗的多个查询不会混淆。使用 ctx.Send 以便 StorageActor 回复
+func (a *CombatActor) query(ctx *actor.Context, q *battleQuery, requestType, key string, data interface{}) {
+	a.querySeq++
+	requestID := fmt.Sprintf("combat_%d", a.querySeq)
+	a.queries[requestID] = q
+	ctx.Send(a.storagePID, &storage.StorageRequestMessage{
+		Type:      requestType,
+		Key:       key,
+		Data:      data,
+		RequestID: requestID,
+	})
+}
+
+// handleStorageResponse 按请求ID找到对应的查询，将 StorageActor 返回的结果发给发起查询的玩家
+func (a *CombatActor) handleStorageResponse(ctx *actor.Context, msg *storage.StorageResponseMessage) {
+	q, ok := a.queries[msg.RequestID]
+	if !ok {
+		return
+	}
+	delete(a.queries, msg.RequestID)
+	if msg.Error != nil {
+		err := msg.Error
+		if errors.Is(err, storage.ErrNotFound) {
+			err = newGameError(ErrCodeBattleNotFound, "battle not found")
 		}
+		reportFailure(ctx, a.gamePID, q.playerID, q.requestType, err)
+		return
+	}
 
-		// Send battle result to GameActor
-		if a.gamePID != nil {
-			battleResultMsg := &pb.GameMessage{
-				Type:    "battle_result",
-				Id:      msg.Id, // 保持原始消息的ID
-				Payload: resultBytes,
-			}
-			log.Printf("[CombatActor] Sending battle result: winner=%s, loser=%s, damage=%d",
-				result.WinnerId, result.LoserId, result.DamageDealt)
-			ctx.Engine().Send(a.gamePID, battleResultMsg)
+	switch q.kind {
+	case queryBattleReport, queryBattleReplay:
+		battle, ok := msg.Data.(*pb.BattleResult)
+		if !ok {
+			return
+		}
+		if q.kind == queryBattleReplay {
+			a.replayBattle(ctx, q.playerID, battle)
 		} else {
-			log.Printf("[CombatActor] Cannot send battle result: GameActor PID not available")
+			a.sendBattleReport(ctx, q.playerID, battle)
 		}
+	case queryBattleHistory:
+		if history, ok := msg.Data.(*pb.BattleHistoryResponse); ok {
+			sendToPlayer(ctx, a.gatewayPID, q.playerID, "battle_history_response", history)
+		}
+	}
+}
 
-	default:
-		err := fmt.Errorf("未知的消息类型: %s", msg.Type)
-		a.sendError(ctx, msg.Id, msg.Type, err)
+// handleBattleReplay 处理战斗复盘请求：按保存的种子和输入重新结算，并与原战报比对
+func (a *CombatActor) handleBattleReplay(ctx *actor.Context, msg *pb.GameMessage) {
+	var req pb.BattleReplayRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil || req.BattleId == "" {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeInvalidRequest, "battle_id is required"))
+		return
+	}
+
+	if battle, ok := a.battles[req.BattleId]; ok {
+		a.replayBattle(ctx, msg.Id, battle)
+		return
+	}
+	if a.storagePID == nil {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeBattleNotFound, "battle not found: %s", req.BattleId))
+		return
+	}
+	a.query(ctx, &battleQuery{playerID: msg.Id, requestType: msg.Type, kind: queryBattleReplay}, "get_battle", req.BattleId, nil)
+}
+
+// replayBattle 复盘战斗并将结果发送给玩家，只有参战双方和守城援军可以复盘
+func (a *CombatActor) replayBattle(ctx *actor.Context, playerID string, battle *pb.BattleResult) {
+	if !battleParticipant(battle, playerID) {
+		reportFailure(ctx, a.gamePID, playerID, "battle_replay", newGameError(ErrCodeBattleNotFound, "battle not found: %s", battle.BattleId))
+		return
+	}
+	replay, err := ReplayBattle(battle)
+	if err != nil {
+		reportFailure(ctx, a.gamePID, playerID, "battle_replay", newGameError(ErrCodeBattleNotReplayable, "%v", err))
+		return
+	}
+
+	identical := SameOutcome(battle, replay)
+	if !identical {
+		log.Printf("[CombatActor] Replay mismatch: battle=%s, seed=%d", battle.BattleId, battle.Seed)
+	}
+	sendToPlayer(ctx, a.gatewayPID, playerID, "battle_replay_response", &pb.BattleReplayResponse{
+		BattleId:  battle.BattleId,
+		Identical: identical,
+		Replay:    publicReport(replay),
+	})
+}
+
+// sendBattleReport 发送单场战报，只有参战双方和守城援军可以查看
+func (a *CombatActor) sendBattleReport(ctx *actor.Context, playerID string, battle *pb.BattleResult) {
+	if !battleParticipant(battle, playerID) {
+		reportFailure(ctx, a.gamePID, playerID, "battle_history", newGameError(ErrCodeBattleNotFound, "battle not found: %s", battle.BattleId))
+		return
+	}
+	sendToPlayer(ctx, a.gatewayPID, playerID, "battle_report", publicReport(battle))
+}
+
+// battleParticipant 检查玩家是否参加了战斗：进攻方、防守方或守城援军所属玩家
+func battleParticipant(battle *pb.BattleResult, playerID string) bool {
+	if battle.AttackerId == playerID || battle.DefenderId == playerID {
+		return true
+	}
+	for _, id := range battle.ReinforcementIds {
+		if id == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// reinforcementOwners 援军所属玩家ID，同一玩家只记录一次
+func reinforcementOwners(reinforcements []*pb.Reinforcement) []string {
+	var owners []string
+	seen := make(map[string]bool)
+	for _, r := range reinforcements {
+		if !seen[r.OwnerId] {
+			seen[r.OwnerId] = true
+			owners = append(owners, r.OwnerId)
+		}
 	}
+	return owners
 }
 
 // getCombatData 获取玩家的战斗数据
@@ -0,0 +1,89 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/storage"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// TestCombatActorConcurrentQueries 同一玩家对同一战斗同时发起的查询各自得到对应的回复，
+// 与 StorageActor 的回复顺序无关
+func TestCombatActorConcurrentQueries(t *testing.T) {
+	engine, err := actor.NewEngine(actor.NewEngineConfig())
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	requests := make(chan *storage.StorageRequestMessage, 8)
+	replies := make(chan *pb.GameMessage, 8)
+	storagePID := engine.SpawnFunc(func(ctx *actor.Context) {
+		if msg, ok := ctx.Message().(*storage.StorageRequestMessage); ok {
+			requests <- msg
+		}
+	}, "storage")
+	gatewayPID := engine.SpawnFunc(func(ctx *actor.Context) {
+		if msg, ok := ctx.Message().(*pb.GameMessage); ok {
+			replies <- msg
+		}
+	}, "gateway")
+
+	a := NewCombatActor(0)().(*CombatActor)
+	a.storagePID = storagePID
+	a.gatewayPID = gatewayPID
+
+	input := testBattleInput(3)
+	battle := ResolveBattle(input)
+	battle.BattleId = "battle_1"
+	battle.AttackerId = "attacker"
+	battle.Input = input
+
+	replayPayload, _ := json.Marshal(&pb.BattleReplayRequest{BattleId: battle.BattleId})
+	historyPayload, _ := json.Marshal(&pb.BattleHistoryRequest{BattleId: battle.BattleId})
+	runInEngine(t, engine, func(ctx *actor.Context) {
+		a.handleBattleReplay(ctx, &pb.GameMessage{Type: "battle_replay", Id: "attacker", Payload: replayPayload})
+		a.handleBattleHistory(ctx, &pb.GameMessage{Type: "battle_history", Id: "attacker", Payload: historyPayload})
+		a.handleBattleReplay(ctx, &pb.GameMessage{Type: "battle_replay", Id: "attacker", Payload: replayPayload})
+	})
+
+	var sent []*storage.StorageRequestMessage
+	for len(sent) < 3 {
+		select {
+		case req := <-requests:
+			sent = append(sent, req)
+		case <-time.After(time.Second):
+			t.Fatalf("%d storage requests sent, want 3", len(sent))
+		}
+	}
+	if sent[0].RequestID == sent[1].RequestID || sent[0].RequestID == sent[2].RequestID || sent[1].RequestID == sent[2].RequestID {
+		t.Fatalf("storage requests share request IDs: %s, %s, %s", sent[0].RequestID, sent[1].RequestID, sent[2].RequestID)
+	}
+
+	// StorageActor 以相反的顺序回复
+	runInEngine(t, engine, func(ctx *actor.Context) {
+		for i := len(sent) - 1; i >= 0; i-- {
+			a.handleStorageResponse(ctx, &storage.StorageResponseMessage{
+				Type:      sent[i].Type,
+				Key:       sent[i].Key,
+				Data:      battle,
+				RequestID: sent[i].RequestID,
+			})
+		}
+	})
+	want := []string{"battle_replay_response", "battle_report", "battle_replay_response"}
+	for _, wantType := range want {
+		select {
+		case msg := <-replies:
+			if msg.Type != wantType || msg.Id != "attacker" {
+				t.Errorf("reply %s to %s, want %s to attacker", msg.Type, msg.Id, wantType)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %s sent", wantType)
+		}
+	}
+	if len(a.queries) != 0 {
+		t.Errorf("%d queries still pending", len(a.queries))
+	}
+}
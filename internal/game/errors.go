@@ -0,0 +1,94 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// 错误码，随 error 消息返回给客户端
+const (
+	ErrCodeUnknown             = "unknown"
+	ErrCodeInvalidRequest      = "invalid_request"
+	ErrCodePlayerNotFound      = "player_not_found"
+	ErrCodePlayerOffline       = "player_offline"
+	ErrCodeNotAuthorized       = "not_authorized"
+	ErrCodeSelfAttack          = "self_attack"
+	ErrCodeInBattle            = "in_battle"
+	ErrCodeAttackCooldown      = "attack_cooldown"
+	ErrCodeTargetProtected     = "target_protected"
+	ErrCodePlayerRecovering    = "player_recovering"
+	ErrCodeNotEnoughTroops     = "not_enough_troops"
+	ErrCodeHeroNotFound        = "hero_not_found"
+	ErrCodeBattleNotFound      = "battle_not_found"
+	ErrCodeBattleNotReplayable = "battle_not_replayable"
+	ErrCodeServiceUnavailable  = "service_unavailable"
+	ErrCodeHeroBusy            = "hero_busy"
+	ErrCodeMarchLimit          = "march_limit"
+	ErrCodeMarchNotFound       = "march_not_found"
+	ErrCodeBuildQueueFull      = "build_queue_full"
+	ErrCodeAlreadyBuilding     = "already_building"
+	ErrCodePrerequisiteNotMet  = "prerequisite_not_met"
+	ErrCodeMaxLevel            = "max_level"
+	ErrCodeTaskNotFound        = "task_not_found"
+	ErrCodeNotEnoughSpeedup    = "not_enough_speedup"
+	ErrCodeNotEnoughResources  = "not_enough_resources"
+	ErrCodeTrainQueueFull      = "train_queue_full"
+	ErrCodeNodeNotFound        = "node_not_found"
+	ErrCodeAlreadyGathering    = "already_gathering"
+	ErrCodeAllianceNotFound    = "alliance_not_found"
+	ErrCodeAlreadyInAlliance   = "already_in_alliance"
+	ErrCodeNotInAlliance       = "not_in_alliance"
+	ErrCodeAllianceFull        = "alliance_full"
+	ErrCodeAllianceNameTaken   = "alliance_name_taken"
+	ErrCodePermissionDenied    = "permission_denied"
+	ErrCodeNotAllied           = "not_allied"
+	ErrCodeAlreadyReinforcing  = "already_reinforcing"
+	ErrCodeReinforcementFull   = "reinforcement_full"
+	ErrCodeHeroOwned           = "hero_owned"
+	ErrCodeEquipmentNotFound   = "equipment_not_found"
+)
+
+// requestFailed 其他 Actor 处理玩家请求失败时发给 GameActor 的通知，
+// 由 GameActor 以 error 消息转发给玩家
+type requestFailed struct {
+	playerID    string
+	requestType string
+	err         error
+}
+
+// gameError 带错误码的业务错误
+type gameError struct {
+	code    string
+	message string
+}
+
+func (e *gameError) Error() string {
+	return e.message
+}
+
+// newGameError 创建带错误码的业务错误
+func newGameError(code string, format string, args ...interface{}) error {
+	return &gameError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// errorPayload 将错误编码为 ErrorResponse，非业务错误使用 ErrCodeUnknown
+func errorPayload(msgType string, err error) []byte {
+	response := &pb.ErrorResponse{
+		Code:        ErrCodeUnknown,
+		Message:     err.Error(),
+		RequestType: msgType,
+	}
+	var gerr *gameError
+	if errors.As(err, &gerr) {
+		response.Code = gerr.code
+	}
+
+	data, marshalErr := json.Marshal(response)
+	if marshalErr != nil {
+		return []byte(err.Error())
+	}
+	return data
+}
@@ -8,22 +8,52 @@ import (
 	"time"
 
 	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/storage"
 	pb "github.com/cowpeatechnology/slg-game-server/proto"
+	"google.golang.org/protobuf/proto"
 )
 
 // GameActor handles game logic
 type GameActor struct {
-	engine     *actor.Engine
-	players    map[string]*pb.PlayerData
-	gatewayPID *actor.PID
-	combatPID  *actor.PID
+	engine      *actor.Engine
+	players     map[string]*pb.PlayerData
+	gatewayPID  *actor.PID
+	combatPID   *actor.PID
+	mapPID      *actor.PID
+	storagePID  *actor.PID
+	alliancePID *actor.PID
+	accountPID  *actor.PID
+
+	online          map[string]bool      // 在线玩家
+	guests          map[string]bool      // 没有账号的临时玩家，数据不持久化
+	battling        map[string]string    // 战斗中的玩家，value为对手ID
+	attackCooldowns map[string]time.Time // 玩家下次可发起攻击的时间
+
+	marches  map[string]*activeMarch     // 进行中的行军，key为行军ID
+	marchSeq int64                       // 行军序号，用于生成行军ID
+	fighting map[string]string           // 等待战斗结算的行军，key为进攻方ID
+	nodes    map[string]*pb.ResourceNode // 地图上的资源点，key为资源点ID
+
+	buildSeq    int64                  // 建造任务序号，用于生成任务ID
+	buildTimers map[string]*time.Timer // 建造任务的完成计时，key为任务ID
+	trainSeq    int64                  // 训练任务序号，用于生成任务ID
+	trainTimers map[string]*time.Timer // 训练任务的完成计时，key为任务ID
 }
 
 // NewGameActor creates a new Game Actor
 func NewGameActor() actor.Producer {
 	return func() actor.Receiver {
 		return &GameActor{
-			players: make(map[string]*pb.PlayerData),
+			players:         make(map[string]*pb.PlayerData),
+			online:          make(map[string]bool),
+			guests:          make(map[string]bool),
+			battling:        make(map[string]string),
+			attackCooldowns: make(map[string]time.Time),
+			marches:         make(map[string]*activeMarch),
+			fighting:        make(map[string]string),
+			nodes:           make(map[string]*pb.ResourceNode),
+			buildTimers:     make(map[string]*time.Timer),
+			trainTimers:     make(map[string]*time.Timer),
 		}
 	}
 }
@@ -46,12 +76,64 @@ func (a *GameActor) Receive(ctx *actor.Context) {
 				a.combatPID = msg
 				log.Printf("[GameActor] Received CombatActor PID: %v", msg)
 			}
+			if strings.Contains(msg.ID, "map/") {
+				a.mapPID = msg
+				log.Printf("[GameActor] Received MapActor PID: %v", msg)
+			}
 			if strings.Contains(msg.ID, "gateway/") {
 				a.gatewayPID = msg
 				log.Printf("[GameActor] Received GatewayActor PID: %v", msg)
 			}
+			if strings.Contains(msg.ID, "storage/") {
+				a.storagePID = msg
+				log.Printf("[GameActor] Received StorageActor PID: %v", msg)
+			}
+			if strings.Contains(msg.ID, "alliance/") {
+				a.alliancePID = msg
+				log.Printf("[GameActor] Received AllianceActor PID: %v", msg)
+			}
+			if strings.Contains(msg.ID, "account/") {
+				a.accountPID = msg
+				log.Printf("[GameActor] Received AccountActor PID: %v", msg)
+			}
 		}
 
+	case *recoverPlayer:
+		a.handleRecoverPlayer(ctx, msg)
+
+	case *marchArrive:
+		a.handleMarchArrive(ctx, msg)
+
+	case *cityPlaced:
+		a.handleCityPlaced(ctx, msg)
+
+	case *nodeSpawned:
+		a.handleNodeSpawned(msg)
+
+	case *buildComplete:
+		a.handleBuildComplete(ctx, msg)
+
+	case *trainComplete:
+		a.handleTrainComplete(ctx, msg)
+
+	case *battleResolved:
+		a.handleBattleResolved(ctx, msg)
+
+	case *battleTimedOut:
+		a.handleBattleTimeout(ctx, msg)
+
+	case *requestFailed:
+		a.handleRequestFailed(ctx, msg)
+
+	case *allianceChanged:
+		a.handleAllianceChanged(ctx, msg)
+
+	case *accountLogin:
+		a.handleAccountLogin(ctx, msg)
+
+	case *PlayerOfflineMessage:
+		a.handlePlayerOffline(ctx, msg)
+
 	case *pb.GameMessage:
 		a.handleGameMessage(ctx, msg)
 	}
@@ -61,63 +143,77 @@ func (a *GameActor) Receive(ctx *actor.Context) {
 func (a *GameActor) handleGameMessage(ctx *actor.Context, msg *pb.GameMessage) {
 	if err := a.validateMessage(msg); err != nil {
 		log.Printf("[GameActor] 消息验证失败: %v", err)
-		a.sendError(ctx, msg.Id, msg.Type, err)
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, err)
 		return
 	}
 
 	switch msg.Type {
 	case "player_join":
 		a.handlePlayerJoin(ctx, msg)
+	case "register", "login", "resume":
+		if a.accountPID != nil {
+			ctx.Engine().Send(a.accountPID, msg)
+		} else {
+			a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeServiceUnavailable, "account service not available"))
+		}
 	case "chat":
 		a.handleChat(ctx, msg)
 	case "battle_request":
+		a.handleBattleRequest(ctx, msg)
+	case "hero_list":
+		a.handleHeroList(ctx, msg)
+	case "hero_recruit", "equipment_forge", "hero_equip", "hero_unequip":
+		a.handleHeroMessage(ctx, msg)
+	case "march_recall":
+		a.handleMarchRecall(ctx, msg)
+	case "march_list":
+		a.handleMarchList(ctx, msg)
+	case "gather":
+		a.handleGather(ctx, msg)
+	case "reinforce":
+		a.handleReinforce(ctx, msg)
+	case "build_start", "build_speedup", "build_cancel":
+		a.handleBuildMessage(ctx, msg)
+	case "train_start", "train_cancel":
+		a.handleTrainMessage(ctx, msg)
+	case "resources_get":
+		a.handleResourcesGet(ctx, msg)
+	case "battle_history", "battle_replay":
+		if _, exists := a.players[msg.Id]; !exists {
+			a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodePlayerNotFound, "player not found"))
+			return
+		}
 		if a.combatPID != nil {
 			ctx.Engine().Send(a.combatPID, msg)
 		} else {
-			log.Printf("[GameActor] CombatActor PID not available")
-			a.sendError(ctx, msg.Id, msg.Type, fmt.Errorf("combat service not available"))
+			a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeServiceUnavailable, "combat service not available"))
 		}
-	case "battle_result":
-		// 解析战斗结果，获取参与战斗的玩家ID
-		var battleResult struct {
-			WinnerID    string `json:"winner_id"`
-			LoserID     string `json:"loser_id"`
-			DamageDealt int32  `json:"damage_dealt"`
+	case "alliance_create", "alliance_join", "alliance_leave", "alliance_kick", "alliance_promote",
+		"alliance_members", "alliance_list", "alliance_announce", "alliance_chat":
+		player, exists := a.players[msg.Id]
+		if !exists {
+			a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodePlayerNotFound, "player not found"))
+			return
 		}
-		if err := json.Unmarshal(msg.Payload, &battleResult); err != nil {
-			log.Printf("[GameActor] Failed to unmarshal battle result: %v", err)
+		// 临时玩家的数据不保存，下线后也不会离开联盟，不允许占用成员名额
+		if a.guests[msg.Id] && (msg.Type == "alliance_create" || msg.Type == "alliance_join") {
+			a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeNotAuthorized, "guests cannot join alliances"))
 			return
 		}
-
-		// 确保两个玩家都在线
-		_, winnerExists := a.players[battleResult.WinnerID]
-		_, loserExists := a.players[battleResult.LoserID]
-
-		if !winnerExists || !loserExists {
-			log.Printf("[GameActor] One or both players not found: winner=%s, loser=%s",
-				battleResult.WinnerID, battleResult.LoserID)
+		if a.alliancePID != nil {
+			ctx.Engine().Send(a.alliancePID, &allianceCommand{msg: msg, name: player.Name})
+		} else {
+			a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeServiceUnavailable, "alliance service not available"))
+		}
+	case "map_view", "map_subscribe", "map_unsubscribe":
+		if _, exists := a.players[msg.Id]; !exists {
+			a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodePlayerNotFound, "player not found"))
 			return
 		}
-
-		// 分别发送战斗结果给胜利者和失败者
-		if a.gatewayPID != nil {
-			// 发送给胜利者
-			winnerMsg := &pb.GameMessage{
-				Type:    "battle_result",
-				Id:      battleResult.WinnerID,
-				Payload: msg.Payload,
-			}
-			ctx.Engine().Send(a.gatewayPID, winnerMsg)
-			log.Printf("[GameActor] Sent battle result to winner: %s", battleResult.WinnerID)
-
-			// 发送给失败者
-			loserMsg := &pb.GameMessage{
-				Type:    "battle_result",
-				Id:      battleResult.LoserID,
-				Payload: msg.Payload,
-			}
-			ctx.Engine().Send(a.gatewayPID, loserMsg)
-			log.Printf("[GameActor] Sent battle result to loser: %s", battleResult.LoserID)
+		if a.mapPID != nil {
+			ctx.Engine().Send(a.mapPID, msg)
+		} else {
+			a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeServiceUnavailable, "map service not available"))
 		}
 	default:
 		log.Printf("[GameActor] Unknown message type: %s", msg.Type)
@@ -127,25 +223,33 @@ func (a *GameActor) handleGameMessage(ctx *actor.Context, msg *pb.GameMessage) {
 // handlePlayerJoin handles player join requests
 func (a *GameActor) handlePlayerJoin(ctx *actor.Context, msg *pb.GameMessage) {
 	// 生成玩家ID
-	playerID := fmt.Sprintf("player_%d", len(a.players)+1)
+	playerID, err := newPlayerID()
+	if err != nil {
+		log.Printf("[GameActor] Failed to generate player ID: %v", err)
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeServiceUnavailable, "failed to create player"))
+		return
+	}
 
 	// 创建新玩家数据
-	player := &pb.PlayerData{
-		Id:      playerID,
-		Name:    fmt.Sprintf("Player_%d", len(a.players)+1),
-		Level:   1,
-		Hp:      100,
-		Attack:  10,
-		Defense: 5,
-	}
+	player := newPlayerData(playerID, fmt.Sprintf("Player_%d", len(a.players)+1))
 
 	// 保存玩家数据
 	a.players[playerID] = player
+	a.online[playerID] = true
+	a.guests[playerID] = true
+	a.syncCombatData(ctx, player)
+
+	// 由 MapActor 在地图上分配主城位置
+	if a.mapPID != nil {
+		ctx.Engine().Send(a.mapPID, &placeCity{playerID: player.Id, name: player.Name, level: player.City.Buildings[BuildingTownHall]})
+	}
 
 	// 创建响应消息
+	// 带回请求的连接ID，GatewayActor 据此将玩家绑定到发起请求的连接
 	response := &pb.GameMessage{
-		Type: "player_join_response",
-		Id:   playerID,
+		Type:      "player_join_response",
+		Id:        playerID,
+		SessionId: msg.SessionId,
 		Payload: []byte(fmt.Sprintf(`{"id":"%s","name":"%s"}`,
 			player.Id, player.Name)),
 	}
@@ -158,12 +262,54 @@ func (a *GameActor) handlePlayerJoin(ctx *actor.Context, msg *pb.GameMessage) {
 	log.Printf("[GameActor] New player joined: %s", playerID)
 }
 
+// handleAccountLogin 账号验证通过后登录玩家：内存中已有的玩家直接使用，
+// 否则使用存储中加载的数据，新账号创建新玩家
+func (a *GameActor) handleAccountLogin(ctx *actor.Context, msg *accountLogin) {
+	player, exists := a.players[msg.account.PlayerId]
+	if !exists {
+		player = msg.player
+		if player == nil {
+			player = newPlayerData(msg.account.PlayerId, msg.account.Username)
+		}
+		a.players[player.Id] = player
+		a.restorePlayer(ctx, player)
+		// 玩家离线期间可能已被踢出联盟，由 AllianceActor 核对
+		if a.alliancePID != nil {
+			ctx.Engine().Send(a.alliancePID, &allianceReconcile{playerID: player.Id, allianceID: player.AllianceId})
+		}
+	}
+	a.online[player.Id] = true
+	a.syncCombatData(ctx, player)
+	a.savePlayer(ctx, player)
+
+	data, err := json.Marshal(&pb.LoginResponse{
+		Success:      true,
+		Message:      "login successful",
+		PlayerInfo:   playerSnapshot(player, time.Now()),
+		SessionToken: msg.token,
+	})
+	if err != nil {
+		log.Printf("[GameActor] Failed to marshal login response: %v", err)
+		return
+	}
+	// 登录、注册和断线重连都以成功的 login_response 回复，重连时玩家转到新连接
+	if a.gatewayPID != nil {
+		ctx.Engine().Send(a.gatewayPID, &pb.GameMessage{
+			Type:      "login_response",
+			Id:        player.Id,
+			SessionId: msg.sessionID,
+			Payload:   data,
+		})
+	}
+	log.Printf("[GameActor] Account %s logged in as %s", msg.account.Username, player.Id)
+}
+
 // handleChat processes chat messages
 func (a *GameActor) handleChat(ctx *actor.Context, msg *pb.GameMessage) {
 	// 确保发送者是已登录的玩家
 	sender, exists := a.players[msg.Id]
 	if !exists {
-		a.sendError(ctx, msg.Id, msg.Type, fmt.Errorf("player not found"))
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodePlayerNotFound, "player not found"))
 		return
 	}
 
@@ -185,6 +331,81 @@ func (a *GameActor) handleChat(ctx *actor.Context, msg *pb.GameMessage) {
 	}
 }
 
+// syncCombatData 将玩家属性快照同步给 CombatActor
+func (a *GameActor) syncCombatData(ctx *actor.Context, player *pb.PlayerData) {
+	if a.combatPID == nil {
+		return
+	}
+	ctx.Engine().Send(a.combatPID, proto.Clone(player).(*pb.PlayerData))
+}
+
+// savePlayer 将玩家数据快照交给 StorageActor 持久化。临时玩家无法再次登录，
+// 数据不保存，避免在 Redis 中无限累积
+func (a *GameActor) savePlayer(ctx *actor.Context, player *pb.PlayerData) {
+	if a.storagePID == nil || a.guests[player.Id] {
+		return
+	}
+	// 离开主城的部队保存在行军中，一并保存以免服务重启后丢失
+	snapshot := proto.Clone(player).(*pb.PlayerData)
+	for _, m := range a.marches {
+		if m.march.OwnerId == player.Id {
+			snapshot.Marches = append(snapshot.Marches, proto.Clone(m.march).(*pb.March))
+		}
+	}
+	ctx.Engine().Send(a.storagePID, &storage.StorageRequestMessage{
+		Type: "save_player",
+		Key:  player.Id,
+		Data: snapshot,
+	})
+}
+
+// handleAllianceChanged 记录 AllianceActor 通知的玩家所属联盟
+func (a *GameActor) handleAllianceChanged(ctx *actor.Context, msg *allianceChanged) {
+	player, ok := a.players[msg.playerID]
+	if !ok || player.AllianceId == msg.allianceID {
+		return
+	}
+	player.AllianceId = msg.allianceID
+	a.syncCombatData(ctx, player)
+	a.savePlayer(ctx, player)
+	a.pushPlayerUpdate(ctx, player)
+	a.releaseReinforcements(ctx, player.Id)
+}
+
+// handleCityPlaced 记录 MapActor 分配的主城坐标
+func (a *GameActor) handleCityPlaced(ctx *actor.Context, msg *cityPlaced) {
+	player, ok := a.players[msg.playerID]
+	if !ok {
+		return
+	}
+	player.Home = &pb.Coord{X: msg.x, Y: msg.y}
+	a.syncCombatData(ctx, player)
+	a.savePlayer(ctx, player)
+	a.pushPlayerUpdate(ctx, player)
+	log.Printf("[GameActor] Player %s city placed at (%d, %d)", player.Id, msg.x, msg.y)
+}
+
+// handleHeroList 返回玩家拥有的英雄
+func (a *GameActor) handleHeroList(ctx *actor.Context, msg *pb.GameMessage) {
+	player, exists := a.players[msg.Id]
+	if !exists {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodePlayerNotFound, "player not found"))
+		return
+	}
+	payload, err := json.Marshal(player.Heroes)
+	if err != nil {
+		log.Printf("[GameActor] Failed to marshal heroes: %v", err)
+		return
+	}
+	if a.gatewayPID != nil {
+		ctx.Engine().Send(a.gatewayPID, &pb.GameMessage{
+			Type:    "hero_list_response",
+			Id:      msg.Id,
+			Payload: payload,
+		})
+	}
+}
+
 // validateMessage 验证消息的有效性
 func (a *GameActor) validateMessage(msg *pb.GameMessage) error {
 	if msg == nil {
@@ -196,17 +417,19 @@ func (a *GameActor) validateMessage(msg *pb.GameMessage) error {
 	return nil
 }
 
-// sendError 发送错误消息给客户端
-func (a *GameActor) sendError(ctx *actor.Context, id string, msgType string, err error) {
+// sendError 发送错误消息给客户端，sessionID 为发起请求的连接ID，
+// 未绑定玩家的连接（如注册、登录请求）只能按连接ID收到回复
+func (a *GameActor) sendError(ctx *actor.Context, id, sessionID, msgType string, err error) {
 	if a.gatewayPID == nil {
 		log.Printf("[GameActor] 无法发送错误消息: gatewayPID为空")
 		return
 	}
 
 	errMsg := &pb.GameMessage{
-		Type:    "error",
-		Id:      id,
-		Payload: []byte(err.Error()),
+		Type:      "error",
+		Id:        id,
+		SessionId: sessionID,
+		Payload:   errorPayload(msgType, err),
 	}
 
 	ctx.Engine().Send(a.gatewayPID, errMsg)
@@ -0,0 +1,265 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/world"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+const (
+	gatherRatePerLevel = 1200             // 资源点每级每小时的采集量
+	gatherTickInterval = 30 * time.Second // 采集的资源计入玩家资源的间隔
+)
+
+// gatherRate 资源点每小时的采集量
+func gatherRate(node *pb.ResourceNode) int64 {
+	return gatherRatePerLevel * int64(node.Level)
+}
+
+// handleNodeSpawned 记录 MapActor 生成的资源点
+func (a *GameActor) handleNodeSpawned(msg *nodeSpawned) {
+	a.nodes[msg.node.Id] = msg.node
+}
+
+// handleGather 派出部队前往资源点采集
+func (a *GameActor) handleGather(ctx *actor.Context, msg *pb.GameMessage) {
+	player, ok := a.players[msg.Id]
+	if !ok {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodePlayerNotFound, "player not found"))
+		return
+	}
+	var req pb.GatherRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid gather request format"))
+		return
+	}
+	node, ok := a.nodes[req.NodeId]
+	if !ok {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeNodeNotFound, "resource node not found: %s", req.NodeId))
+		return
+	}
+	if node.OccupantId == player.Id {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeAlreadyGathering, "already gathering at %s", node.Id))
+		return
+	}
+	if player.Home == nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeServiceUnavailable, "city location not assigned yet"))
+		return
+	}
+	if a.marchCount(player.Id) >= maxMarches {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeMarchLimit, "too many marches: max %d", maxMarches))
+		return
+	}
+
+	// 未指定采集部队时派出全部驻守部队
+	if len(req.Army) == 0 {
+		req.Army = copyTroops(player.Troops)
+	}
+	if err := validateArmy(req.Army, player.Troops); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, err)
+		return
+	}
+	if err := a.validateMarchHero(player, req.HeroId); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, err)
+		return
+	}
+
+	a.startMarch(ctx, player, &pb.March{
+		NodeId: node.Id,
+		Army:   req.Army,
+		HeroId: req.HeroId,
+	}, &pb.Coord{X: node.X, Y: node.Y})
+}
+
+// arriveAtNode 采集行军到达资源点：空闲时开始采集，被其他玩家占领时发起争夺，
+// 资源点已消失或已被自己占领时返回主城
+func (a *GameActor) arriveAtNode(ctx *actor.Context, m *activeMarch) {
+	now := time.Now()
+	node, ok := a.nodes[m.march.NodeId]
+	switch {
+	case !ok, node.OccupantId == m.march.OwnerId:
+		a.returnMarch(ctx, m, now)
+	case node.OccupantId == "":
+		a.beginGathering(ctx, m, node, now)
+	default:
+		a.engageNode(ctx, m, node, now)
+	}
+}
+
+// engageNode 与正在采集的部队争夺资源点，由 CombatActor 结算
+func (a *GameActor) engageNode(ctx *actor.Context, m *activeMarch, node *pb.ResourceNode, now time.Time) {
+	attacker, ok := a.players[m.march.OwnerId]
+	if !ok {
+		return
+	}
+	occupant, ok := a.marches[node.MarchId]
+	defender, exists := a.players[node.OccupantId]
+	if !ok || !exists || a.combatPID == nil {
+		a.returnMarch(ctx, m, now)
+		return
+	}
+	// 任一方正在结算其他战斗时稍后重试
+	_, attackerBusy := a.battling[attacker.Id]
+	_, defenderBusy := a.battling[defender.Id]
+	if attackerBusy || defenderBusy {
+		a.wakeMarch(ctx, m, marchRetryDelay)
+		return
+	}
+
+	// 先结算占领者到目前为止的采集，资源点恰好采完时无需争夺
+	a.settleGathering(occupant, now)
+	if node.Amount <= 0 {
+		a.leaveNode(ctx, occupant, now)
+		a.returnMarch(ctx, m, now)
+		return
+	}
+
+	m.march.TargetId = defender.Id
+	a.requestBattle(ctx, m, attacker, defender, &pb.BattleRequest{
+		AttackerId:     attacker.Id,
+		DefenderId:     defender.Id,
+		Army:           m.march.Army,
+		HeroId:         m.march.HeroId,
+		DefenderArmy:   occupant.march.Army,
+		DefenderHeroId: occupant.march.HeroId,
+		NodeId:         node.Id,
+	}, now)
+}
+
+// contestNode 资源点争夺结束：占领者战败或全军覆没时离开资源点，
+// 资源点空出且进攻方仍有部队时由进攻方开始采集，返回 true
+func (a *GameActor) contestNode(ctx *actor.Context, m *activeMarch, result *pb.BattleResult) bool {
+	now := time.Now()
+	node, ok := a.nodes[result.NodeId]
+	if !ok {
+		return false
+	}
+	if occupant, ok := a.marches[node.MarchId]; ok && occupant.march.OwnerId == result.DefenderId {
+		occupant.march.Army = marchSurvivors(occupant.march.Army, survivingTroops(result.DefenderStacks))
+		occupant.march.BattleId = result.BattleId
+		if result.WinnerId == m.march.OwnerId || len(occupant.march.Army) == 0 {
+			a.leaveNode(ctx, occupant, now)
+		} else {
+			a.pushMarch(ctx, "march_update", occupant, occupant.march.OwnerId)
+		}
+	}
+
+	if _, ok := a.nodes[node.Id]; !ok || node.OccupantId != "" || len(m.march.Army) == 0 {
+		return false
+	}
+	a.beginGathering(ctx, m, node, now)
+	return true
+}
+
+// beginGathering 行军占领资源点并开始采集，部队停留在资源点上
+func (a *GameActor) beginGathering(ctx *actor.Context, m *activeMarch, node *pb.ResourceNode, now time.Time) {
+	m.march.Status = pb.MarchStatus_MARCH_STATUS_GATHERING
+	m.gatheredAt = now
+	node.OccupantId = m.march.OwnerId
+	node.MarchId = m.march.Id
+	a.updateNode(ctx, node)
+	a.syncNodeTile(ctx, node)
+
+	if a.mapPID != nil {
+		ctx.Engine().Send(a.mapPID, &mapEntityUpdate{entity: &pb.MapEntity{
+			Id:      m.march.Id,
+			Kind:    world.EntityArmy,
+			X:       node.X,
+			Y:       node.Y,
+			OwnerId: m.march.OwnerId,
+		}})
+	}
+	a.wakeMarch(ctx, m, gatherTickInterval)
+	a.pushMarch(ctx, "march_update", m, m.march.OwnerId)
+	log.Printf("[GameActor] March %s started gathering %s at %s", m.march.Id, node.Resource, node.Id)
+}
+
+// handleGatherTick 将采集的资源计入玩家资源，资源点采完后部队返回主城
+func (a *GameActor) handleGatherTick(ctx *actor.Context, m *activeMarch) {
+	now := time.Now()
+	a.settleGathering(m, now)
+	if owner, ok := a.players[m.march.OwnerId]; ok {
+		a.pushResourcesUpdate(ctx, owner)
+	}
+
+	node, ok := a.nodes[m.march.NodeId]
+	if !ok || node.Amount <= 0 {
+		a.leaveNode(ctx, m, now)
+		return
+	}
+	a.updateNode(ctx, node)
+	a.wakeMarch(ctx, m, gatherTickInterval)
+}
+
+// settleGathering 按采集时间将资源从资源点转入玩家资源，不足一个单位的采集时间留到下次结算
+func (a *GameActor) settleGathering(m *activeMarch, now time.Time) {
+	node, ok := a.nodes[m.march.NodeId]
+	if !ok || node.MarchId != m.march.Id {
+		return
+	}
+	owner, ok := a.players[m.march.OwnerId]
+	if !ok {
+		return
+	}
+
+	rate := gatherRate(node)
+	amount := rate * now.Sub(m.gatheredAt).Milliseconds() / int64(time.Hour/time.Millisecond)
+	if amount >= node.Amount {
+		amount = node.Amount
+		m.gatheredAt = now
+	} else {
+		m.gatheredAt = m.gatheredAt.Add(time.Duration(amount) * time.Hour / time.Duration(rate))
+	}
+	if amount <= 0 {
+		return
+	}
+
+	node.Amount -= amount
+	m.march.Gathered += amount
+	settleResources(owner, now)
+	addResources(owner, map[string]int64{node.Resource: amount})
+}
+
+// leaveNode 采集行军离开资源点返回主城，资源点采完时移除并在其他位置重新生成
+func (a *GameActor) leaveNode(ctx *actor.Context, m *activeMarch, now time.Time) {
+	if node, ok := a.nodes[m.march.NodeId]; ok && node.MarchId == m.march.Id {
+		a.settleGathering(m, now)
+		node.OccupantId = ""
+		node.MarchId = ""
+		a.syncNodeTile(ctx, node)
+		a.updateNode(ctx, node)
+		log.Printf("[GameActor] March %s left %s, gathered %d %s", m.march.Id, node.Id, m.march.Gathered, node.Resource)
+	}
+	if len(m.march.Army) == 0 {
+		a.finishMarch(ctx, m)
+		return
+	}
+	a.returnMarch(ctx, m, now)
+}
+
+// updateNode 更新地图上的资源点，已采完的资源点交给 MapActor 移除
+func (a *GameActor) updateNode(ctx *actor.Context, node *pb.ResourceNode) {
+	if node.Amount <= 0 {
+		delete(a.nodes, node.Id)
+		if a.mapPID != nil {
+			ctx.Engine().Send(a.mapPID, &nodeDepleted{id: node.Id})
+		}
+		return
+	}
+	if a.mapPID != nil {
+		ctx.Engine().Send(a.mapPID, &mapEntityUpdate{entity: nodeEntity(node)})
+	}
+}
+
+// syncNodeTile 将资源点的占领者和采集行军同步为所在格子的占领者和驻扎部队
+func (a *GameActor) syncNodeTile(ctx *actor.Context, node *pb.ResourceNode) {
+	if a.mapPID == nil {
+		return
+	}
+	ctx.Engine().Send(a.mapPID, &setTileOwner{x: node.X, y: node.Y, ownerID: node.OccupantId})
+	a.syncTileArmy(ctx, &pb.Coord{X: node.X, Y: node.Y})
+}
@@ -0,0 +1,344 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// 英雄稀有度
+const (
+	RarityCommon    = "common"
+	RarityRare      = "rare"
+	RarityEpic      = "epic"
+	RarityLegendary = "legendary"
+)
+
+// 装备部位
+const (
+	SlotWeapon    = "weapon"
+	SlotArmor     = "armor"
+	SlotMount     = "mount"
+	SlotAccessory = "accessory"
+)
+
+// 技能类型
+const (
+	skillPassive = "passive" // 被动技能，战斗开始时生效
+	skillActive  = "active"  // 主动技能，每回合按概率触发
+)
+
+// 技能效果
+const (
+	effectAttack     = "attack"      // 提高部队攻击力
+	effectDefense    = "defense"     // 提高部队防御力
+	effectDamage     = "damage"      // 按比例直接损伤敌方每支部队的生命值
+	effectDamageTake = "damage_take" // 降低本回合受到的伤害
+)
+
+const (
+	heroExpPerLevel = 50 // 英雄升级所需经验 = 当前等级 * heroExpPerLevel
+	heroMaxLevel    = 60 // 英雄等级上限
+)
+
+// skillDef 技能定义：troopType 为空时对全部兵种生效
+type skillDef struct {
+	kind      string
+	effect    string
+	troopType string
+	value     float64 // 效果数值，均为比例
+	chance    float64 // 主动技能每回合的触发概率
+}
+
+var skillTable = map[string]skillDef{
+	"inspire":       {kind: skillPassive, effect: effectAttack, value: 0.1},
+	"phalanx":       {kind: skillPassive, effect: effectDefense, troopType: TroopInfantry, value: 0.2},
+	"swift_cavalry": {kind: skillPassive, effect: effectAttack, troopType: TroopCavalry, value: 0.2},
+	"marksman":      {kind: skillPassive, effect: effectAttack, troopType: TroopArcher, value: 0.2},
+	"siege_master":  {kind: skillPassive, effect: effectAttack, troopType: TroopSiege, value: 0.3},
+	"war_cry":       {kind: skillActive, effect: effectAttack, value: 0.3, chance: 0.3},
+	"shield_wall":   {kind: skillActive, effect: effectDamageTake, value: 0.3, chance: 0.3},
+	"fire_attack":   {kind: skillActive, effect: effectDamage, value: 0.08, chance: 0.25},
+}
+
+// heroTemplate 英雄模板：基础属性和技能
+type heroTemplate struct {
+	name    string
+	rarity  string
+	attack  int32
+	defense int32
+	skills  []string
+}
+
+var heroTemplates = map[string]heroTemplate{
+	"militia_captain": {name: "民兵队长", rarity: RarityCommon, attack: 5, defense: 5, skills: []string{"inspire"}},
+	"iron_general":    {name: "铁壁将军", rarity: RarityRare, attack: 6, defense: 10, skills: []string{"phalanx", "shield_wall"}},
+	"horse_lord":      {name: "草原骑主", rarity: RarityEpic, attack: 12, defense: 6, skills: []string{"swift_cavalry", "war_cry"}},
+	"fire_strategist": {name: "火攻军师", rarity: RarityLegendary, attack: 15, defense: 8, skills: []string{"marksman", "fire_attack"}},
+}
+
+// rarityGrowth 不同稀有度每级的属性成长
+var rarityGrowth = map[string]int32{
+	RarityCommon:    1,
+	RarityRare:      2,
+	RarityEpic:      3,
+	RarityLegendary: 4,
+}
+
+// recruitCost 按稀有度招募英雄所需的资源
+var recruitCost = map[string]map[string]int64{
+	RarityCommon:    {ResourceGold: 100},
+	RarityRare:      {ResourceGold: 500},
+	RarityEpic:      {ResourceGold: 2000, ResourceIron: 1000},
+	RarityLegendary: {ResourceGold: 5000, ResourceIron: 3000},
+}
+
+// equipmentTemplate 可锻造的装备：部位、属性加成和锻造消耗
+type equipmentTemplate struct {
+	name    string
+	slot    string
+	attack  int32
+	defense int32
+	cost    map[string]int64
+}
+
+var equipmentTemplates = map[string]equipmentTemplate{
+	"iron_sword":     {name: "铁剑", slot: SlotWeapon, attack: 5, cost: map[string]int64{ResourceIron: 300, ResourceWood: 200}},
+	"chain_mail":     {name: "锁子甲", slot: SlotArmor, defense: 5, cost: map[string]int64{ResourceIron: 400}},
+	"war_horse":      {name: "战马", slot: SlotMount, attack: 3, defense: 2, cost: map[string]int64{ResourceFood: 800, ResourceGold: 100}},
+	"jade_pendant":   {name: "玉佩", slot: SlotAccessory, defense: 3, cost: map[string]int64{ResourceStone: 300, ResourceGold: 150}},
+	"steel_halberd":  {name: "精钢戟", slot: SlotWeapon, attack: 10, cost: map[string]int64{ResourceIron: 1200, ResourceWood: 600, ResourceGold: 200}},
+	"lamellar_armor": {name: "札甲", slot: SlotArmor, defense: 10, cost: map[string]int64{ResourceIron: 1500, ResourceGold: 200}},
+}
+
+// starterHeroTemplate 新玩家获得的初始英雄
+const starterHeroTemplate = "militia_captain"
+
+// NewHero 按模板创建1级英雄，模板不存在时返回 nil
+func NewHero(id, templateID string) *pb.Hero {
+	tmpl, ok := heroTemplates[templateID]
+	if !ok {
+		return nil
+	}
+	return &pb.Hero{
+		Id:         id,
+		TemplateId: templateID,
+		Name:       tmpl.name,
+		Rarity:     tmpl.rarity,
+		Level:      1,
+		Equipment:  make(map[string]*pb.Equipment),
+		Skills:     append([]string(nil), tmpl.skills...),
+	}
+}
+
+// newStarterHero 创建新玩家的初始英雄，附带一件初始武器
+func newStarterHero() *pb.Hero {
+	hero := NewHero("hero_1", starterHeroTemplate)
+	hero.Equipment[SlotWeapon] = &pb.Equipment{
+		Id:     "equip_wooden_sword",
+		Name:   "木剑",
+		Slot:   SlotWeapon,
+		Attack: 2,
+	}
+	return hero
+}
+
+// heroStats 计算英雄的攻击和防御：模板基础值 + 等级成长 + 装备加成
+func heroStats(hero *pb.Hero) (attack, defense int32) {
+	tmpl := heroTemplates[hero.TemplateId]
+	growth := rarityGrowth[hero.Rarity] * (hero.Level - 1)
+	attack = tmpl.attack + growth
+	defense = tmpl.defense + growth
+	for _, equip := range hero.Equipment {
+		attack += equip.Attack
+		defense += equip.Defense
+	}
+	return attack, defense
+}
+
+// findHero 按ID查找玩家拥有的英雄
+func findHero(player *pb.PlayerData, heroID string) *pb.Hero {
+	for _, hero := range player.Heroes {
+		if hero.Id == heroID {
+			return hero
+		}
+	}
+	return nil
+}
+
+// defaultCommander 选择防守统帅：等级最高的英雄，等级相同时按ID排序，
+// exclude 中的英雄（如正在出征）不参与选择
+func defaultCommander(player *pb.PlayerData, exclude map[string]bool) *pb.Hero {
+	heroes := make([]*pb.Hero, 0, len(player.Heroes))
+	for _, hero := range player.Heroes {
+		if !exclude[hero.Id] {
+			heroes = append(heroes, hero)
+		}
+	}
+	if len(heroes) == 0 {
+		return nil
+	}
+	sort.Slice(heroes, func(i, j int) bool {
+		if heroes[i].Level != heroes[j].Level {
+			return heroes[i].Level > heroes[j].Level
+		}
+		return heroes[i].Id < heroes[j].Id
+	})
+	return heroes[0]
+}
+
+// gainHeroExperience 增加英雄经验并处理升级
+func gainHeroExperience(hero *pb.Hero, exp int32) {
+	hero.Experience += int64(exp)
+	for hero.Level < heroMaxLevel && hero.Experience >= int64(hero.Level)*heroExpPerLevel {
+		hero.Experience -= int64(hero.Level) * heroExpPerLevel
+		hero.Level++
+	}
+}
+
+// handleHeroMessage 处理英雄招募、装备锻造和穿脱装备的请求
+func (a *GameActor) handleHeroMessage(ctx *actor.Context, msg *pb.GameMessage) {
+	player, ok := a.players[msg.Id]
+	if !ok {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodePlayerNotFound, "player not found"))
+		return
+	}
+	var req pb.HeroRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid hero request format"))
+		return
+	}
+
+	var err error
+	switch msg.Type {
+	case "hero_recruit":
+		err = recruitHero(player, req.TemplateId)
+	case "equipment_forge":
+		err = forgeEquipment(player, req.TemplateId)
+	case "hero_equip":
+		err = a.equipHero(player, req.HeroId, req.EquipmentId)
+	case "hero_unequip":
+		err = a.unequipHero(player, req.HeroId, req.Slot)
+	}
+	if err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, err)
+		return
+	}
+	a.syncCombatData(ctx, player)
+	a.savePlayer(ctx, player)
+	a.pushPlayerUpdate(ctx, player)
+	if msg.Type == "hero_recruit" || msg.Type == "equipment_forge" {
+		a.pushResourcesUpdate(ctx, player)
+	}
+}
+
+// recruitHero 消耗资源招募模板对应的英雄，每个模板只能拥有一个
+func recruitHero(player *pb.PlayerData, templateID string) error {
+	tmpl, ok := heroTemplates[templateID]
+	if !ok {
+		return newGameError(ErrCodeInvalidRequest, "unknown hero template: %s", templateID)
+	}
+	for _, hero := range player.Heroes {
+		if hero.TemplateId == templateID {
+			return newGameError(ErrCodeHeroOwned, "hero already recruited: %s", templateID)
+		}
+	}
+	settleResources(player, time.Now())
+	if err := spendResources(player, recruitCost[tmpl.rarity]); err != nil {
+		return err
+	}
+	// 英雄不会被移除，按数量编号不会与已有英雄重复
+	hero := NewHero(fmt.Sprintf("hero_%d", len(player.Heroes)+1), templateID)
+	player.Heroes = append(player.Heroes, hero)
+	log.Printf("[GameActor] Player %s recruited hero %s (%s)", player.Id, hero.Id, templateID)
+	return nil
+}
+
+// forgeEquipment 消耗资源锻造装备，放入背包
+func forgeEquipment(player *pb.PlayerData, templateID string) error {
+	tmpl, ok := equipmentTemplates[templateID]
+	if !ok {
+		return newGameError(ErrCodeInvalidRequest, "unknown equipment template: %s", templateID)
+	}
+	settleResources(player, time.Now())
+	if err := spendResources(player, tmpl.cost); err != nil {
+		return err
+	}
+	// 装备不会被销毁，按已有数量编号不会重复
+	owned := len(player.Inventory)
+	for _, hero := range player.Heroes {
+		owned += len(hero.Equipment)
+	}
+	equip := &pb.Equipment{
+		Id:      fmt.Sprintf("equip_%d", owned+1),
+		Name:    tmpl.name,
+		Slot:    tmpl.slot,
+		Attack:  tmpl.attack,
+		Defense: tmpl.defense,
+	}
+	player.Inventory = append(player.Inventory, equip)
+	log.Printf("[GameActor] Player %s forged %s (%s)", player.Id, equip.Id, templateID)
+	return nil
+}
+
+// equipHero 将背包中的装备穿戴到英雄身上，该部位原有的装备放回背包
+func (a *GameActor) equipHero(player *pb.PlayerData, heroID, equipmentID string) error {
+	hero, err := a.idleHero(player, heroID)
+	if err != nil {
+		return err
+	}
+	index := -1
+	for i, equip := range player.Inventory {
+		if equip.Id == equipmentID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return newGameError(ErrCodeEquipmentNotFound, "equipment not found: %s", equipmentID)
+	}
+	equip := player.Inventory[index]
+	player.Inventory = append(player.Inventory[:index], player.Inventory[index+1:]...)
+	if hero.Equipment == nil {
+		hero.Equipment = make(map[string]*pb.Equipment)
+	}
+	if previous, ok := hero.Equipment[equip.Slot]; ok {
+		player.Inventory = append(player.Inventory, previous)
+	}
+	hero.Equipment[equip.Slot] = equip
+	log.Printf("[GameActor] Player %s equipped %s on hero %s", player.Id, equip.Id, hero.Id)
+	return nil
+}
+
+// unequipHero 卸下英雄指定部位的装备，放回背包
+func (a *GameActor) unequipHero(player *pb.PlayerData, heroID, slot string) error {
+	hero, err := a.idleHero(player, heroID)
+	if err != nil {
+		return err
+	}
+	equip, ok := hero.Equipment[slot]
+	if !ok {
+		return newGameError(ErrCodeEquipmentNotFound, "hero %s has no equipment in slot %s", heroID, slot)
+	}
+	delete(hero.Equipment, slot)
+	player.Inventory = append(player.Inventory, equip)
+	log.Printf("[GameActor] Player %s unequipped %s from hero %s", player.Id, equip.Id, hero.Id)
+	return nil
+}
+
+// idleHero 查找没有出征的英雄，出征中的英雄不能更换装备
+func (a *GameActor) idleHero(player *pb.PlayerData, heroID string) (*pb.Hero, error) {
+	hero := findHero(player, heroID)
+	if hero == nil {
+		return nil, newGameError(ErrCodeHeroNotFound, "hero not found: %s", heroID)
+	}
+	if a.marchingHeroes(player.Id)[heroID] {
+		return nil, newGameError(ErrCodeHeroBusy, "hero is marching: %s", heroID)
+	}
+	return hero, nil
+}
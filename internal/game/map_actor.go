@@ -0,0 +1,473 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/world"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultViewRadius = 7                   // 默认视野半径
+	maxViewRadius     = 15                  // 视野半径上限，单次最多返回 31x31 个格子
+	maxSubscribeSpan  = 2*maxViewRadius + 1 // 订阅区域的最大边长
+
+	cityPlacementAttempts = 1000        // 随机选择主城、资源点位置的最大尝试次数
+	cityEntityPrefix      = "city_"     // 主城实体ID前缀
+	movingTickInterval    = time.Second // 行军中实体的位置更新间隔
+
+	tilesPerNode     = 400         // 每 tilesPerNode 个格子生成一个资源点
+	nodeEntityPrefix = "node_"     // 资源点ID前缀
+	nodeBaseAmount   = 5000        // 资源点每级的资源储量
+	nodeRespawnDelay = time.Minute // 资源点采集完后重新生成的等待时间
+)
+
+// nodeResources 资源点可能产出的资源类型
+var nodeResources = []string{ResourceFood, ResourceWood, ResourceStone, ResourceIron}
+
+// MapActor 持有世界地图和地图实体，是地图数据的唯一修改者。
+// 玩家可以订阅一个矩形区域，区域内的实体和格子变化会以 map_delta 增量推送
+type MapActor struct {
+	engine     *actor.Engine
+	worldMap   *world.Map
+	gamePID    *actor.PID // 游戏Actor的引用
+	gatewayPID *actor.PID // 网关Actor的引用，视野和增量直接发给玩家
+
+	entities      *world.SpatialIndex  // 地图上的城市、部队
+	subscriptions *world.Subscriptions // 玩家订阅的区域，key为玩家ID
+	moving        map[string]*pb.Coord // 正在移动的实体及其行程起点，按行程定时更新位置
+	ticking       bool                 // 是否已设置位置更新计时
+	nodeSeq       int64                // 资源点序号，用于生成资源点ID
+}
+
+// mapEntityUpdate 添加或移动地图实体，由其他 Actor 发送
+type mapEntityUpdate struct {
+	entity *pb.MapEntity
+}
+
+// mapEntityRemove 从地图上移除实体
+type mapEntityRemove struct {
+	id string
+}
+
+// placeCity 为玩家分配主城位置，MapActor 完成后回复 cityPlaced；
+// at 为优先使用的坐标，为空或已被占用时随机分配
+type placeCity struct {
+	playerID string
+	name     string
+	level    int32
+	at       *pb.Coord
+}
+
+// cityPlaced MapActor 分配的主城坐标
+type cityPlaced struct {
+	playerID string
+	x, y     int32
+}
+
+// movingTick 定时更新移动中实体位置的消息
+type movingTick struct{}
+
+// nodeSpawned MapActor 生成的资源点，GameActor 据此管理资源点的采集
+type nodeSpawned struct {
+	node *pb.ResourceNode
+}
+
+// nodeDepleted 资源点已采集完，MapActor 移除该资源点并在其他位置重新生成
+type nodeDepleted struct {
+	id string
+}
+
+// respawnNode 资源点重新生成计时结束
+type respawnNode struct{}
+
+// setTileOwner 修改格子的占领者，ownerID 为空表示取消占领
+type setTileOwner struct {
+	x, y    int32
+	ownerID string
+}
+
+// setTileArmy 修改格子上驻扎的部队，armyID 为空表示部队离开
+type setTileArmy struct {
+	x, y   int32
+	armyID string
+}
+
+// NewMapActor creates a new Map Actor
+func NewMapActor(width, height int32, seed int64) actor.Producer {
+	return func() actor.Receiver {
+		return &MapActor{
+			worldMap:      world.NewMap(width, height, seed),
+			entities:      world.NewSpatialIndex(),
+			subscriptions: world.NewSubscriptions(),
+			moving:        make(map[string]*pb.Coord),
+		}
+	}
+}
+
+// Receive handles incoming messages
+func (a *MapActor) Receive(ctx *actor.Context) {
+	switch msg := ctx.Message().(type) {
+	case actor.Started:
+		log.Printf("[MapActor] Started, size=%dx%d", a.worldMap.Width(), a.worldMap.Height())
+		a.engine = ctx.Engine()
+
+	case actor.Stopped:
+		log.Println("[MapActor] Stopped")
+
+	case *actor.PID:
+		if msg.ID != "" && strings.Contains(msg.ID, "game/") {
+			first := a.gamePID == nil
+			a.gamePID = msg
+			log.Printf("[MapActor] Received GameActor PID: %v", msg)
+			// 资源点由 GameActor 管理采集，在得知 GameActor 后生成
+			if first {
+				a.spawnNodes(ctx)
+			}
+		}
+		if msg.ID != "" && strings.Contains(msg.ID, "gateway/") {
+			a.gatewayPID = msg
+			log.Printf("[MapActor] Received GatewayActor PID: %v", msg)
+		}
+
+	case *placeCity:
+		a.handlePlaceCity(ctx, msg)
+
+	case *mapEntityUpdate:
+		a.handleEntityUpdate(ctx, msg.entity)
+		a.trackMoving(ctx, msg.entity)
+
+	case *mapEntityRemove:
+		delete(a.moving, msg.id)
+		a.handleEntityRemove(ctx, msg.id)
+
+	case *movingTick:
+		a.handleMovingTick(ctx)
+
+	case *nodeDepleted:
+		a.handleNodeDepleted(ctx, msg)
+
+	case *respawnNode:
+		a.spawnNode(ctx)
+
+	case *setTileOwner:
+		if tile := a.worldMap.Tile(msg.x, msg.y); tile != nil && tile.OwnerID != msg.ownerID {
+			a.worldMap.SetOwner(msg.x, msg.y, msg.ownerID)
+			a.pushTile(ctx, msg.x, msg.y)
+		}
+
+	case *setTileArmy:
+		if tile := a.worldMap.Tile(msg.x, msg.y); tile != nil && tile.ArmyID != msg.armyID {
+			a.worldMap.SetArmy(msg.x, msg.y, msg.armyID)
+			a.pushTile(ctx, msg.x, msg.y)
+		}
+
+	case *PlayerOfflineMessage:
+		a.subscriptions.Unsubscribe(msg.PlayerID)
+
+	case *playerRemoved:
+		a.handlePlayerRemoved(ctx, msg.playerID)
+
+	case *pb.GameMessage:
+		a.handleMapMessage(ctx, msg)
+	}
+}
+
+// handleMapMessage processes map-related messages
+func (a *MapActor) handleMapMessage(ctx *actor.Context, msg *pb.GameMessage) {
+	switch msg.Type {
+	case "map_view":
+		a.handleMapView(ctx, msg)
+	case "map_subscribe":
+		a.handleMapSubscribe(ctx, msg)
+	case "map_unsubscribe":
+		a.subscriptions.Unsubscribe(msg.Id)
+		sendToPlayer(ctx, a.gatewayPID, msg.Id, "map_unsubscribe_response", struct{}{})
+	default:
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, fmt.Errorf("未知的消息类型: %s", msg.Type))
+	}
+}
+
+// handleMapView 返回指定坐标周围的格子
+func (a *MapActor) handleMapView(ctx *actor.Context, msg *pb.GameMessage) {
+	var req pb.MapViewRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid map view request format"))
+		return
+	}
+	if !a.worldMap.InBounds(req.X, req.Y) {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeInvalidRequest,
+			"coordinates out of map: (%d, %d)", req.X, req.Y))
+		return
+	}
+	radius := req.Radius
+	if radius <= 0 {
+		radius = defaultViewRadius
+	}
+	if radius > maxViewRadius {
+		radius = maxViewRadius
+	}
+
+	sendToPlayer(ctx, a.gatewayPID, msg.Id, "map_view_response", &pb.MapViewResponse{
+		X:      req.X,
+		Y:      req.Y,
+		Radius: radius,
+		Width:  a.worldMap.Width(),
+		Height: a.worldMap.Height(),
+		Tiles:  a.worldMap.View(req.X, req.Y, radius),
+	})
+}
+
+// handleMapSubscribe 订阅矩形区域，替换玩家之前的订阅，并推送区域内的全量快照
+func (a *MapActor) handleMapSubscribe(ctx *actor.Context, msg *pb.GameMessage) {
+	var req pb.MapSubscribeRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid map subscribe request format"))
+		return
+	}
+	rect := world.Rect{MinX: req.MinX, MinY: req.MinY, MaxX: req.MaxX, MaxY: req.MaxY}
+	if rect.Width() <= 0 || rect.Height() <= 0 {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid area: (%d, %d)-(%d, %d)",
+			req.MinX, req.MinY, req.MaxX, req.MaxY))
+		return
+	}
+	if rect.Width() > maxSubscribeSpan || rect.Height() > maxSubscribeSpan {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeInvalidRequest, "area too large: %dx%d, max %dx%d",
+			rect.Width(), rect.Height(), maxSubscribeSpan, maxSubscribeSpan))
+		return
+	}
+	if !a.worldMap.InBounds(rect.MinX, rect.MinY) || !a.worldMap.InBounds(rect.MaxX, rect.MaxY) {
+		reportFailure(ctx, a.gamePID, msg.Id, msg.Type, newGameError(ErrCodeInvalidRequest, "area out of map: (%d, %d)-(%d, %d)",
+			req.MinX, req.MinY, req.MaxX, req.MaxY))
+		return
+	}
+
+	a.subscriptions.Subscribe(msg.Id, rect)
+
+	snapshot := &pb.MapDelta{Snapshot: true, Updated: a.entities.Query(rect)}
+	for y := rect.MinY; y <= rect.MaxY; y++ {
+		for x := rect.MinX; x <= rect.MaxX; x++ {
+			snapshot.Tiles = append(snapshot.Tiles, a.worldMap.TileProto(x, y))
+		}
+	}
+	sendToPlayer(ctx, a.gatewayPID, msg.Id, "map_delta", snapshot)
+}
+
+// handlePlaceCity 在空闲的格子上放置玩家主城并占领该格子，优先使用指定的坐标
+func (a *MapActor) handlePlaceCity(ctx *actor.Context, msg *placeCity) {
+	for i := 0; i < cityPlacementAttempts; i++ {
+		x := rand.Int31n(a.worldMap.Width())
+		y := rand.Int31n(a.worldMap.Height())
+		if i == 0 && msg.at != nil && a.worldMap.InBounds(msg.at.X, msg.at.Y) {
+			x, y = msg.at.X, msg.at.Y
+		}
+		if !a.freeTile(x, y) {
+			continue
+		}
+
+		a.worldMap.SetOwner(x, y, msg.playerID)
+		a.pushTile(ctx, x, y)
+		a.handleEntityUpdate(ctx, &pb.MapEntity{
+			Id:      cityEntityPrefix + msg.playerID,
+			Kind:    world.EntityCity,
+			X:       x,
+			Y:       y,
+			OwnerId: msg.playerID,
+			Name:    msg.name,
+			Level:   msg.level,
+		})
+		if a.gamePID != nil {
+			ctx.Engine().Send(a.gamePID, &cityPlaced{playerID: msg.playerID, x: x, y: y})
+		}
+		return
+	}
+	log.Printf("[MapActor] Failed to place city for player %s: no free tile", msg.playerID)
+}
+
+// handlePlayerRemoved 移除玩家的主城并取消对主城格子的占领，格子可以重新分配
+func (a *MapActor) handlePlayerRemoved(ctx *actor.Context, playerID string) {
+	a.subscriptions.Unsubscribe(playerID)
+	city := a.entities.Get(cityEntityPrefix + playerID)
+	if city == nil {
+		return
+	}
+	if tile := a.worldMap.Tile(city.X, city.Y); tile != nil && tile.OwnerID == playerID {
+		a.worldMap.SetOwner(city.X, city.Y, "")
+		a.pushTile(ctx, city.X, city.Y)
+	}
+	a.handleEntityRemove(ctx, city.Id)
+}
+
+// freeTile 格子可通行、未被占领且没有城市、资源点等实体
+func (a *MapActor) freeTile(x, y int32) bool {
+	tile := a.worldMap.Tile(x, y)
+	if !tile.Passable() || tile.OwnerID != "" {
+		return false
+	}
+	return len(a.entities.Query(world.Rect{MinX: x, MinY: y, MaxX: x, MaxY: y})) == 0
+}
+
+// spawnNodes 按地图大小生成初始的资源点
+func (a *MapActor) spawnNodes(ctx *actor.Context) {
+	count := int(a.worldMap.Width()) * int(a.worldMap.Height()) / tilesPerNode
+	for i := 0; i < count; i++ {
+		a.spawnNode(ctx)
+	}
+	log.Printf("[MapActor] Spawned %d resource nodes", count)
+}
+
+// spawnNode 在空闲的格子上随机生成资源点，等级由格子的资源等级决定，并通知 GameActor
+func (a *MapActor) spawnNode(ctx *actor.Context) {
+	for i := 0; i < cityPlacementAttempts; i++ {
+		x := rand.Int31n(a.worldMap.Width())
+		y := rand.Int31n(a.worldMap.Height())
+		if !a.freeTile(x, y) {
+			continue
+		}
+
+		a.nodeSeq++
+		level := (a.worldMap.Tile(x, y).ResourceLevel + 1) / 2
+		node := &pb.ResourceNode{
+			Id:       fmt.Sprintf("%s%d", nodeEntityPrefix, a.nodeSeq),
+			Resource: nodeResources[rand.Intn(len(nodeResources))],
+			Level:    level,
+			X:        x,
+			Y:        y,
+			Amount:   nodeBaseAmount * int64(level),
+		}
+		a.handleEntityUpdate(ctx, nodeEntity(node))
+		if a.gamePID != nil {
+			ctx.Engine().Send(a.gamePID, &nodeSpawned{node: node})
+		}
+		return
+	}
+	log.Printf("[MapActor] Failed to spawn resource node: no free tile")
+}
+
+// handleNodeDepleted 移除采集完的资源点，等待一段时间后在其他位置生成新的资源点
+func (a *MapActor) handleNodeDepleted(ctx *actor.Context, msg *nodeDepleted) {
+	a.handleEntityRemove(ctx, msg.id)
+	pid := ctx.PID()
+	engine := ctx.Engine()
+	time.AfterFunc(nodeRespawnDelay, func() {
+		engine.Send(pid, &respawnNode{})
+	})
+}
+
+// nodeEntity 资源点在地图上的实体
+func nodeEntity(node *pb.ResourceNode) *pb.MapEntity {
+	return &pb.MapEntity{
+		Id:       node.Id,
+		Kind:     world.EntityNode,
+		X:        node.X,
+		Y:        node.Y,
+		OwnerId:  node.OccupantId,
+		Level:    node.Level,
+		Resource: node.Resource,
+		Amount:   node.Amount,
+	}
+}
+
+// trackMoving 记录有行程的实体，定时按行程进度更新其位置
+func (a *MapActor) trackMoving(ctx *actor.Context, entity *pb.MapEntity) {
+	if entity.Target == nil || entity.ArriveAt <= time.Now().UnixMilli() {
+		delete(a.moving, entity.Id)
+		return
+	}
+	a.moving[entity.Id] = &pb.Coord{X: entity.X, Y: entity.Y}
+	a.scheduleMovingTick(ctx)
+}
+
+// scheduleMovingTick 设置下一次位置更新
+func (a *MapActor) scheduleMovingTick(ctx *actor.Context) {
+	if a.ticking || len(a.moving) == 0 {
+		return
+	}
+	a.ticking = true
+	pid := ctx.PID()
+	engine := ctx.Engine()
+	time.AfterFunc(movingTickInterval, func() {
+		engine.Send(pid, &movingTick{})
+	})
+}
+
+// handleMovingTick 按行程进度更新移动中实体的位置，位置变化时通知订阅者
+func (a *MapActor) handleMovingTick(ctx *actor.Context) {
+	a.ticking = false
+	now := time.Now().UnixMilli()
+	for id, origin := range a.moving {
+		entity := a.entities.Get(id)
+		if entity == nil || entity.Target == nil {
+			delete(a.moving, id)
+			continue
+		}
+		progress := 1.0
+		if total := entity.ArriveAt - entity.DepartAt; total > 0 {
+			progress = float64(now-entity.DepartAt) / float64(total)
+		}
+		x, y := world.Interpolate(origin.X, origin.Y, entity.Target.X, entity.Target.Y, progress)
+		if x != entity.X || y != entity.Y {
+			moved := proto.Clone(entity).(*pb.MapEntity)
+			moved.X, moved.Y = x, y
+			a.handleEntityUpdate(ctx, moved)
+		}
+		if progress >= 1 {
+			delete(a.moving, id)
+		}
+	}
+	a.scheduleMovingTick(ctx)
+}
+
+// handleEntityUpdate 更新实体位置，并通知新旧位置的订阅者：
+// 能看到新位置的订阅者收到更新，只能看到旧位置的订阅者收到移除
+func (a *MapActor) handleEntityUpdate(ctx *actor.Context, entity *pb.MapEntity) {
+	if !a.worldMap.InBounds(entity.X, entity.Y) {
+		log.Printf("[MapActor] Entity out of map: id=%s, pos=(%d, %d)", entity.Id, entity.X, entity.Y)
+		return
+	}
+	old := a.entities.Upsert(entity)
+
+	watchers := make(map[string]bool)
+	for _, id := range a.subscriptions.Watchers(entity.X, entity.Y) {
+		watchers[id] = true
+		sendToPlayer(ctx, a.gatewayPID, id, "map_delta", &pb.MapDelta{Updated: []*pb.MapEntity{entity}})
+	}
+	if old == nil {
+		return
+	}
+	for _, id := range a.subscriptions.Watchers(old.X, old.Y) {
+		if !watchers[id] {
+			sendToPlayer(ctx, a.gatewayPID, id, "map_delta", &pb.MapDelta{Removed: []string{entity.Id}})
+		}
+	}
+}
+
+// handleEntityRemove 移除实体，并通知能看到该实体的订阅者
+func (a *MapActor) handleEntityRemove(ctx *actor.Context, id string) {
+	entity := a.entities.Remove(id)
+	if entity == nil {
+		return
+	}
+	for _, subscriberID := range a.subscriptions.Watchers(entity.X, entity.Y) {
+		sendToPlayer(ctx, a.gatewayPID, subscriberID, "map_delta", &pb.MapDelta{Removed: []string{id}})
+	}
+}
+
+// pushTile 将格子的变化推送给关注该格子的订阅者
+func (a *MapActor) pushTile(ctx *actor.Context, x, y int32) {
+	watchers := a.subscriptions.Watchers(x, y)
+	if len(watchers) == 0 {
+		return
+	}
+	delta := &pb.MapDelta{Tiles: []*pb.Tile{a.worldMap.TileProto(x, y)}}
+	for _, subscriberID := range watchers {
+		sendToPlayer(ctx, a.gatewayPID, subscriberID, "map_delta", delta)
+	}
+}
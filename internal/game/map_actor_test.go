@@ -0,0 +1,223 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// TestMapActorPlayerRemoved 移除玩家后主城实体和格子占领被释放，其他玩家的主城保留
+func TestMapActorPlayerRemoved(t *testing.T) {
+	a := NewMapActor(32, 32, 1)().(*MapActor)
+	runInActor(t, func(ctx *actor.Context) {
+		a.handlePlaceCity(ctx, &placeCity{playerID: "guest", name: "Guest", level: 1})
+		a.handlePlaceCity(ctx, &placeCity{playerID: "other", name: "Other", level: 1})
+	})
+	city := a.entities.Get(cityEntityPrefix + "guest")
+	if city == nil || a.entities.Get(cityEntityPrefix+"other") == nil {
+		t.Fatal("cities not placed")
+	}
+
+	runInActor(t, func(ctx *actor.Context) {
+		a.handlePlayerRemoved(ctx, "guest")
+	})
+	if a.entities.Get(city.Id) != nil {
+		t.Errorf("city of the removed player still on the map")
+	}
+	if !a.freeTile(city.X, city.Y) {
+		t.Errorf("tile (%d, %d) not released: owner %q", city.X, city.Y, a.worldMap.Tile(city.X, city.Y).OwnerID)
+	}
+	if a.entities.Get(cityEntityPrefix+"other") == nil {
+		t.Errorf("city of another player removed")
+	}
+}
+
+// testMap 运行中的 MapActor，发给网关和 GameActor 的消息分别转入 toGateway 和 toGame
+type testMap struct {
+	engine    *actor.Engine
+	pid       *actor.PID
+	toGateway chan *pb.GameMessage
+	toGame    chan any
+}
+
+func newTestMap(t *testing.T) *testMap {
+	t.Helper()
+	engine, err := actor.NewEngine(actor.NewEngineConfig())
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	m := &testMap{engine: engine, toGateway: make(chan *pb.GameMessage, 256), toGame: make(chan any, 256)}
+	gateway := engine.SpawnFunc(func(ctx *actor.Context) {
+		if msg, ok := ctx.Message().(*pb.GameMessage); ok {
+			m.toGateway <- msg
+		}
+	}, "gateway")
+	game := engine.SpawnFunc(func(ctx *actor.Context) {
+		m.toGame <- ctx.Message()
+	}, "game")
+	m.pid = engine.Spawn(NewMapActor(16, 16, 1), "map")
+	engine.Send(m.pid, gateway)
+	engine.Send(m.pid, game)
+	return m
+}
+
+// placeCity 由 MapActor 分配玩家主城，返回主城坐标
+func (m *testMap) placeCity(t *testing.T, playerID string) *pb.Coord {
+	t.Helper()
+	m.engine.Send(m.pid, &placeCity{playerID: playerID, name: playerID, level: 1})
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case msg := <-m.toGame:
+			if placed, ok := msg.(*cityPlaced); ok && placed.playerID == playerID {
+				return &pb.Coord{X: placed.x, Y: placed.y}
+			}
+		case <-timeout:
+			t.Fatalf("city of %s not placed", playerID)
+			return nil
+		}
+	}
+}
+
+// gatewayMessage 等待 MapActor 发给网关的 msgType 消息，其他消息被跳过
+func (m *testMap) gatewayMessage(t *testing.T, msgType string, payload any) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case msg := <-m.toGateway:
+			if msg.Type != msgType {
+				continue
+			}
+			if err := json.Unmarshal(msg.Payload, payload); err != nil {
+				t.Fatalf("Unmarshal %s: %v", msgType, err)
+			}
+			return
+		case <-timeout:
+			t.Fatalf("no %s sent to the gateway", msgType)
+		}
+	}
+}
+
+// viewTile 以 map_view 查询格子
+func (m *testMap) viewTile(t *testing.T, at *pb.Coord) *pb.Tile {
+	t.Helper()
+	payload, _ := json.Marshal(&pb.MapViewRequest{X: at.X, Y: at.Y, Radius: 1})
+	m.engine.Send(m.pid, &pb.GameMessage{Type: "map_view", Id: "viewer", Payload: payload})
+	var resp pb.MapViewResponse
+	m.gatewayMessage(t, "map_view_response", &resp)
+	for _, tile := range resp.Tiles {
+		if tile.X == at.X && tile.Y == at.Y {
+			return tile
+		}
+	}
+	t.Fatalf("tile (%d, %d) missing from map view", at.X, at.Y)
+	return nil
+}
+
+// TestMapViewTileOwnerAndArmy map_view 返回主城和采集中资源点的占领者，以及停留在格子上的行军
+func TestMapViewTileOwnerAndArmy(t *testing.T) {
+	m := newTestMap(t)
+	a := newTestGameActor()
+	a.mapPID = m.pid
+	host := addTestPlayer(a, "host", 0)
+	ally := addTestPlayer(a, "ally", 0)
+	host.AllianceId, ally.AllianceId = "alliance_1", "alliance_1"
+	host.Home = m.placeCity(t, "host")
+	ally.Home = m.placeCity(t, "ally")
+	nodeAt := &pb.Coord{X: (host.Home.X + 1) % 16, Y: host.Home.Y}
+	if nodeAt.X == ally.Home.X && nodeAt.Y == ally.Home.Y {
+		nodeAt.Y = (nodeAt.Y + 1) % 16
+	}
+
+	node := &pb.ResourceNode{Id: "node_1", Resource: ResourceFood, Level: 1, X: nodeAt.X, Y: nodeAt.Y, Amount: 1000}
+	a.nodes[node.Id] = node
+	gathering := &activeMarch{march: &pb.March{Id: "march_1", OwnerId: "host", NodeId: node.Id,
+		Army: map[string]int32{TroopInfantry: 10}, Status: pb.MarchStatus_MARCH_STATUS_OUTBOUND, From: host.Home, To: nodeAt}}
+	stationed := &activeMarch{march: &pb.March{Id: "march_2", OwnerId: "ally", TargetId: "host", Reinforce: true,
+		Army: map[string]int32{TroopInfantry: 10}, Status: pb.MarchStatus_MARCH_STATUS_OUTBOUND, From: ally.Home, To: host.Home}}
+	a.marches[gathering.march.Id] = gathering
+	a.marches[stationed.march.Id] = stationed
+	t.Cleanup(func() {
+		for _, march := range a.marches {
+			if march.timer != nil {
+				march.timer.Stop()
+			}
+		}
+	})
+
+	check := func(name string, at *pb.Coord, owner, army string) {
+		t.Helper()
+		tile := m.viewTile(t, at)
+		if tile.OwnerId != owner || tile.ArmyId != army {
+			t.Errorf("%s: tile (%d, %d) owner %q army %q, want owner %q army %q",
+				name, at.X, at.Y, tile.OwnerId, tile.ArmyId, owner, army)
+		}
+	}
+	check("city placed", host.Home, "host", "")
+	check("free node", nodeAt, "", "")
+
+	runInEngine(t, m.engine, func(ctx *actor.Context) {
+		a.beginGathering(ctx, gathering, node, time.Now())
+		a.arriveAtHost(ctx, stationed)
+	})
+	check("reinforcement stationed", host.Home, "host", "march_2")
+	check("node occupied", nodeAt, "host", "march_1")
+
+	runInEngine(t, m.engine, func(ctx *actor.Context) {
+		a.leaveNode(ctx, gathering, time.Now())
+		a.returnMarch(ctx, stationed, time.Now())
+	})
+	check("reinforcement returning", host.Home, "host", "")
+	check("node left", nodeAt, "", "")
+
+	m.engine.Send(m.pid, &playerRemoved{playerID: "host"})
+	check("city released", host.Home, "", "")
+}
+
+// TestMapSubscriberTileDelta 订阅者收到区域内格子占领者和驻扎部队变化的 map_delta
+func TestMapSubscriberTileDelta(t *testing.T) {
+	m := newTestMap(t)
+	a := newTestGameActor()
+	a.mapPID = m.pid
+	addTestPlayer(a, "p1", 0)
+	node := &pb.ResourceNode{Id: "node_1", Resource: ResourceFood, Level: 1, X: 3, Y: 4, Amount: 1000}
+	a.nodes[node.Id] = node
+	gathering := &activeMarch{march: &pb.March{Id: "march_1", OwnerId: "p1", NodeId: node.Id,
+		Army: map[string]int32{TroopInfantry: 10}, Status: pb.MarchStatus_MARCH_STATUS_OUTBOUND, To: &pb.Coord{X: 3, Y: 4}}}
+	a.marches[gathering.march.Id] = gathering
+	t.Cleanup(func() { gathering.timer.Stop() })
+
+	payload, _ := json.Marshal(&pb.MapSubscribeRequest{MinX: 0, MinY: 0, MaxX: 7, MaxY: 7})
+	m.engine.Send(m.pid, &pb.GameMessage{Type: "map_subscribe", Id: "viewer", Payload: payload})
+	var snapshot pb.MapDelta
+	m.gatewayMessage(t, "map_delta", &snapshot)
+	if !snapshot.Snapshot {
+		t.Fatalf("first map_delta is not a snapshot")
+	}
+
+	runInEngine(t, m.engine, func(ctx *actor.Context) {
+		a.beginGathering(ctx, gathering, node, time.Now())
+	})
+	var owner, army string
+	timeout := time.After(time.Second)
+	for owner != "p1" || army != "march_1" {
+		select {
+		case msg := <-m.toGateway:
+			var delta pb.MapDelta
+			if msg.Type != "map_delta" || msg.Id != "viewer" || json.Unmarshal(msg.Payload, &delta) != nil {
+				continue
+			}
+			for _, tile := range delta.Tiles {
+				if tile.X == 3 && tile.Y == 4 {
+					owner, army = tile.OwnerId, tile.ArmyId
+				}
+			}
+		case <-timeout:
+			t.Fatalf("tile delta owner %q army %q, want p1 and march_1", owner, army)
+		}
+	}
+}
@@ -0,0 +1,460 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/world"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+const (
+	maxMarches        = 3               // 每个玩家同时进行的行军数量上限
+	minMarchDuration  = 5 * time.Second // 单程行军的最短时间
+	marchRetryDelay   = time.Second     // 到达时目标正在战斗中，等待后重试
+	marchEntityPrefix = "march_"        // 行军ID前缀，同时作为地图上部队实体的ID
+)
+
+// activeMarch 进行中的行军
+type activeMarch struct {
+	march    *pb.March
+	departAt time.Time
+	arriveAt time.Time
+	wakeAt   time.Time // 当前计时的触发时间，到达后重试时晚于 arriveAt
+	timer    *time.Timer
+
+	gatheredAt time.Time // 采集已结算到的时间
+}
+
+// marchArrive 行军计时结束时 GameActor 发给自身的消息；
+// at 用于忽略召回或重新计时之前的旧消息
+type marchArrive struct {
+	marchID string
+	at      time.Time
+}
+
+// startMarch 派出部队前往 to，部队在返回前不再驻守主城。
+// march 中已设置目标（TargetId 为目标玩家，采集时 NodeId 为资源点）、部队和英雄
+func (a *GameActor) startMarch(ctx *actor.Context, owner *pb.PlayerData, march *pb.March, to *pb.Coord) {
+	now := time.Now()
+	a.marchSeq++
+	march.Id = fmt.Sprintf("%s%d", marchEntityPrefix, a.marchSeq)
+	march.OwnerId = owner.Id
+	march.Army = copyTroops(march.Army)
+	m := &activeMarch{march: march}
+	a.marches[march.Id] = m
+	removeTroops(owner.Troops, march.Army)
+
+	a.scheduleMarch(ctx, m, owner.Home, to, now, marchDuration(owner.Home, to, march.Army))
+	a.syncCombatData(ctx, owner)
+	a.savePlayer(ctx, owner)
+	a.pushPlayerUpdate(ctx, owner)
+	a.pushMarch(ctx, "march_start", m, owner.Id, march.TargetId)
+	log.Printf("[GameActor] March %s started: %s -> (%d, %d), army=%s, arrive in %v",
+		march.Id, owner.Id, to.X, to.Y, formatTroops(march.Army), m.arriveAt.Sub(now).Round(time.Second))
+}
+
+// marchDuration 按距离和部队中最慢兵种的速度计算单程时间
+func marchDuration(from, to *pb.Coord, army map[string]int32) time.Duration {
+	speed := armySpeed(army)
+	if speed <= 0 {
+		return minMarchDuration
+	}
+	distance := world.Distance(from.X, from.Y, to.X, to.Y)
+	duration := time.Duration(distance / speed * float64(time.Minute))
+	if duration < minMarchDuration {
+		return minMarchDuration
+	}
+	return duration
+}
+
+// scheduleMarch 开始一段新的行程，更新地图上的部队并设置到达计时
+func (a *GameActor) scheduleMarch(ctx *actor.Context, m *activeMarch, from, to *pb.Coord, now time.Time, duration time.Duration) {
+	m.departAt = now
+	m.arriveAt = now.Add(duration)
+	m.march.From = from
+	m.march.To = to
+	m.march.DepartAt = m.departAt.UnixMilli()
+	m.march.ArriveAt = m.arriveAt.UnixMilli()
+	a.wakeMarch(ctx, m, duration)
+
+	if a.mapPID != nil {
+		ctx.Engine().Send(a.mapPID, &mapEntityUpdate{entity: &pb.MapEntity{
+			Id:       m.march.Id,
+			Kind:     world.EntityArmy,
+			X:        from.X,
+			Y:        from.Y,
+			OwnerId:  m.march.OwnerId,
+			Target:   &pb.Coord{X: to.X, Y: to.Y},
+			DepartAt: m.march.DepartAt,
+			ArriveAt: m.march.ArriveAt,
+		}})
+	}
+}
+
+// handleMarchArrive 行军到达目标时发起战斗、开始采集或驻守盟友主城，返回主城时部队重新驻守；
+// 采集中的行军按采集间隔计入采集的资源
+func (a *GameActor) handleMarchArrive(ctx *actor.Context, msg *marchArrive) {
+	m, ok := a.marches[msg.marchID]
+	if !ok || !m.wakeAt.Equal(msg.at) {
+		return
+	}
+	switch m.march.Status {
+	case pb.MarchStatus_MARCH_STATUS_OUTBOUND:
+		switch {
+		case m.march.NodeId != "":
+			a.arriveAtNode(ctx, m)
+		case m.march.Reinforce:
+			a.arriveAtHost(ctx, m)
+		default:
+			a.engageMarch(ctx, m)
+		}
+	case pb.MarchStatus_MARCH_STATUS_GATHERING:
+		a.handleGatherTick(ctx, m)
+	case pb.MarchStatus_MARCH_STATUS_RETURNING:
+		a.finishMarch(ctx, m)
+	}
+}
+
+// engageMarch 行军到达目标主城，校验目标状态后交给 CombatActor 结算
+func (a *GameActor) engageMarch(ctx *actor.Context, m *activeMarch) {
+	now := time.Now()
+	attacker, ok := a.players[m.march.OwnerId]
+	if !ok {
+		return
+	}
+	defender, ok := a.players[m.march.TargetId]
+	if !ok || a.combatPID == nil {
+		a.returnMarch(ctx, m, now)
+		return
+	}
+	if defender.ProtectedUntil > now.Unix() {
+		log.Printf("[GameActor] March %s target %s is protected, returning", m.march.Id, defender.Id)
+		a.returnMarch(ctx, m, now)
+		return
+	}
+	// 任一方正在结算其他战斗时稍后重试
+	_, attackerBusy := a.battling[attacker.Id]
+	_, defenderBusy := a.battling[defender.Id]
+	if attackerBusy || defenderBusy {
+		a.wakeMarch(ctx, m, marchRetryDelay)
+		return
+	}
+
+	battleReq := &pb.BattleRequest{
+		AttackerId:     attacker.Id,
+		DefenderId:     defender.Id,
+		Army:           m.march.Army,
+		HeroId:         m.march.HeroId,
+		Reinforcements: a.stationedReinforcements(defender),
+	}
+	if hero := defaultCommander(defender, a.marchingHeroes(defender.Id)); hero != nil {
+		battleReq.DefenderHeroId = hero.Id
+	}
+	a.requestBattle(ctx, m, attacker, defender, battleReq, now)
+}
+
+// requestBattle 标记双方进入战斗，并将行军到达后的战斗交给 CombatActor 结算
+func (a *GameActor) requestBattle(ctx *actor.Context, m *activeMarch, attacker, defender *pb.PlayerData, battleReq *pb.BattleRequest, now time.Time) {
+	payload, err := json.Marshal(battleReq)
+	if err != nil {
+		log.Printf("[GameActor] Failed to marshal battle request: %v", err)
+		a.returnMarch(ctx, m, now)
+		return
+	}
+
+	m.march.Status = pb.MarchStatus_MARCH_STATUS_FIGHTING
+	a.fighting[attacker.Id] = m.march.Id
+	a.battling[attacker.Id] = defender.Id
+	a.battling[defender.Id] = attacker.Id
+	// 战斗按双方的快照结算，先结算随时间恢复的生命值和城防耐久
+	settleDurability(defender.City, now)
+	for _, player := range []*pb.PlayerData{attacker, defender} {
+		a.regenerateHp(player, now)
+		a.syncCombatData(ctx, player)
+	}
+	a.pushMarch(ctx, "march_arrive", m, attacker.Id, defender.Id)
+	ctx.Engine().Send(a.combatPID, &pb.GameMessage{
+		Type:    "battle_request",
+		Id:      attacker.Id,
+		Payload: payload,
+	})
+}
+
+// wakeMarch 设置行军计时，到期后 GameActor 处理行军的到达
+func (a *GameActor) wakeMarch(ctx *actor.Context, m *activeMarch, delay time.Duration) {
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	m.wakeAt = time.Now().Add(delay)
+	pid := ctx.PID()
+	engine := ctx.Engine()
+	msg := &marchArrive{marchID: m.march.Id, at: m.wakeAt}
+	m.timer = time.AfterFunc(delay, func() {
+		engine.Send(pid, msg)
+	})
+}
+
+// endMarchBattle 战斗结束后幸存部队返回主城；result 为空表示战斗未能结算，部队原样返回
+func (a *GameActor) endMarchBattle(ctx *actor.Context, attackerID string, result *pb.BattleResult) {
+	marchID, ok := a.fighting[attackerID]
+	if !ok {
+		return
+	}
+	delete(a.fighting, attackerID)
+	m, ok := a.marches[marchID]
+	if !ok {
+		return
+	}
+
+	if result != nil {
+		m.march.BattleId = result.BattleId
+		m.march.Army = marchSurvivors(m.march.Army, survivingTroops(result.AttackerStacks))
+		// 夺得资源点的部队留下采集
+		if result.NodeId != "" && a.contestNode(ctx, m, result) {
+			return
+		}
+	}
+	if len(m.march.Army) == 0 {
+		// 部队全军覆没，统帅英雄直接回到主城
+		a.finishMarch(ctx, m)
+		return
+	}
+	a.returnMarch(ctx, m, time.Now())
+}
+
+// survivingTroops 战斗后幸存的部队
+func survivingTroops(stacks []*pb.BattleStack) map[string]int32 {
+	survivors := make(map[string]int32)
+	for _, stack := range stacks {
+		if stack.Remaining > 0 && validTroopType(stack.TroopType) {
+			survivors[stack.TroopType] = stack.Remaining
+		}
+	}
+	return survivors
+}
+
+// marchSurvivors 行军的幸存部队，每个兵种不超过出发时的数量，
+// 战报中行军没有的兵种不计入
+func marchSurvivors(army, survivors map[string]int32) map[string]int32 {
+	result := make(map[string]int32)
+	for troopType, count := range army {
+		if remaining := survivors[troopType]; remaining > 0 {
+			if remaining > count {
+				remaining = count
+			}
+			result[troopType] = remaining
+		}
+	}
+	return result
+}
+
+// returnMarch 行军掉头返回主城：从当前位置出发，用时与已行进的时间相同；
+// 已到达目标的行军按完整单程计算
+func (a *GameActor) returnMarch(ctx *actor.Context, m *activeMarch, now time.Time) {
+	owner, ok := a.players[m.march.OwnerId]
+	if !ok {
+		a.removeMarch(ctx, m)
+		return
+	}
+	from := m.march.To
+	duration := m.arriveAt.Sub(m.departAt)
+	if now.Before(m.arriveAt) {
+		from = a.marchPosition(m, now)
+		duration = now.Sub(m.departAt)
+	}
+	if duration < minMarchDuration {
+		duration = minMarchDuration
+	}
+
+	held, at := holdsTile(m), m.march.To
+	m.march.Status = pb.MarchStatus_MARCH_STATUS_RETURNING
+	a.scheduleMarch(ctx, m, from, owner.Home, now, duration)
+	if held {
+		a.syncTileArmy(ctx, at)
+	}
+	// 保存战后幸存的部队和掠夺的资源
+	a.savePlayer(ctx, owner)
+	a.pushMarch(ctx, "march_update", m, m.march.OwnerId, m.march.TargetId)
+}
+
+// marchPosition 按行程进度计算行军的当前位置
+func (a *GameActor) marchPosition(m *activeMarch, now time.Time) *pb.Coord {
+	total := m.arriveAt.Sub(m.departAt)
+	progress := 1.0
+	if total > 0 {
+		progress = float64(now.Sub(m.departAt)) / float64(total)
+	}
+	x, y := world.Interpolate(m.march.From.X, m.march.From.Y, m.march.To.X, m.march.To.Y, progress)
+	return &pb.Coord{X: x, Y: y}
+}
+
+// finishMarch 行军结束，幸存部队重新驻守主城，带回的掠夺资源计入玩家资源
+func (a *GameActor) finishMarch(ctx *actor.Context, m *activeMarch) {
+	a.removeMarch(ctx, m)
+	owner, ok := a.players[m.march.OwnerId]
+	if !ok {
+		return
+	}
+	addTroops(owner.Troops, m.march.Army)
+	if len(m.march.Loot) > 0 {
+		settleResources(owner, time.Now())
+		addResources(owner, m.march.Loot)
+		a.pushResourcesUpdate(ctx, owner)
+	}
+	a.syncCombatData(ctx, owner)
+	a.savePlayer(ctx, owner)
+	a.pushPlayerUpdate(ctx, owner)
+	a.pushMarch(ctx, "march_return", m, owner.Id)
+	log.Printf("[GameActor] March %s returned: army=%s", m.march.Id, formatTroops(m.march.Army))
+}
+
+// removeMarch 移除行军记录和地图上的部队
+func (a *GameActor) removeMarch(ctx *actor.Context, m *activeMarch) {
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	delete(a.marches, m.march.Id)
+	if a.mapPID != nil {
+		ctx.Engine().Send(a.mapPID, &mapEntityRemove{id: m.march.Id})
+	}
+	if holdsTile(m) {
+		a.syncTileArmy(ctx, m.march.To)
+	}
+}
+
+// holdsTile 行军正在目标格子上采集或驻守
+func holdsTile(m *activeMarch) bool {
+	return m.march.Status == pb.MarchStatus_MARCH_STATUS_GATHERING || m.march.Status == pb.MarchStatus_MARCH_STATUS_STATIONED
+}
+
+// syncTileArmy 将停留在格子上的行军同步给 MapActor，有多支行军时取ID最小的一支，没有行军时清除
+func (a *GameActor) syncTileArmy(ctx *actor.Context, at *pb.Coord) {
+	if a.mapPID == nil || at == nil {
+		return
+	}
+	armyID := ""
+	for id, m := range a.marches {
+		if holdsTile(m) && m.march.To.X == at.X && m.march.To.Y == at.Y && (armyID == "" || id < armyID) {
+			armyID = id
+		}
+	}
+	ctx.Engine().Send(a.mapPID, &setTileArmy{x: at.X, y: at.Y, armyID: armyID})
+}
+
+// cancelMarches 取消玩家的全部行军，部队不再返回主城，占据的资源点被释放
+func (a *GameActor) cancelMarches(ctx *actor.Context, playerID string) {
+	for _, m := range a.marches {
+		if m.march.OwnerId != playerID {
+			continue
+		}
+		a.removeMarch(ctx, m)
+		if node, ok := a.nodes[m.march.NodeId]; ok && node.MarchId == m.march.Id {
+			node.OccupantId = ""
+			node.MarchId = ""
+			a.syncNodeTile(ctx, node)
+			a.updateNode(ctx, node)
+		}
+	}
+}
+
+// handleMarchRecall 召回前往目标途中、正在采集或驻守盟友主城的行军；
+// 驻守的援军也可以由接受增援的玩家遣返
+func (a *GameActor) handleMarchRecall(ctx *actor.Context, msg *pb.GameMessage) {
+	var req pb.MarchRecallRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid march recall request format"))
+		return
+	}
+	m, ok := a.marches[req.MarchId]
+	stationed := ok && m.march.Status == pb.MarchStatus_MARCH_STATUS_STATIONED
+	if !ok || (m.march.OwnerId != msg.Id && !(stationed && m.march.TargetId == msg.Id)) {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeMarchNotFound, "march not found: %s", req.MarchId))
+		return
+	}
+	switch m.march.Status {
+	case pb.MarchStatus_MARCH_STATUS_OUTBOUND:
+		a.returnMarch(ctx, m, time.Now())
+	case pb.MarchStatus_MARCH_STATUS_GATHERING:
+		a.leaveNode(ctx, m, time.Now())
+	case pb.MarchStatus_MARCH_STATUS_STATIONED:
+		// 守城战结算前援军不能离开
+		if _, busy := a.battling[m.march.TargetId]; busy {
+			a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeInBattle, "%s is in battle", m.march.TargetId))
+			return
+		}
+		a.returnMarch(ctx, m, time.Now())
+	default:
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeInvalidRequest, "march cannot be recalled: %s", req.MarchId))
+		return
+	}
+	log.Printf("[GameActor] March %s recalled by %s", m.march.Id, msg.Id)
+}
+
+// handleMarchList 返回玩家发出的以及针对该玩家的行军
+func (a *GameActor) handleMarchList(ctx *actor.Context, msg *pb.GameMessage) {
+	list := &pb.MarchList{}
+	for _, m := range a.marches {
+		if m.march.OwnerId == msg.Id {
+			list.Marches = append(list.Marches, m.march)
+		} else if m.march.TargetId == msg.Id {
+			list.Marches = append(list.Marches, targetMarchView(m.march))
+		}
+	}
+	sendToPlayer(ctx, a.gatewayPID, msg.Id, "march_list_response", list)
+}
+
+// marchCount 玩家进行中的行军数量
+func (a *GameActor) marchCount(playerID string) int {
+	count := 0
+	for _, m := range a.marches {
+		if m.march.OwnerId == playerID {
+			count++
+		}
+	}
+	return count
+}
+
+// marchingHeroes 玩家正在出征的英雄
+func (a *GameActor) marchingHeroes(playerID string) map[string]bool {
+	heroes := make(map[string]bool)
+	for _, m := range a.marches {
+		if m.march.OwnerId == playerID && m.march.HeroId != "" {
+			heroes[m.march.HeroId] = true
+		}
+	}
+	return heroes
+}
+
+// pushMarch 将行军状态推送给相关玩家，忽略空的玩家ID（如采集行军没有目标玩家）；
+// 只有出征玩家能看到完整的行军，其他玩家收到 targetMarchView
+func (a *GameActor) pushMarch(ctx *actor.Context, msgType string, m *activeMarch, playerIDs ...string) {
+	for _, id := range playerIDs {
+		switch id {
+		case "":
+		case m.march.OwnerId:
+			sendToPlayer(ctx, a.gatewayPID, id, msgType, m.march)
+		default:
+			sendToPlayer(ctx, a.gatewayPID, id, msgType, targetMarchView(m.march))
+		}
+	}
+}
+
+// targetMarchView 目标玩家看到的行军，只包含出征玩家、路线和时间，
+// 不包含部队、统帅英雄和掠夺的资源
+func targetMarchView(march *pb.March) *pb.March {
+	return &pb.March{
+		Id:        march.Id,
+		OwnerId:   march.OwnerId,
+		TargetId:  march.TargetId,
+		From:      march.From,
+		To:        march.To,
+		DepartAt:  march.DepartAt,
+		ArriveAt:  march.ArriveAt,
+		Status:    march.Status,
+		Reinforce: march.Reinforce,
+	}
+}
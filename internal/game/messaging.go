@@ -0,0 +1,36 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/anthdm/hollywood/actor"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// sendToPlayer 经 GatewayActor 将消息发送给指定玩家，payload 以 JSON 编码
+func sendToPlayer(ctx *actor.Context, gatewayPID *actor.PID, playerID string, msgType string, payload interface{}) {
+	if gatewayPID == nil {
+		log.Printf("[%s] 无法发送消息 %s: gatewayPID为空", ctx.PID().ID, msgType)
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("[%s] Failed to marshal %s: %v", ctx.PID().ID, msgType, err)
+		return
+	}
+	ctx.Engine().Send(gatewayPID, &pb.GameMessage{
+		Type:    msgType,
+		Id:      playerID,
+		Payload: data,
+	})
+}
+
+// reportFailure 将玩家请求的失败通知 GameActor，由其以 error 消息回复玩家
+func reportFailure(ctx *actor.Context, gamePID *actor.PID, playerID string, requestType string, err error) {
+	if gamePID == nil {
+		log.Printf("[%s] 无法发送错误消息: gamePID为空", ctx.PID().ID)
+		return
+	}
+	ctx.Engine().Send(gamePID, &requestFailed{playerID: playerID, requestType: requestType, err: err})
+}
@@ -0,0 +1,293 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	baseExpWin        = 20              // 胜利基础经验
+	baseExpLose       = 5               // 失败基础经验
+	expPerEnemyLevel  = 5               // 对手每级额外提供的经验
+	expPerLevel       = 100             // 升级所需经验 = 当前等级 * expPerLevel
+	hpGrowth          = 20              // 每级生命值上限成长
+	attackGrowth      = 2               // 每级攻击力成长
+	defenseGrowth     = 1               // 每级防御力成长
+	recoveryDuration  = 5 * time.Minute // 战败后的恢复时间
+	hpRegenInterval   = time.Minute     // 生命值自然恢复间隔
+	hpRegenPercentage = 10              // 每个恢复间隔恢复的生命值上限百分比
+)
+
+// recoverPlayer 战败恢复计时结束时 GameActor 发给自身的消息
+type recoverPlayer struct {
+	playerID string
+}
+
+// playerIDRandomBytes 玩家ID的随机字节数
+const playerIDRandomBytes = 6
+
+// newPlayerID 生成随机的玩家ID。战报索引、联盟成员等按玩家ID持久化，
+// ID 不能随服务重启从头编号，否则新玩家会继承旧玩家的数据
+func newPlayerID() (string, error) {
+	id := make([]byte, playerIDRandomBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "player_" + hex.EncodeToString(id), nil
+}
+
+// newPlayerData 创建1级玩家的初始数据
+func newPlayerData(id, name string) *pb.PlayerData {
+	now := time.Now()
+	return &pb.PlayerData{
+		Id:          id,
+		Name:        name,
+		Level:       1,
+		Hp:          100,
+		MaxHp:       100,
+		Attack:      10,
+		Defense:     5,
+		HpUpdatedAt: now.Unix(),
+		Troops:      copyTroops(initialTroops),
+		Heroes:      []*pb.Hero{newStarterHero()},
+		City:        newCity(),
+
+		Resources:          copyResources(initialResources),
+		ResourcesUpdatedAt: now.UnixMilli(),
+	}
+}
+
+// applyBattleResult 根据战斗结果更新双方的生命值、部队、经验和状态
+func (a *GameActor) applyBattleResult(ctx *actor.Context, result *pb.BattleResult) {
+	now := time.Now()
+	for _, side := range []struct {
+		id     string
+		hp     int32
+		heroID string
+	}{
+		{result.AttackerId, result.AttackerHp, result.AttackerHeroId},
+		{result.DefenderId, result.DefenderHp, result.DefenderHeroId},
+	} {
+		player, ok := a.players[side.id]
+		if !ok {
+			continue
+		}
+
+		// 资源点争夺只有双方的采集部队参战，战损由行军部队承担，不影响生命值和驻守部队
+		if result.NodeId == "" {
+			player.Hp = side.hp
+			player.HpUpdatedAt = now.Unix()
+			// 进攻方的战损由行军部队承担，这里只扣除防守方的驻守部队
+			applyCasualties(player, result.DefenderStacks)
+		}
+
+		// 先判定战败再结算经验，战败玩家升级时不会回满生命值而跳过恢复和战败保护
+		if result.NodeId == "" && player.Hp <= 0 {
+			a.startRecovery(ctx, player, now)
+		}
+
+		exp := baseExpLose + expPerEnemyLevel*a.levelOf(result.WinnerId)
+		if side.id == result.WinnerId {
+			exp = baseExpWin + expPerEnemyLevel*a.levelOf(result.LoserId)
+		}
+		a.gainExperience(player, exp)
+		// 统帅英雄获得与玩家相同的经验
+		if hero := findHero(player, side.heroID); hero != nil {
+			gainHeroExperience(hero, exp)
+		}
+
+		a.syncCombatData(ctx, player)
+		a.savePlayer(ctx, player)
+		a.pushPlayerUpdate(ctx, player)
+	}
+}
+
+// applyCasualties 从玩家的部队中扣除其所属部队的战损
+func applyCasualties(player *pb.PlayerData, stacks []*pb.BattleStack) {
+	for _, stack := range stacks {
+		if stack.OwnerId != player.Id || stack.Lost <= 0 {
+			continue
+		}
+		remaining := player.Troops[stack.TroopType] - stack.Lost
+		if remaining > 0 {
+			player.Troops[stack.TroopType] = remaining
+		} else {
+			delete(player.Troops, stack.TroopType)
+		}
+	}
+}
+
+// levelOf 返回玩家等级，玩家不存在时按1级计算
+func (a *GameActor) levelOf(id string) int32 {
+	if player, ok := a.players[id]; ok {
+		return player.Level
+	}
+	return 1
+}
+
+// gainExperience 增加经验并处理升级，每次升级提升属性并回满生命值
+func (a *GameActor) gainExperience(player *pb.PlayerData, exp int32) {
+	player.Experience += int64(exp)
+	for player.Experience >= int64(player.Level)*expPerLevel {
+		player.Experience -= int64(player.Level) * expPerLevel
+		player.Level++
+		player.MaxHp += hpGrowth
+		player.Attack += attackGrowth
+		player.Defense += defenseGrowth
+		if player.Status == pb.PlayerStatus_PLAYER_STATUS_ACTIVE {
+			player.Hp = player.MaxHp
+		}
+		log.Printf("[GameActor] Player %s leveled up to %d", player.Id, player.Level)
+	}
+}
+
+// startRecovery 使战败玩家进入恢复状态，恢复时间结束后回满生命值；
+// 同时开启战败保护，避免被连续攻击
+func (a *GameActor) startRecovery(ctx *actor.Context, player *pb.PlayerData, now time.Time) {
+	player.Hp = 0
+	player.Status = pb.PlayerStatus_PLAYER_STATUS_RECOVERING
+	player.RecoverAt = now.Add(recoveryDuration).Unix()
+	player.ProtectedUntil = now.Add(defeatProtection).Unix()
+
+	a.scheduleRecovery(ctx, player.Id, recoveryDuration)
+	log.Printf("[GameActor] Player %s defeated, recovering until %d", player.Id, player.RecoverAt)
+}
+
+// scheduleRecovery 设置恢复状态的结束计时
+func (a *GameActor) scheduleRecovery(ctx *actor.Context, playerID string, delay time.Duration) {
+	pid := ctx.PID()
+	engine := ctx.Engine()
+	time.AfterFunc(delay, func() {
+		engine.Send(pid, &recoverPlayer{playerID: playerID})
+	})
+}
+
+// handleRecoverPlayer 结束玩家的恢复状态
+func (a *GameActor) handleRecoverPlayer(ctx *actor.Context, msg *recoverPlayer) {
+	player, ok := a.players[msg.playerID]
+	if !ok || player.Status != pb.PlayerStatus_PLAYER_STATUS_RECOVERING {
+		return
+	}
+	// 恢复期间可能被重新计时，以 RecoverAt 为准
+	if time.Now().Unix() < player.RecoverAt {
+		return
+	}
+
+	player.Status = pb.PlayerStatus_PLAYER_STATUS_ACTIVE
+	player.RecoverAt = 0
+	player.Hp = player.MaxHp
+	player.HpUpdatedAt = time.Now().Unix()
+
+	a.syncCombatData(ctx, player)
+	a.savePlayer(ctx, player)
+	a.pushPlayerUpdate(ctx, player)
+	log.Printf("[GameActor] Player %s recovered", player.Id)
+}
+
+// regenerateHp 按上次结算时间计算生命值的自然恢复，恢复中的玩家不参与
+func (a *GameActor) regenerateHp(player *pb.PlayerData, now time.Time) {
+	if player.Status != pb.PlayerStatus_PLAYER_STATUS_ACTIVE || player.Hp >= player.MaxHp {
+		player.HpUpdatedAt = now.Unix()
+		return
+	}
+
+	intervals := (now.Unix() - player.HpUpdatedAt) / int64(hpRegenInterval/time.Second)
+	if intervals <= 0 {
+		return
+	}
+	regen := intervals * int64(player.MaxHp) * hpRegenPercentage / 100
+	if int64(player.Hp)+regen >= int64(player.MaxHp) {
+		player.Hp = player.MaxHp
+		player.HpUpdatedAt = now.Unix()
+		return
+	}
+	player.Hp += int32(regen)
+	player.HpUpdatedAt += intervals * int64(hpRegenInterval/time.Second)
+}
+
+// pushPlayerUpdate 将最新的玩家数据推送给该玩家，资源按当前时间计算产出
+func (a *GameActor) pushPlayerUpdate(ctx *actor.Context, player *pb.PlayerData) {
+	if a.gatewayPID == nil {
+		return
+	}
+	data, err := json.Marshal(playerSnapshot(player, time.Now()))
+	if err != nil {
+		log.Printf("[GameActor] Failed to marshal player data: %v", err)
+		return
+	}
+	ctx.Engine().Send(a.gatewayPID, &pb.GameMessage{
+		Type:    "player_update",
+		Id:      player.Id,
+		Payload: data,
+	})
+}
+
+// playerSnapshot 推送给客户端的玩家数据快照，资源和城防耐久按当前时间结算
+func playerSnapshot(player *pb.PlayerData, now time.Time) *pb.PlayerData {
+	snapshot := proto.Clone(player).(*pb.PlayerData)
+	snapshot.Resources = currentResources(player, now)
+	snapshot.ResourcesUpdatedAt = now.UnixMilli()
+	settleDurability(snapshot.City, now)
+	return snapshot
+}
+
+// restorePlayer 从存储加载的玩家数据重新进入游戏：补齐空字段，未返回的行军直接回城，
+// 重新设置建造、训练和恢复计时，并在地图上重新放置主城
+func (a *GameActor) restorePlayer(ctx *actor.Context, player *pb.PlayerData) {
+	if player.Troops == nil {
+		player.Troops = make(map[string]int32)
+	}
+	if player.Resources == nil {
+		player.Resources = make(map[string]int64)
+	}
+	if player.City == nil {
+		player.City = newCity()
+	}
+	if player.City.Buildings == nil {
+		player.City.Buildings = make(map[string]int32)
+	}
+
+	// 行军计时和资源点占领不会恢复，部队和携带的资源直接返回主城
+	for _, march := range player.Marches {
+		addTroops(player.Troops, march.Army)
+		if len(march.Loot) > 0 {
+			settleResources(player, time.Now())
+			addResources(player, march.Loot)
+		}
+		log.Printf("[GameActor] March %s of %s returned on restore: army=%s", march.Id, player.Id, formatTroops(march.Army))
+	}
+	player.Marches = nil
+
+	// 任务ID的序号在服务重启后从头开始，重新分配ID以免与新任务重复
+	for _, task := range player.City.Queue {
+		a.buildSeq++
+		task.Id = fmt.Sprintf("%s%d", buildTaskPrefix, a.buildSeq)
+		a.scheduleBuild(ctx, player.Id, task)
+	}
+	for _, task := range player.City.Training {
+		a.trainSeq++
+		task.Id = fmt.Sprintf("%s%d", trainTaskPrefix, a.trainSeq)
+		a.scheduleTraining(ctx, player.Id, task)
+	}
+	if player.Status == pb.PlayerStatus_PLAYER_STATUS_RECOVERING {
+		a.scheduleRecovery(ctx, player.Id, time.Until(time.Unix(player.RecoverAt, 0)))
+	}
+
+	// 优先放回原来的坐标，原坐标已被占用时重新分配
+	if a.mapPID != nil {
+		ctx.Engine().Send(a.mapPID, &placeCity{
+			playerID: player.Id,
+			name:     player.Name,
+			level:    player.City.Buildings[BuildingTownHall],
+			at:       player.Home,
+		})
+	}
+}
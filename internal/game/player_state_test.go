@@ -0,0 +1,158 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// newTestGameActor 创建没有连接其他 Actor 的 GameActor
+func newTestGameActor() *GameActor {
+	return NewGameActor()().(*GameActor)
+}
+
+// addTestPlayer 加入一名在线且已分配主城的玩家
+func addTestPlayer(a *GameActor, id string, x int32) *pb.PlayerData {
+	player := newPlayerData(id, id)
+	player.Home = &pb.Coord{X: x, Y: 0}
+	a.players[id] = player
+	a.online[id] = true
+	return player
+}
+
+// runInActor 在一个临时 Actor 中执行 fn，用于测试需要 actor.Context 的方法
+func runInActor(t *testing.T, fn func(ctx *actor.Context)) {
+	t.Helper()
+	engine, err := actor.NewEngine(actor.NewEngineConfig())
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	runInEngine(t, engine, fn)
+}
+
+// runInEngine 在 engine 中的临时 Actor 里执行 fn，fn 发出的消息与 engine 中的其他 Actor 互通
+func runInEngine(t *testing.T, engine *actor.Engine, fn func(ctx *actor.Context)) {
+	t.Helper()
+	done := make(chan struct{})
+	pid := engine.SpawnFunc(func(ctx *actor.Context) {
+		if f, ok := ctx.Message().(func(*actor.Context)); ok {
+			f(ctx)
+			close(done)
+		}
+	}, "test")
+	defer engine.Poison(pid)
+	engine.Send(pid, fn)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("actor did not run the function")
+	}
+}
+
+// TestApplyBattleResult 进攻方获胜，防守方战后剩余 defenderHp
+func TestApplyBattleResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(a *GameActor)
+		nodeID     string
+		defenderHp int32
+		check      func(t *testing.T, atk, def *pb.PlayerData)
+	}{
+		{
+			name:       "defender defeated",
+			defenderHp: 0,
+			check: func(t *testing.T, atk, def *pb.PlayerData) {
+				if def.Status != pb.PlayerStatus_PLAYER_STATUS_RECOVERING || def.Hp != 0 {
+					t.Errorf("defender status %v hp %d, want recovering with 0 hp", def.Status, def.Hp)
+				}
+				if def.RecoverAt == 0 || def.ProtectedUntil <= time.Now().Unix() {
+					t.Errorf("defender RecoverAt %d ProtectedUntil %d not set", def.RecoverAt, def.ProtectedUntil)
+				}
+				if atk.Hp != 80 || atk.Status != pb.PlayerStatus_PLAYER_STATUS_ACTIVE {
+					t.Errorf("attacker hp %d status %v", atk.Hp, atk.Status)
+				}
+				if want := int64(baseExpWin + expPerEnemyLevel); atk.Experience != want {
+					t.Errorf("attacker experience %d, want %d", atk.Experience, want)
+				}
+				if want := int64(baseExpLose + expPerEnemyLevel); def.Experience != want {
+					t.Errorf("defender experience %d, want %d", def.Experience, want)
+				}
+				if hero := atk.Heroes[0]; hero.Experience == 0 && hero.Level == 1 {
+					t.Errorf("commanding hero gained no experience")
+				}
+				if got, want := def.Troops[TroopInfantry], initialTroops[TroopInfantry]-10; got != want {
+					t.Errorf("defender infantry %d, want %d", got, want)
+				}
+			},
+		},
+		{
+			name: "defeated player levelling up stays recovering",
+			setup: func(a *GameActor) {
+				a.players["def"].Experience = expPerLevel - 1
+			},
+			defenderHp: 0,
+			check: func(t *testing.T, atk, def *pb.PlayerData) {
+				if def.Level != 2 {
+					t.Fatalf("defender level %d, want 2", def.Level)
+				}
+				if def.Status != pb.PlayerStatus_PLAYER_STATUS_RECOVERING || def.Hp != 0 {
+					t.Errorf("defender status %v hp %d, want recovering with 0 hp", def.Status, def.Hp)
+				}
+			},
+		},
+		{
+			name:       "defender survives",
+			defenderHp: 30,
+			check: func(t *testing.T, atk, def *pb.PlayerData) {
+				if def.Status != pb.PlayerStatus_PLAYER_STATUS_ACTIVE || def.Hp != 30 {
+					t.Errorf("defender status %v hp %d, want active with 30 hp", def.Status, def.Hp)
+				}
+			},
+		},
+		{
+			name:       "resource node battle keeps hp and garrison",
+			nodeID:     "node_1",
+			defenderHp: 0,
+			check: func(t *testing.T, atk, def *pb.PlayerData) {
+				if def.Status != pb.PlayerStatus_PLAYER_STATUS_ACTIVE || def.Hp != def.MaxHp {
+					t.Errorf("defender status %v hp %d, want unchanged", def.Status, def.Hp)
+				}
+				if got := def.Troops[TroopInfantry]; got != initialTroops[TroopInfantry] {
+					t.Errorf("defender infantry %d, want unchanged", got)
+				}
+				if def.Experience == 0 {
+					t.Errorf("defender gained no experience")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestGameActor()
+			atk := addTestPlayer(a, "atk", 0)
+			def := addTestPlayer(a, "def", 10)
+			if tt.setup != nil {
+				tt.setup(a)
+			}
+			result := &pb.BattleResult{
+				AttackerId:     "atk",
+				DefenderId:     "def",
+				WinnerId:       "atk",
+				LoserId:        "def",
+				AttackerHp:     80,
+				DefenderHp:     tt.defenderHp,
+				AttackerHeroId: atk.Heroes[0].Id,
+				NodeId:         tt.nodeID,
+				DefenderStacks: []*pb.BattleStack{
+					{OwnerId: "def", TroopType: TroopInfantry, Lost: 10},
+				},
+			}
+			runInActor(t, func(ctx *actor.Context) {
+				a.applyBattleResult(ctx, result)
+			})
+			tt.check(t, atk, def)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package game
+
+import (
+	"log"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+const (
+	baseDurability         = 500              // 没有城墙时的城防耐久上限
+	durabilityPerWallLevel = 500              // 城墙每级增加的耐久上限
+	durabilityRecoveryTime = time.Hour        // 城防耐久从0恢复满所需的时间
+	wallDefensePerLevel    = 0.05             // 城墙每级为守城部队提供的防御加成，按剩余耐久比例生效
+	plunderRate            = 0.3              // 未受保护资源中被掠夺的比例
+	breachedPlunderRate    = 0.5              // 城防耐久归零后的掠夺比例
+	raidShield             = 15 * time.Minute // 被掠夺后的保护时间，避免同一目标被反复掠夺
+)
+
+// maxDurability 城防耐久上限
+func maxDurability(city *pb.City) int32 {
+	return baseDurability + durabilityPerWallLevel*city.Buildings[BuildingWall]
+}
+
+// settleDurability 按上次结算时间恢复城防耐久，durabilityRecoveryTime 内从0恢复满
+func settleDurability(city *pb.City, now time.Time) {
+	max := maxDurability(city)
+	nowMs := now.UnixMilli()
+	if city.Durability >= max {
+		city.Durability = max
+		city.DurabilityUpdatedAt = nowMs
+		return
+	}
+
+	perPoint := durabilityRecoveryTime.Milliseconds() / int64(max)
+	points := (nowMs - city.DurabilityUpdatedAt) / perPoint
+	if points <= 0 {
+		return
+	}
+	if int64(city.Durability)+points >= int64(max) {
+		city.Durability = max
+		city.DurabilityUpdatedAt = nowMs
+		return
+	}
+	city.Durability += int32(points)
+	city.DurabilityUpdatedAt += points * perPoint
+}
+
+// wallDefenseBonus 守城时城墙提供的防御加成
+func wallDefenseBonus(city *pb.City) float64 {
+	if city == nil || city.Buildings[BuildingWall] == 0 {
+		return 0
+	}
+	ratio := float64(city.Durability) / float64(maxDurability(city))
+	return wallDefensePerLevel * float64(city.Buildings[BuildingWall]) * ratio
+}
+
+// plunderAmounts 计算掠夺的资源：每种资源超出仓库保护量的部分按比例掠夺，
+// 总量不超过部队的携带上限，超出时按比例缩减
+func plunderAmounts(resources map[string]int64, protected, load int64, rate float64) map[string]int64 {
+	loot := make(map[string]int64)
+	total := int64(0)
+	for _, resource := range resourceTypes {
+		if available := resources[resource] - protected; available > 0 {
+			loot[resource] = int64(float64(available) * rate)
+			total += loot[resource]
+		}
+	}
+	if total > load {
+		for resource, amount := range loot {
+			loot[resource] = amount * load / total
+		}
+	}
+	for resource, amount := range loot {
+		if amount <= 0 {
+			delete(loot, resource)
+		}
+	}
+	return loot
+}
+
+// plunderCity 进攻方攻城胜利：幸存部队削减城防耐久并掠夺资源，掠夺的资源随行军带回；
+// 防守方获得掠夺保护。幸存部队以 GameActor 持有的行军部队为上限计算
+func (a *GameActor) plunderCity(ctx *actor.Context, result *pb.BattleResult) {
+	attacker, ok := a.players[result.AttackerId]
+	if !ok {
+		return
+	}
+	defender, ok := a.players[result.DefenderId]
+	if !ok {
+		return
+	}
+	m, ok := a.marches[a.fighting[attacker.Id]]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	survivors := marchSurvivors(m.march.Army, survivingTroops(result.AttackerStacks))
+	city := defender.City
+
+	settleDurability(city, now)
+	damage := armySiege(survivors)
+	if damage > city.Durability {
+		damage = city.Durability
+	}
+	city.Durability -= damage
+	breached := city.Durability == 0
+
+	rate := plunderRate
+	if breached {
+		rate = breachedPlunderRate
+	}
+	settleResources(defender, now)
+	loot := plunderAmounts(defender.Resources, protectedStorage(city), armyLoad(survivors), rate)
+	for resource, amount := range loot {
+		defender.Resources[resource] -= amount
+	}
+	m.march.Loot = loot
+
+	shieldUntil := now.Add(raidShield).Unix()
+	if defender.ProtectedUntil < shieldUntil {
+		defender.ProtectedUntil = shieldUntil
+	}
+
+	report := &pb.PlunderReport{
+		BattleId:         result.BattleId,
+		AttackerId:       attacker.Id,
+		DefenderId:       defender.Id,
+		Resources:        loot,
+		DurabilityDamage: damage,
+		Durability:       city.Durability,
+		MaxDurability:    maxDurability(city),
+		Breached:         breached,
+		ShieldUntil:      defender.ProtectedUntil,
+	}
+	sendToPlayer(ctx, a.gatewayPID, attacker.Id, "plunder_report", report)
+	sendToPlayer(ctx, a.gatewayPID, defender.Id, "plunder_report", report)
+	log.Printf("[GameActor] %s plundered %s: loot=%v, durability -%d (%d left)",
+		attacker.Id, defender.Id, loot, damage, city.Durability)
+
+	a.syncCombatData(ctx, defender)
+	a.savePlayer(ctx, defender)
+	a.pushPlayerUpdate(ctx, defender)
+}
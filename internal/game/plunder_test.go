@@ -0,0 +1,216 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+func TestPlunderAmounts(t *testing.T) {
+	resources := map[string]int64{ResourceFood: 1000, ResourceWood: 500, ResourceStone: 100}
+	tests := []struct {
+		name      string
+		protected int64
+		load      int64
+		rate      float64
+		want      map[string]int64
+	}{
+		{"within load", 200, 10000, 0.3, map[string]int64{ResourceFood: 240, ResourceWood: 90}},
+		{"scaled down to load", 200, 165, 0.3, map[string]int64{ResourceFood: 120, ResourceWood: 45}},
+		{"breached rate", 0, 10000, 0.5, map[string]int64{ResourceFood: 500, ResourceWood: 250, ResourceStone: 50}},
+		{"everything protected", 1000, 10000, 0.3, map[string]int64{}},
+		{"no load", 0, 0, 0.3, map[string]int64{}},
+		{"rounded to zero dropped", 0, 10000, 0.001, map[string]int64{ResourceFood: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := plunderAmounts(resources, tt.protected, tt.load, tt.rate)
+			if len(got) != len(tt.want) {
+				t.Fatalf("plunderAmounts = %v, want %v", got, tt.want)
+			}
+			for resource, amount := range tt.want {
+				if got[resource] != amount {
+					t.Errorf("plunderAmounts = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSettleDurability(t *testing.T) {
+	now := time.Now()
+	nowMs := now.UnixMilli()
+	// 1级城墙耐久上限1000，每3.6秒恢复1点
+	perPoint := durabilityRecoveryTime.Milliseconds() / 1000
+	tests := []struct {
+		name          string
+		durability    int32
+		updatedAt     int64
+		want          int32
+		wantUpdatedAt int64
+	}{
+		{"partial recovery", 0, nowMs - 10*perPoint - 1, 10, nowMs - 1},
+		{"less than one point", 100, nowMs - perPoint + 1, 100, nowMs - perPoint + 1},
+		{"recovers to full", 990, nowMs - 20*perPoint, 1000, nowMs},
+		{"already full", 1000, nowMs - time.Hour.Milliseconds(), 1000, nowMs},
+		{"above max after downgrade", 1500, nowMs - perPoint, 1000, nowMs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			city := &pb.City{
+				Buildings:           map[string]int32{BuildingWall: 1},
+				Durability:          tt.durability,
+				DurabilityUpdatedAt: tt.updatedAt,
+			}
+			if got := maxDurability(city); got != 1000 {
+				t.Fatalf("maxDurability = %d, want 1000", got)
+			}
+			settleDurability(city, now)
+			if city.Durability != tt.want || city.DurabilityUpdatedAt != tt.wantUpdatedAt {
+				t.Errorf("durability %d updated at %d, want %d at %d",
+					city.Durability, city.DurabilityUpdatedAt, tt.want, tt.wantUpdatedAt)
+			}
+		})
+	}
+}
+
+func TestWallDefenseBonus(t *testing.T) {
+	tests := []struct {
+		name string
+		city *pb.City
+		want float64
+	}{
+		{"no city", nil, 0},
+		{"no wall", &pb.City{Buildings: map[string]int32{}, Durability: baseDurability}, 0},
+		{"full durability", &pb.City{Buildings: map[string]int32{BuildingWall: 2}, Durability: 1500}, 0.1},
+		{"half durability", &pb.City{Buildings: map[string]int32{BuildingWall: 2}, Durability: 750}, 0.05},
+		{"breached", &pb.City{Buildings: map[string]int32{BuildingWall: 2}, Durability: 0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wallDefenseBonus(tt.city); got < tt.want-1e-9 || got > tt.want+1e-9 {
+				t.Errorf("wallDefenseBonus = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlunderCity(t *testing.T) {
+	tests := []struct {
+		name       string
+		durability int32
+		rate       float64
+		breached   bool
+	}{
+		{"wall holds", 1000, plunderRate, false},
+		{"wall breached", 10, breachedPlunderRate, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestGameActor()
+			addTestPlayer(a, "atk", 0)
+			def := addTestPlayer(a, "def", 10)
+			now := time.Now()
+			def.City.Buildings[BuildingWall] = 1
+			def.City.Durability = tt.durability
+			def.City.DurabilityUpdatedAt = now.UnixMilli()
+			// 结算时间晚于当前时间，掠夺前不会产出资源
+			def.ResourcesUpdatedAt = now.Add(time.Hour).UnixMilli()
+			def.Resources = map[string]int64{ResourceFood: 5000, ResourceWood: 3000, ResourceIron: 100}
+			before := copyResources(def.Resources)
+
+			m := &activeMarch{march: &pb.March{Id: "march_1", OwnerId: "atk", TargetId: "def",
+				Army: map[string]int32{TroopInfantry: 50}}}
+			a.marches[m.march.Id] = m
+			a.fighting["atk"] = m.march.Id
+
+			// 战报中的幸存数量超过行军部队的部分不计入
+			result := &pb.BattleResult{
+				AttackerId: "atk",
+				DefenderId: "def",
+				WinnerId:   "atk",
+				AttackerStacks: []*pb.BattleStack{
+					{OwnerId: "atk", TroopType: TroopInfantry, Remaining: 40},
+					{OwnerId: "atk", TroopType: TroopCavalry, Remaining: 30},
+				},
+			}
+			runInActor(t, func(ctx *actor.Context) {
+				a.plunderCity(ctx, result)
+			})
+
+			survivors := map[string]int32{TroopInfantry: 40}
+			wantDurability := tt.durability - armySiege(survivors)
+			if wantDurability < 0 {
+				wantDurability = 0
+			}
+			if def.City.Durability != wantDurability {
+				t.Errorf("durability %d, want %d", def.City.Durability, wantDurability)
+			}
+			if breached := def.City.Durability == 0; breached != tt.breached {
+				t.Errorf("breached = %v, want %v", breached, tt.breached)
+			}
+
+			want := plunderAmounts(before, protectedStorage(def.City), armyLoad(survivors), tt.rate)
+			if len(m.march.Loot) == 0 || len(m.march.Loot) != len(want) {
+				t.Fatalf("loot %v, want %v", m.march.Loot, want)
+			}
+			for resource, amount := range want {
+				if m.march.Loot[resource] != amount {
+					t.Errorf("loot %v, want %v", m.march.Loot, want)
+				}
+				if def.Resources[resource] != before[resource]-amount {
+					t.Errorf("defender %s %d, want %d", resource, def.Resources[resource], before[resource]-amount)
+				}
+			}
+			if def.ProtectedUntil < now.Add(raidShield).Unix() {
+				t.Errorf("defender not shielded after the raid: %d", def.ProtectedUntil)
+			}
+		})
+	}
+}
+
+// TestHandleBattleResolvedPlunders 攻城胜利的掠夺在行军结束战斗、掉头返回之前结算
+func TestHandleBattleResolvedPlunders(t *testing.T) {
+	a := newTestGameActor()
+	addTestPlayer(a, "atk", 0)
+	def := addTestPlayer(a, "def", 10)
+	def.ResourcesUpdatedAt = time.Now().Add(time.Hour).UnixMilli()
+	def.Resources = map[string]int64{ResourceFood: 5000}
+
+	m := &activeMarch{march: &pb.March{Id: "march_1", OwnerId: "atk", TargetId: "def",
+		Army: map[string]int32{TroopInfantry: 50}, Status: pb.MarchStatus_MARCH_STATUS_OUTBOUND,
+		From: &pb.Coord{X: 0}, To: &pb.Coord{X: 10}}}
+	a.marches[m.march.Id] = m
+	a.fighting["atk"] = m.march.Id
+	a.battling["atk"] = "def"
+	a.battling["def"] = "atk"
+
+	result := &pb.BattleResult{
+		BattleId:       "battle_1",
+		AttackerId:     "atk",
+		DefenderId:     "def",
+		WinnerId:       "atk",
+		LoserId:        "def",
+		AttackerHp:     100,
+		AttackerStacks: []*pb.BattleStack{{OwnerId: "atk", TroopType: TroopInfantry, Remaining: 40}},
+	}
+	runInActor(t, func(ctx *actor.Context) {
+		a.handleBattleResolved(ctx, &battleResolved{result: result})
+	})
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+
+	if m.march.Loot[ResourceFood] == 0 || def.Resources[ResourceFood] >= 5000 {
+		t.Errorf("loot %v, defender food %d: city not plundered", m.march.Loot, def.Resources[ResourceFood])
+	}
+	if m.march.Status != pb.MarchStatus_MARCH_STATUS_RETURNING || m.march.Army[TroopInfantry] != 40 {
+		t.Errorf("march status %v, army %v, want the survivors returning", m.march.Status, m.march.Army)
+	}
+	if _, ok := a.fighting["atk"]; ok || len(a.battling) != 0 {
+		t.Errorf("battle state not cleared: fighting %v, battling %v", a.fighting, a.battling)
+	}
+}
@@ -0,0 +1,196 @@
+package game
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/world"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+const maxReinforcements = 5 // 每个玩家主城可接受的增援行军数量上限
+
+// allied 两名玩家属于同一联盟
+func allied(a, b *pb.PlayerData) bool {
+	return a.AllianceId != "" && a.AllianceId == b.AllianceId
+}
+
+// handleReinforce 派出部队前往同一联盟玩家的主城驻守
+func (a *GameActor) handleReinforce(ctx *actor.Context, msg *pb.GameMessage) {
+	player, ok := a.players[msg.Id]
+	if !ok {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodePlayerNotFound, "player not found"))
+		return
+	}
+	var req pb.ReinforceRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid reinforce request format"))
+		return
+	}
+	if err := a.validateReinforce(player, req.HostId); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, err)
+		return
+	}
+
+	// 未指定增援部队时派出全部驻守部队
+	if len(req.Army) == 0 {
+		req.Army = copyTroops(player.Troops)
+	}
+	if err := validateArmy(req.Army, player.Troops); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, err)
+		return
+	}
+
+	host := a.players[req.HostId]
+	a.startMarch(ctx, player, &pb.March{
+		TargetId:  host.Id,
+		Army:      req.Army,
+		Reinforce: true,
+	}, host.Home)
+}
+
+// validateReinforce 检查增援目标是同一联盟的其他玩家，且目标主城还能接受增援
+func (a *GameActor) validateReinforce(player *pb.PlayerData, hostID string) error {
+	if hostID == player.Id {
+		return newGameError(ErrCodeInvalidRequest, "cannot reinforce yourself")
+	}
+	host, ok := a.players[hostID]
+	if !ok {
+		return newGameError(ErrCodePlayerNotFound, "player not found: %s", hostID)
+	}
+	if !allied(player, host) {
+		return newGameError(ErrCodeNotAllied, "player %s is not in your alliance", hostID)
+	}
+	if player.Home == nil || host.Home == nil {
+		return newGameError(ErrCodeServiceUnavailable, "city location not assigned yet")
+	}
+	if a.marchCount(player.Id) >= maxMarches {
+		return newGameError(ErrCodeMarchLimit, "too many marches: max %d", maxMarches)
+	}
+
+	count := 0
+	for _, m := range a.marches {
+		if !m.march.Reinforce || m.march.TargetId != host.Id {
+			continue
+		}
+		// 援军按所属玩家区分战后的幸存部队，每名玩家只能向同一主城派出一支援军
+		if m.march.OwnerId == player.Id {
+			return newGameError(ErrCodeAlreadyReinforcing, "already reinforcing %s", host.Id)
+		}
+		count++
+	}
+	if count >= maxReinforcements {
+		return newGameError(ErrCodeReinforcementFull, "%s cannot accept more reinforcements: max %d", host.Id, maxReinforcements)
+	}
+	return nil
+}
+
+// arriveAtHost 增援行军到达盟友主城后驻守，目标已不是盟友时返回主城
+func (a *GameActor) arriveAtHost(ctx *actor.Context, m *activeMarch) {
+	owner, ownerExists := a.players[m.march.OwnerId]
+	host, hostExists := a.players[m.march.TargetId]
+	if !ownerExists || !hostExists || !allied(owner, host) {
+		a.returnMarch(ctx, m, time.Now())
+		return
+	}
+
+	m.march.Status = pb.MarchStatus_MARCH_STATUS_STATIONED
+	if a.mapPID != nil {
+		ctx.Engine().Send(a.mapPID, &mapEntityUpdate{entity: &pb.MapEntity{
+			Id:      m.march.Id,
+			Kind:    world.EntityArmy,
+			X:       m.march.To.X,
+			Y:       m.march.To.Y,
+			OwnerId: owner.Id,
+		}})
+	}
+	a.syncTileArmy(ctx, m.march.To)
+	a.pushMarch(ctx, "march_update", m, owner.Id, host.Id)
+	log.Printf("[GameActor] March %s stationed at %s: army=%s", m.march.Id, host.Id, formatTroops(m.march.Army))
+}
+
+// stationedReinforcements 驻守在玩家主城的援军，守城时加入防守方
+func (a *GameActor) stationedReinforcements(host *pb.PlayerData) []*pb.Reinforcement {
+	var reinforcements []*pb.Reinforcement
+	for _, m := range a.marches {
+		if !m.march.Reinforce || m.march.TargetId != host.Id || m.march.Status != pb.MarchStatus_MARCH_STATUS_STATIONED {
+			continue
+		}
+		owner, ok := a.players[m.march.OwnerId]
+		if !ok {
+			continue
+		}
+		reinforcements = append(reinforcements, &pb.Reinforcement{
+			MarchId: m.march.Id,
+			OwnerId: owner.Id,
+			Army:    copyTroops(m.march.Army),
+			Attack:  owner.Attack,
+			Defense: owner.Defense,
+		})
+	}
+	return reinforcements
+}
+
+// settleReinforcements 守城战结束后更新参战援军的幸存部队，并将战报发给援军所属玩家。
+// 全军覆没的援军结束行军，守城失败时幸存的援军返回主城
+func (a *GameActor) settleReinforcements(ctx *actor.Context, result *pb.BattleResult) {
+	for _, m := range a.marches {
+		if !m.march.Reinforce || m.march.TargetId != result.DefenderId {
+			continue
+		}
+		survivors, fought := reinforcementSurvivors(result.DefenderStacks, m.march.OwnerId)
+		if !fought {
+			continue
+		}
+		m.march.Army = marchSurvivors(m.march.Army, survivors)
+		m.march.BattleId = result.BattleId
+		sendToPlayer(ctx, a.gatewayPID, m.march.OwnerId, "battle_result", result)
+
+		switch {
+		case len(m.march.Army) == 0:
+			a.finishMarch(ctx, m)
+		case result.WinnerId == result.AttackerId && m.march.Status == pb.MarchStatus_MARCH_STATUS_STATIONED:
+			a.returnMarch(ctx, m, time.Now())
+		default:
+			a.pushMarch(ctx, "march_update", m, m.march.OwnerId, m.march.TargetId)
+		}
+	}
+}
+
+// reinforcementSurvivors 援军所属玩家在战斗后幸存的部队，fought 表示该玩家的部队参加了战斗
+func reinforcementSurvivors(stacks []*pb.BattleStack, ownerID string) (survivors map[string]int32, fought bool) {
+	survivors = make(map[string]int32)
+	for _, stack := range stacks {
+		if stack.OwnerId != ownerID {
+			continue
+		}
+		fought = true
+		if stack.Remaining > 0 {
+			survivors[stack.TroopType] = stack.Remaining
+		}
+	}
+	return survivors, fought
+}
+
+// releaseReinforcements 玩家离开联盟后，召回其派出的以及驻守在其主城的、已不再是盟友的援军
+func (a *GameActor) releaseReinforcements(ctx *actor.Context, playerID string) {
+	now := time.Now()
+	for _, m := range a.marches {
+		if !m.march.Reinforce || (m.march.OwnerId != playerID && m.march.TargetId != playerID) {
+			continue
+		}
+		if m.march.Status != pb.MarchStatus_MARCH_STATUS_OUTBOUND && m.march.Status != pb.MarchStatus_MARCH_STATUS_STATIONED {
+			continue
+		}
+		owner, ownerExists := a.players[m.march.OwnerId]
+		host, hostExists := a.players[m.march.TargetId]
+		if ownerExists && hostExists && allied(owner, host) {
+			continue
+		}
+		a.returnMarch(ctx, m, now)
+		log.Printf("[GameActor] Reinforcement %s released: %s is no longer allied with %s",
+			m.march.Id, m.march.OwnerId, m.march.TargetId)
+	}
+}
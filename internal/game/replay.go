@@ -0,0 +1,48 @@
+package game
+
+import (
+	"fmt"
+
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// ReplayBattle 使用战报中保存的输入和随机种子重新结算战斗，
+// 战斗ID、时间和状态沿用原战报
+func ReplayBattle(battle *pb.BattleResult) (*pb.BattleResult, error) {
+	if battle.Input == nil || battle.Input.Attacker == nil || battle.Input.Defender == nil {
+		return nil, fmt.Errorf("battle %s has no recorded input", battle.BattleId)
+	}
+	replay := ResolveBattle(battle.Input)
+	replay.BattleId = battle.BattleId
+	replay.Timestamp = battle.Timestamp
+	replay.Status = battle.Status
+	replay.Input = battle.Input
+	return replay, nil
+}
+
+// SameOutcome 比较两份战报的结算内容，忽略战斗ID、时间、状态、资源点、援军玩家和输入快照
+func SameOutcome(a, b *pb.BattleResult) bool {
+	return proto.Equal(outcomeOf(a), outcomeOf(b))
+}
+
+func outcomeOf(battle *pb.BattleResult) *pb.BattleResult {
+	outcome := proto.Clone(battle).(*pb.BattleResult)
+	outcome.BattleId = ""
+	outcome.Timestamp = 0
+	outcome.Status = pb.BattleStatus_BATTLE_STATUS_PENDING
+	outcome.Input = nil
+	outcome.NodeId = ""
+	outcome.ReinforcementIds = nil
+	return outcome
+}
+
+// publicReport 返回发送给玩家的战报副本，不包含双方的输入快照
+func publicReport(battle *pb.BattleResult) *pb.BattleResult {
+	if battle.Input == nil {
+		return battle
+	}
+	report := proto.Clone(battle).(*pb.BattleResult)
+	report.Input = nil
+	return report
+}
@@ -0,0 +1,154 @@
+package game
+
+import (
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// 资源类型
+const (
+	ResourceFood  = "food"  // 粮食
+	ResourceWood  = "wood"  // 木材
+	ResourceStone = "stone" // 石料
+	ResourceIron  = "iron"  // 铁矿
+	ResourceGold  = "gold"  // 金币
+)
+
+// resourceTypes 全部资源类型，按固定顺序遍历
+var resourceTypes = []string{ResourceFood, ResourceWood, ResourceStone, ResourceIron, ResourceGold}
+
+const (
+	baseStorageCapacity      = 5000 // 没有仓库时每种资源的容量
+	storageCapacityPerLevel  = 5000 // 仓库每级增加的容量
+	protectedStoragePerLevel = 1000 // 仓库每级保护的资源数量
+)
+
+// productionTable 产出资源的建筑：资源类型和每级每小时产量
+var productionTable = map[string]struct {
+	resource string
+	perLevel int64
+}{
+	BuildingFarm:     {ResourceFood, 200},
+	BuildingSawmill:  {ResourceWood, 200},
+	BuildingQuarry:   {ResourceStone, 150},
+	BuildingIronMine: {ResourceIron, 100},
+	BuildingMarket:   {ResourceGold, 50},
+}
+
+// initialResources 新玩家的初始资源
+var initialResources = map[string]int64{
+	ResourceFood:  2000,
+	ResourceWood:  2000,
+	ResourceStone: 1000,
+	ResourceIron:  500,
+	ResourceGold:  200,
+}
+
+// copyResources 复制资源数量
+func copyResources(src map[string]int64) map[string]int64 {
+	dst := make(map[string]int64, len(src))
+	for resource, amount := range src {
+		dst[resource] = amount
+	}
+	return dst
+}
+
+// resourceProduction 主城每小时的资源产量
+func resourceProduction(city *pb.City) map[string]int64 {
+	production := make(map[string]int64)
+	for building, level := range city.Buildings {
+		if p, ok := productionTable[building]; ok {
+			production[p.resource] += p.perLevel * int64(level)
+		}
+	}
+	return production
+}
+
+// storageCapacity 每种资源的仓库容量
+func storageCapacity(city *pb.City) int64 {
+	return baseStorageCapacity + storageCapacityPerLevel*int64(city.Buildings[BuildingWarehouse])
+}
+
+// protectedStorage 每种资源受仓库保护的数量
+func protectedStorage(city *pb.City) int64 {
+	return protectedStoragePerLevel * int64(city.Buildings[BuildingWarehouse])
+}
+
+// currentResources 按上次结算时间计算当前的资源数量，不修改玩家数据；
+// 产出不超过仓库容量，已超出容量的资源（如掠夺所得）保留但不再增长
+func currentResources(player *pb.PlayerData, now time.Time) map[string]int64 {
+	amounts := copyResources(player.Resources)
+	elapsed := now.UnixMilli() - player.ResourcesUpdatedAt
+	if elapsed <= 0 {
+		return amounts
+	}
+	capacity := storageCapacity(player.City)
+	for resource, rate := range resourceProduction(player.City) {
+		current := amounts[resource]
+		if current >= capacity {
+			continue
+		}
+		produced := rate * elapsed / int64(time.Hour/time.Millisecond)
+		if current+produced > capacity {
+			produced = capacity - current
+		}
+		amounts[resource] = current + produced
+	}
+	return amounts
+}
+
+// settleResources 将产出计入玩家资源并更新结算时间。
+// 不足一个单位的产出在结算时舍去，所以只在资源或建筑等级变化前结算，查询时使用 currentResources
+func settleResources(player *pb.PlayerData, now time.Time) {
+	player.Resources = currentResources(player, now)
+	player.ResourcesUpdatedAt = now.UnixMilli()
+}
+
+// spendResources 扣除资源，资源不足时返回错误且不扣除
+func spendResources(player *pb.PlayerData, cost map[string]int64) error {
+	for _, resource := range resourceTypes {
+		if have := player.Resources[resource]; have < cost[resource] {
+			return newGameError(ErrCodeNotEnoughResources, "not enough %s: have %d, need %d",
+				resource, have, cost[resource])
+		}
+	}
+	for resource, amount := range cost {
+		player.Resources[resource] -= amount
+	}
+	return nil
+}
+
+// addResources 增加资源，不受仓库容量限制
+func addResources(player *pb.PlayerData, amounts map[string]int64) {
+	for resource, amount := range amounts {
+		player.Resources[resource] += amount
+	}
+}
+
+// resourceState 玩家在 now 时刻的资源状态
+func resourceState(player *pb.PlayerData, now time.Time) *pb.ResourceState {
+	return &pb.ResourceState{
+		Amounts:    currentResources(player, now),
+		Production: resourceProduction(player.City),
+		Capacity:   storageCapacity(player.City),
+		Protected:  protectedStorage(player.City),
+		UpdatedAt:  now.UnixMilli(),
+	}
+}
+
+// handleResourcesGet 返回玩家当前的资源状态
+func (a *GameActor) handleResourcesGet(ctx *actor.Context, msg *pb.GameMessage) {
+	player, ok := a.players[msg.Id]
+	if !ok {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodePlayerNotFound, "player not found"))
+		return
+	}
+	sendToPlayer(ctx, a.gatewayPID, player.Id, "resources_get_response", resourceState(player, time.Now()))
+}
+
+// pushResourcesUpdate 资源因消耗、返还或产量变化而改变时推送给玩家
+func (a *GameActor) pushResourcesUpdate(ctx *actor.Context, player *pb.PlayerData) {
+	sendToPlayer(ctx, a.gatewayPID, player.Id, "resources_update", resourceState(player, time.Now()))
+}
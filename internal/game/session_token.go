@@ -0,0 +1,65 @@
+package game
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// defaultSessionTTL 未配置 Server.SessionTTL 时使用的会话令牌有效期
+const defaultSessionTTL = 24 * time.Hour
+
+// errInvalidSessionToken 令牌格式错误、签名不符或已过期
+var errInvalidSessionToken = errors.New("invalid or expired session token")
+
+// sessionClaims 会话令牌中签名保护的内容
+type sessionClaims struct {
+	Username  string `json:"u"`
+	PlayerID  string `json:"p"`
+	ExpiresAt int64  `json:"exp"` // 过期时间（Unix秒）
+}
+
+// signSessionToken 签发会话令牌，格式为 base64url(claims).base64url(HMAC-SHA256(claims))
+func signSessionToken(secret []byte, claims *sessionClaims) (string, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sessionSignature(secret, payload)), nil
+}
+
+// parseSessionToken 校验令牌的签名和有效期，返回其中的账号信息
+func parseSessionToken(secret []byte, token string, now time.Time) (*sessionClaims, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errInvalidSessionToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, sessionSignature(secret, payload)) {
+		return nil, errInvalidSessionToken
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, errInvalidSessionToken
+	}
+	var claims sessionClaims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return nil, errInvalidSessionToken
+	}
+	if claims.Username == "" || claims.PlayerID == "" || now.Unix() >= claims.ExpiresAt {
+		return nil, errInvalidSessionToken
+	}
+	return &claims, nil
+}
+
+// sessionSignature 令牌内容的 HMAC-SHA256 签名
+func sessionSignature(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
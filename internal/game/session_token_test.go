@@ -0,0 +1,87 @@
+package game
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionToken(t *testing.T) {
+	secret := []byte("test-secret")
+	now := time.Unix(1700000000, 0)
+	valid := &sessionClaims{Username: "alice", PlayerID: "player_1", ExpiresAt: now.Add(time.Hour).Unix()}
+	token, err := signSessionToken(secret, valid)
+	if err != nil {
+		t.Fatalf("signSessionToken: %v", err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+
+	sign := func(claims *sessionClaims) string {
+		token, err := signSessionToken(secret, claims)
+		if err != nil {
+			t.Fatalf("signSessionToken: %v", err)
+		}
+		return token
+	}
+	// 替换令牌内容但保留原签名
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"u":"mallory","p":"player_2","exp":9999999999}`))
+
+	tests := []struct {
+		name   string
+		secret []byte
+		token  string
+		now    time.Time
+		ok     bool
+	}{
+		{"valid", secret, token, now, true},
+		{"just before expiry", secret, token, time.Unix(valid.ExpiresAt-1, 0), true},
+		{"expired", secret, token, time.Unix(valid.ExpiresAt, 0), false},
+		{"wrong secret", []byte("other-secret"), token, now, false},
+		{"tampered payload", secret, forged + "." + signature, now, false},
+		{"tampered signature", secret, payload + "." + signature[:len(signature)-2] + "AA", now, false},
+		{"missing signature", secret, payload, now, false},
+		{"not base64", secret, "!!!." + signature, now, false},
+		{"empty", secret, "", now, false},
+		{"missing player", secret, sign(&sessionClaims{Username: "alice", ExpiresAt: valid.ExpiresAt}), now, false},
+		{"missing username", secret, sign(&sessionClaims{PlayerID: "player_1", ExpiresAt: valid.ExpiresAt}), now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := parseSessionToken(tt.secret, tt.token, tt.now)
+			if !tt.ok {
+				if !errors.Is(err, errInvalidSessionToken) {
+					t.Errorf("parseSessionToken = %v, %v; want errInvalidSessionToken", claims, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSessionToken: %v", err)
+			}
+			if *claims != *valid {
+				t.Errorf("claims = %+v, want %+v", claims, valid)
+			}
+		})
+	}
+}
+
+func TestNewAccountActorSessionTTL(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{"configured", time.Hour, time.Hour},
+		{"missing", 0, defaultSessionTTL},
+		{"negative", -time.Second, defaultSessionTTL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAccountActor([]byte("test-secret"), tt.ttl)().(*AccountActor)
+			if a.tokenTTL != tt.want {
+				t.Errorf("tokenTTL = %v, want %v", a.tokenTTL, tt.want)
+			}
+		})
+	}
+}
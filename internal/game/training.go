@@ -0,0 +1,170 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+const (
+	maxTrainQueue      = 2        // 同时进行的训练任务数量上限
+	trainCountPerLevel = 100      // 单次训练数量上限 = 兵营等级 * trainCountPerLevel
+	trainTaskPrefix    = "train_" // 训练任务ID前缀
+)
+
+// trainComplete 训练计时结束时 GameActor 发给自身的消息
+type trainComplete struct {
+	playerID string
+	taskID   string
+	finishAt int64
+}
+
+// trainCost 训练指定数量的士兵所需的资源
+func trainCost(troopType string, count int32) map[string]int64 {
+	cost := make(map[string]int64)
+	for resource, amount := range troopStatTable[troopType].cost {
+		cost[resource] = amount * int64(count)
+	}
+	return cost
+}
+
+// handleTrainMessage 处理训练相关的请求
+func (a *GameActor) handleTrainMessage(ctx *actor.Context, msg *pb.GameMessage) {
+	player, ok := a.players[msg.Id]
+	if !ok {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodePlayerNotFound, "player not found"))
+		return
+	}
+	var req pb.TrainRequest
+	if err := json.Unmarshal(msg.Payload, &req); err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, newGameError(ErrCodeInvalidRequest, "invalid train request format"))
+		return
+	}
+
+	var err error
+	switch msg.Type {
+	case "train_start":
+		err = a.startTraining(ctx, player, req.TroopType, req.Count)
+	case "train_cancel":
+		err = a.cancelTraining(player, req.TaskId)
+	}
+	if err != nil {
+		a.sendError(ctx, msg.Id, msg.SessionId, msg.Type, err)
+		return
+	}
+	a.savePlayer(ctx, player)
+	a.pushCityUpdate(ctx, player)
+	a.pushResourcesUpdate(ctx, player)
+}
+
+// startTraining 扣除训练资源，将训练任务加入兵营队列
+func (a *GameActor) startTraining(ctx *actor.Context, player *pb.PlayerData, troopType string, count int32) error {
+	stats, ok := troopStatTable[troopType]
+	if !ok {
+		return newGameError(ErrCodeInvalidRequest, "unknown troop type: %s", troopType)
+	}
+	barracks := player.City.Buildings[BuildingBarracks]
+	if barracks < stats.barracks {
+		return newGameError(ErrCodePrerequisiteNotMet, "%s requires %s level %d",
+			troopType, BuildingBarracks, stats.barracks)
+	}
+	if limit := barracks * trainCountPerLevel; count <= 0 || count > limit {
+		return newGameError(ErrCodeInvalidRequest, "invalid train count %d: must be 1-%d", count, limit)
+	}
+	if len(player.City.Training) >= maxTrainQueue {
+		return newGameError(ErrCodeTrainQueueFull, "train queue is full: max %d", maxTrainQueue)
+	}
+
+	now := time.Now()
+	settleResources(player, now)
+	if err := spendResources(player, trainCost(troopType, count)); err != nil {
+		return err
+	}
+
+	a.trainSeq++
+	task := &pb.TrainTask{
+		Id:        fmt.Sprintf("%s%d", trainTaskPrefix, a.trainSeq),
+		TroopType: troopType,
+		Count:     count,
+		StartAt:   now.UnixMilli(),
+		FinishAt:  now.Add(stats.trainTime * time.Duration(count)).UnixMilli(),
+	}
+	player.City.Training = append(player.City.Training, task)
+	a.scheduleTraining(ctx, player.Id, task)
+	log.Printf("[GameActor] Player %s started training %d %s, task=%s", player.Id, count, troopType, task.Id)
+	return nil
+}
+
+// cancelTraining 取消训练任务并全额返还训练资源
+func (a *GameActor) cancelTraining(player *pb.PlayerData, taskID string) error {
+	task := findTrainTask(player.City, taskID)
+	if task == nil {
+		return newGameError(ErrCodeTaskNotFound, "train task not found: %s", taskID)
+	}
+	a.removeTrainTask(player.City, taskID)
+	settleResources(player, time.Now())
+	addResources(player, trainCost(task.TroopType, task.Count))
+	log.Printf("[GameActor] Player %s cancelled train task %s", player.Id, taskID)
+	return nil
+}
+
+// scheduleTraining 设置训练任务的完成计时
+func (a *GameActor) scheduleTraining(ctx *actor.Context, playerID string, task *pb.TrainTask) {
+	pid := ctx.PID()
+	engine := ctx.Engine()
+	msg := &trainComplete{playerID: playerID, taskID: task.Id, finishAt: task.FinishAt}
+	delay := time.Until(time.UnixMilli(task.FinishAt))
+	a.trainTimers[task.Id] = time.AfterFunc(delay, func() {
+		engine.Send(pid, msg)
+	})
+}
+
+// handleTrainComplete 训练计时结束，士兵加入驻守部队并同步给 CombatActor
+func (a *GameActor) handleTrainComplete(ctx *actor.Context, msg *trainComplete) {
+	player, ok := a.players[msg.playerID]
+	if !ok {
+		return
+	}
+	task := findTrainTask(player.City, msg.taskID)
+	if task == nil || task.FinishAt != msg.finishAt {
+		return
+	}
+
+	a.removeTrainTask(player.City, task.Id)
+	addTroops(player.Troops, map[string]int32{task.TroopType: task.Count})
+	log.Printf("[GameActor] Player %s finished training %d %s", player.Id, task.Count, task.TroopType)
+
+	a.syncCombatData(ctx, player)
+	a.savePlayer(ctx, player)
+	sendToPlayer(ctx, a.gatewayPID, player.Id, "train_complete", task)
+	a.pushCityUpdate(ctx, player)
+	a.pushPlayerUpdate(ctx, player)
+}
+
+// removeTrainTask 从训练队列中移除任务并停止计时
+func (a *GameActor) removeTrainTask(city *pb.City, taskID string) {
+	if timer, ok := a.trainTimers[taskID]; ok {
+		timer.Stop()
+		delete(a.trainTimers, taskID)
+	}
+	for i, task := range city.Training {
+		if task.Id == taskID {
+			city.Training = append(city.Training[:i], city.Training[i+1:]...)
+			return
+		}
+	}
+}
+
+// findTrainTask 按ID查找训练任务
+func findTrainTask(city *pb.City, taskID string) *pb.TrainTask {
+	for _, task := range city.Training {
+		if task.Id == taskID {
+			return task
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// 兵种
+const (
+	TroopInfantry = "infantry" // 步兵
+	TroopCavalry  = "cavalry"  // 骑兵
+	TroopArcher   = "archer"   // 弓兵
+	TroopSiege    = "siege"    // 攻城器械
+)
+
+// troopLord 没有部队时由玩家本人迎战
+const troopLord = "lord"
+
+// TroopTypes 所有兵种，战斗中按此顺序行动
+var TroopTypes = []string{TroopInfantry, TroopCavalry, TroopArcher, TroopSiege}
+
+// troopStats 单个士兵的基础属性和训练条件
+type troopStats struct {
+	attack    float64
+	defense   float64
+	hp        int64
+	speed     float64          // 行军速度（格/分钟）
+	load      int64            // 单兵可携带的资源数量
+	siege     int32            // 单兵对城防耐久造成的伤害
+	cost      map[string]int64 // 训练一个士兵消耗的资源
+	trainTime time.Duration    // 训练一个士兵的耗时
+	barracks  int32            // 训练所需的兵营等级
+}
+
+var troopStatTable = map[string]troopStats{
+	TroopInfantry: {attack: 10, defense: 12, hp: 30, speed: 10, load: 10, siege: 1,
+		cost: map[string]int64{ResourceFood: 20, ResourceWood: 10}, trainTime: 3 * time.Second, barracks: 1},
+	TroopCavalry: {attack: 14, defense: 8, hp: 35, speed: 20, load: 20, siege: 1,
+		cost: map[string]int64{ResourceFood: 30, ResourceIron: 10}, trainTime: 5 * time.Second, barracks: 2},
+	TroopArcher: {attack: 12, defense: 6, hp: 20, speed: 10, load: 10, siege: 1,
+		cost: map[string]int64{ResourceFood: 20, ResourceWood: 20}, trainTime: 4 * time.Second, barracks: 1},
+	TroopSiege: {attack: 20, defense: 4, hp: 40, speed: 5, load: 5, siege: 20,
+		cost: map[string]int64{ResourceWood: 60, ResourceStone: 40, ResourceIron: 20}, trainTime: 10 * time.Second, barracks: 3},
+}
+
+// counterMatrix 兵种克制系数：counterMatrix[进攻兵种][目标兵种]。
+// 步兵克骑兵、骑兵克弓兵、弓兵克步兵，攻城器械对部队作战能力较弱
+var counterMatrix = map[string]map[string]float64{
+	TroopInfantry: {TroopInfantry: 1, TroopCavalry: 1.5, TroopArcher: 0.75, TroopSiege: 1.25},
+	TroopCavalry:  {TroopInfantry: 0.75, TroopCavalry: 1, TroopArcher: 1.5, TroopSiege: 1.5},
+	TroopArcher:   {TroopInfantry: 1.5, TroopCavalry: 0.75, TroopArcher: 1, TroopSiege: 1},
+	TroopSiege:    {TroopInfantry: 0.5, TroopCavalry: 0.5, TroopArcher: 0.5, TroopSiege: 0.75},
+}
+
+// initialTroops 新玩家的初始驻守部队
+var initialTroops = map[string]int32{
+	TroopInfantry: 50,
+	TroopCavalry:  20,
+	TroopArcher:   30,
+}
+
+// counterFactor 返回兵种克制系数，未定义的组合（如玩家本人）按1计算
+func counterFactor(from, to string) float64 {
+	if factors, ok := counterMatrix[from]; ok {
+		if factor, ok := factors[to]; ok {
+			return factor
+		}
+	}
+	return 1
+}
+
+// validTroopType 检查兵种是否存在
+func validTroopType(troopType string) bool {
+	_, ok := troopStatTable[troopType]
+	return ok
+}
+
+// validateArmy 检查出征部队的兵种和数量，并确认不超过拥有的部队
+func validateArmy(army, owned map[string]int32) error {
+	total := int32(0)
+	for troopType, count := range army {
+		if !validTroopType(troopType) {
+			return newGameError(ErrCodeInvalidRequest, "unknown troop type: %s", troopType)
+		}
+		if count < 0 {
+			return newGameError(ErrCodeInvalidRequest, "invalid troop count for %s: %d", troopType, count)
+		}
+		if count > owned[troopType] {
+			return newGameError(ErrCodeNotEnoughTroops, "not enough %s: have %d, need %d",
+				troopType, owned[troopType], count)
+		}
+		total += count
+	}
+	if total == 0 {
+		return newGameError(ErrCodeNotEnoughTroops, "army is empty")
+	}
+	return nil
+}
+
+// armySpeed 部队的行军速度，由最慢的兵种决定
+func armySpeed(army map[string]int32) float64 {
+	speed := 0.0
+	for troopType, count := range army {
+		if count <= 0 {
+			continue
+		}
+		if s := troopStatTable[troopType].speed; speed == 0 || s < speed {
+			speed = s
+		}
+	}
+	return speed
+}
+
+// armyLoad 部队可携带的资源总量
+func armyLoad(army map[string]int32) int64 {
+	load := int64(0)
+	for troopType, count := range army {
+		load += troopStatTable[troopType].load * int64(count)
+	}
+	return load
+}
+
+// armySiege 部队对城防耐久造成的伤害
+func armySiege(army map[string]int32) int32 {
+	damage := int32(0)
+	for troopType, count := range army {
+		damage += troopStatTable[troopType].siege * count
+	}
+	return damage
+}
+
+// addTroops 将部队加入玩家的驻守部队
+func addTroops(troops, army map[string]int32) {
+	for troopType, count := range army {
+		if count > 0 {
+			troops[troopType] += count
+		}
+	}
+}
+
+// removeTroops 从玩家的驻守部队中扣除部队，调用前需通过 validateArmy 校验
+func removeTroops(troops, army map[string]int32) {
+	for troopType, count := range army {
+		if troops[troopType] -= count; troops[troopType] <= 0 {
+			delete(troops, troopType)
+		}
+	}
+}
+
+// copyTroops 复制部队数量，忽略数量为0的兵种
+func copyTroops(troops map[string]int32) map[string]int32 {
+	result := make(map[string]int32, len(troops))
+	for troopType, count := range troops {
+		if count > 0 {
+			result[troopType] = count
+		}
+	}
+	return result
+}
+
+// formatTroops 按兵种顺序格式化部队，用于日志
+func formatTroops(troops map[string]int32) string {
+	s := ""
+	for _, troopType := range TroopTypes {
+		if count := troops[troopType]; count > 0 {
+			if s != "" {
+				s += ","
+			}
+			s += fmt.Sprintf("%s:%d", troopType, count)
+		}
+	}
+	return s
+}
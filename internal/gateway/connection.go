@@ -0,0 +1,150 @@
+package gateway
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultQueueSize = 256              // 未配置出站队列长度时使用的默认值
+	writeWait        = 10 * time.Second // 单条消息写入 WebSocket 的超时时间
+)
+
+// SlowClientPolicy 出站队列已满时对慢速客户端的处理方式
+type SlowClientPolicy string
+
+const (
+	// SlowClientDisconnect 断开连接，玩家进入重连等待，之后的消息由重连缓存保留
+	SlowClientDisconnect SlowClientPolicy = "disconnect"
+	// SlowClientDrop 丢弃这条消息，保留连接
+	SlowClientDrop SlowClientPolicy = "drop"
+)
+
+// queuedMessage 出站队列中的消息：writePump 只使用序列化后的 data，
+// msg 在连接断开时由 GatewayActor 移入重连缓存
+type queuedMessage struct {
+	msg  *pb.GameMessage
+	data []byte
+}
+
+// clientConn 客户端连接及其出站队列。GatewayActor 只把消息放入队列，
+// 由每个连接独立的 writePump 协程写入 WebSocket，慢速客户端不会阻塞其他玩家
+type clientConn struct {
+	id   string
+	conn *websocket.Conn
+	send chan queuedMessage // 出站队列，长度即队列上限
+	done chan struct{}      // 连接关闭时关闭，通知 writePump 退出
+
+	closeOnce sync.Once
+	highWater int   // 队列深度的历史最大值，只由 GatewayActor 更新
+	dropped   int64 // 队列已满时丢弃的消息数量，只由 GatewayActor 更新
+}
+
+// newClientConn 创建连接并启动 writePump
+func newClientConn(id string, conn *websocket.Conn, queueSize int) *clientConn {
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	c := &clientConn{
+		id:   id,
+		conn: conn,
+		send: make(chan queuedMessage, queueSize),
+		done: make(chan struct{}),
+	}
+	go c.writePump()
+	return c
+}
+
+// enqueue 将消息放入出站队列，队列已满时不阻塞，返回 false
+func (c *clientConn) enqueue(msg *pb.GameMessage, data []byte) bool {
+	select {
+	case c.send <- queuedMessage{msg: msg, data: data}:
+		if depth := len(c.send); depth > c.highWater {
+			c.highWater = depth
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// close 关闭连接，readPump 随之退出并清理连接
+func (c *clientConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// closed 连接是否已关闭
+func (c *clientConn) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// drain 取出出站队列中尚未发送的消息，在连接关闭后调用；
+// 关闭时 writePump 正在写入的那条消息不在其中
+func (c *clientConn) drain() []*pb.GameMessage {
+	var pending []*pb.GameMessage
+	for {
+		select {
+		case item := <-c.send:
+			pending = append(pending, item.msg)
+		default:
+			return pending
+		}
+	}
+}
+
+// writePump 按顺序将出站队列中的消息写入 WebSocket，写入失败时关闭连接
+func (c *clientConn) writePump() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case item := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.BinaryMessage, item.data); err != nil {
+				select {
+				case <-c.done:
+					// 连接已被主动关闭
+				default:
+					log.Printf("[GatewayActor] Error sending message to client %s: %v", c.id, err)
+					c.close()
+				}
+				return
+			}
+		}
+	}
+}
+
+// ClientQueueStats 单个连接的出站队列状态
+type ClientQueueStats struct {
+	ClientID  string `json:"client_id"`
+	PlayerID  string `json:"player_id,omitempty"`
+	Depth     int    `json:"depth"`
+	Capacity  int    `json:"capacity"`
+	HighWater int    `json:"high_water"`
+	Dropped   int64  `json:"dropped"`
+}
+
+// QueueStatsRequest 查询所有连接的出站队列状态，GatewayActor 回复 *QueueStats
+type QueueStatsRequest struct{}
+
+// QueueStats 出站队列的统计数据
+type QueueStats struct {
+	Connections     int                 `json:"connections"`
+	TotalDepth      int                 `json:"total_depth"`
+	MaxDepth        int                 `json:"max_depth"`
+	Dropped         int64               `json:"dropped"`          // 累计丢弃的消息数量，包括已关闭的连接
+	SlowDisconnects int64               `json:"slow_disconnects"` // 因队列已满被断开的连接数量
+	Clients         []*ClientQueueStats `json:"clients"`
+}
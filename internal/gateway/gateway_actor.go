@@ -3,10 +3,13 @@ package gateway
 import (
 	"encoding/json"
 	"log"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/game"
 	pb "github.com/cowpeatechnology/slg-game-server/proto"
 	"github.com/gorilla/websocket"
 	"google.golang.org/protobuf/proto"
@@ -18,18 +21,106 @@ type ConnectMessage struct {
 	Conn     *websocket.Conn
 }
 
+// disconnectMessage is sent by readPump when a client connection closes
+type disconnectMessage struct {
+	client *clientConn
+}
+
+// inboundMessage is sent by readPump for every message read from a client connection
+type inboundMessage struct {
+	clientID string
+	data     []byte
+}
+
+// graceExpired is sent to the gateway itself when a disconnected player's reconnect window closes
+type graceExpired struct {
+	playerID string
+	clientID string
+}
+
+// preJoinTypes 连接绑定玩家之前允许发送的消息类型
+var preJoinTypes = map[string]bool{
+	"player_join": true,
+	"register":    true,
+	"login":       true,
+	"resume":      true,
+}
+
+// clientTypes 连接绑定玩家之后允许发送的请求类型。其他类型（如服务器之间的响应和通知）
+// 不能由客户端发送，一律拒绝
+var clientTypes = map[string]bool{
+	"chat":              true,
+	"battle_request":    true,
+	"battle_history":    true,
+	"battle_replay":     true,
+	"hero_list":         true,
+	"hero_recruit":      true,
+	"equipment_forge":   true,
+	"hero_equip":        true,
+	"hero_unequip":      true,
+	"march_recall":      true,
+	"march_list":        true,
+	"gather":            true,
+	"reinforce":         true,
+	"build_start":       true,
+	"build_speedup":     true,
+	"build_cancel":      true,
+	"train_start":       true,
+	"train_cancel":      true,
+	"resources_get":     true,
+	"alliance_create":   true,
+	"alliance_join":     true,
+	"alliance_leave":    true,
+	"alliance_kick":     true,
+	"alliance_promote":  true,
+	"alliance_members":  true,
+	"alliance_list":     true,
+	"alliance_announce": true,
+	"alliance_chat":     true,
+	"map_view":          true,
+	"map_subscribe":     true,
+	"map_unsubscribe":   true,
+}
+
+// maxBufferedMessages 断线玩家在重连窗口内最多缓存的消息数量，超出时丢弃最早的消息
+const maxBufferedMessages = 256
+
+// detachedPlayer 连接断开、等待重连的玩家及断线期间缓存的消息
+type detachedPlayer struct {
+	clientID string // 断开的连接ID
+	buffered []*pb.GameMessage
+	dropped  int // 超出缓存上限被丢弃的消息数量
+}
+
 // GatewayActor handles WebSocket connections and message routing
 type GatewayActor struct {
 	engine    *actor.Engine
-	clients   sync.Map // key: clientID, value: *websocket.Conn
+	clients   sync.Map // key: clientID, value: *clientConn
 	players   sync.Map // key: playerID, value: clientID
+	sessions  sync.Map // key: clientID, value: playerID
 	gameActor *actor.PID
+
+	reconnectGrace time.Duration              // 断线后等待重连的时间
+	detached       map[string]*detachedPlayer // 等待重连的玩家，key为playerID
+
+	queueSize       int              // 每个连接的出站队列长度
+	slowPolicy      SlowClientPolicy // 出站队列已满时的处理方式
+	dropped         int64            // 累计丢弃的消息数量
+	slowDisconnects int64            // 因队列已满被断开的连接数量
 }
 
 // NewGatewayActor creates a new Gateway Actor
-func NewGatewayActor() actor.Producer {
+func NewGatewayActor(reconnectGrace time.Duration, queueSize int, slowPolicy SlowClientPolicy) actor.Producer {
+	if slowPolicy != SlowClientDrop {
+		slowPolicy = SlowClientDisconnect
+	}
 	return func() actor.Receiver {
-		return &GatewayActor{}
+		return &GatewayActor{
+			reconnectGrace: reconnectGrace,
+			detached:       make(map[string]*detachedPlayer),
+			queueSize:      queueSize,
+			slowPolicy:     slowPolicy,
+		}
 	}
 }
 
@@ -44,8 +135,8 @@ func (a *GatewayActor) Receive(ctx *actor.Context) {
 		log.Println("[GatewayActor] Stopped")
 		// 清理所有连接
 		a.clients.Range(func(key, value interface{}) bool {
-			if conn, ok := value.(*websocket.Conn); ok {
-				conn.Close()
+			if client, ok := value.(*clientConn); ok {
+				client.close()
 			}
 			return true
 		})
@@ -59,15 +150,25 @@ func (a *GatewayActor) Receive(ctx *actor.Context) {
 		}
 
 	case *ConnectMessage:
-		// 存储连接
-		a.clients.Store(msg.ClientID, msg.Conn)
+		// 存储连接并启动写入协程
+		client := newClientConn(msg.ClientID, msg.Conn, a.queueSize)
+		a.clients.Store(msg.ClientID, client)
 		log.Printf("[GatewayActor] Client connected: %s", msg.ClientID)
 		// 启动消息读取
-		go a.readPump(ctx, msg.ClientID, msg.Conn)
+		go a.readPump(ctx, client)
+
+	case *disconnectMessage:
+		a.detachClient(ctx, msg.client)
 
-	case []byte:
+	case *graceExpired:
+		a.handleGraceExpired(msg)
+
+	case *inboundMessage:
 		// 处理从WebSocket接收到的原始消息
-		a.handleWebSocketMessage(ctx, msg)
+		a.handleWebSocketMessage(ctx, msg.clientID, msg.data)
+
+	case *QueueStatsRequest:
+		ctx.Respond(a.queueStats())
 
 	case *pb.GameMessage:
 		a.handleGameMessage(ctx, msg)
@@ -75,27 +176,146 @@ func (a *GatewayActor) Receive(ctx *actor.Context) {
 }
 
 // readPump reads messages from the WebSocket connection
-func (a *GatewayActor) readPump(ctx *actor.Context, clientID string, conn *websocket.Conn) {
+func (a *GatewayActor) readPump(ctx *actor.Context, client *clientConn) {
+	clientID := client.id
 	defer func() {
-		conn.Close()
+		client.close()
 		a.clients.Delete(clientID)
 		log.Printf("[GatewayActor] Client disconnected: %s", clientID)
+		a.engine.Send(ctx.PID(), &disconnectMessage{client: client})
 	}()
 
 	for {
-		_, data, err := conn.ReadMessage()
+		_, data, err := client.conn.ReadMessage()
 		if err != nil {
 			log.Printf("[GatewayActor] Read error: %v", err)
 			return
 		}
 
-		// 直接发送原始数据给自身处理
-		a.engine.Send(ctx.PID(), data)
+		// 将原始数据连同连接ID发送给自身处理
+		a.engine.Send(ctx.PID(), &inboundMessage{clientID: clientID, data: data})
+	}
+}
+
+// detachClient closes a connection and detaches its player. Messages still waiting in the
+// send queue are moved into the reconnect buffer, so a resume delivers them in order
+func (a *GatewayActor) detachClient(ctx *actor.Context, client *clientConn) {
+	client.close()
+	pending := client.drain()
+	a.handleDisconnect(ctx, client.id)
+	for _, msg := range pending {
+		a.bufferMessage(msg)
+	}
+}
+
+// handleDisconnect detaches the player of a closed connection, keeping it for reconnectGrace
+// so that a resume on a new connection receives the messages sent in between
+func (a *GatewayActor) handleDisconnect(ctx *actor.Context, clientID string) {
+	value, ok := a.sessions.LoadAndDelete(clientID)
+	if !ok {
+		return
+	}
+	playerID := value.(string)
+	if current, ok := a.players.Load(playerID); !ok || current.(string) != clientID {
+		return
+	}
+	a.players.Delete(playerID)
+	if a.reconnectGrace <= 0 {
+		a.notifyOffline(playerID)
+		return
+	}
+
+	a.detached[playerID] = &detachedPlayer{clientID: clientID}
+	pid := ctx.PID()
+	engine := a.engine
+	time.AfterFunc(a.reconnectGrace, func() {
+		engine.Send(pid, &graceExpired{playerID: playerID, clientID: clientID})
+	})
+	log.Printf("[GatewayActor] Player %s detached from client %s, waiting %v for reconnect", playerID, clientID, a.reconnectGrace)
+}
+
+// handleGraceExpired drops the buffered messages of a player that did not reconnect in time
+// and notifies GameActor that it went offline
+func (a *GatewayActor) handleGraceExpired(msg *graceExpired) {
+	detached, ok := a.detached[msg.playerID]
+	if !ok || detached.clientID != msg.clientID {
+		return
+	}
+	delete(a.detached, msg.playerID)
+	log.Printf("[GatewayActor] Player %s did not reconnect, dropping %d buffered messages",
+		msg.playerID, len(detached.buffered)+detached.dropped)
+	a.notifyOffline(msg.playerID)
+}
+
+// bufferMessage keeps a message for a detached player until it reconnects.
+// Replies to requests from other connections are not buffered
+func (a *GatewayActor) bufferMessage(msg *pb.GameMessage) bool {
+	detached, ok := a.detached[msg.Id]
+	if !ok || (msg.SessionId != "" && msg.SessionId != detached.clientID) {
+		return false
+	}
+	if len(detached.buffered) >= maxBufferedMessages {
+		detached.buffered = detached.buffered[1:]
+		detached.dropped++
+	}
+	detached.buffered = append(detached.buffered, msg)
+	return true
+}
+
+// bindPlayer maps a player to the connection that sent its player_join, login or resume request.
+// A connection is bound to at most one player; joining again releases the previous player.
+// Messages buffered while the player was detached are returned for delivery on the new connection
+func (a *GatewayActor) bindPlayer(playerID, clientID string) []*pb.GameMessage {
+	if _, ok := a.clients.Load(clientID); !ok {
+		// 连接在响应返回前已断开，玩家未绑定到其他连接且不在等待重连时直接下线
+		log.Printf("[GatewayActor] Client %s closed before player %s was bound", clientID, playerID)
+		_, bound := a.players.Load(playerID)
+		if _, waiting := a.detached[playerID]; !bound && !waiting {
+			a.notifyOffline(playerID)
+		}
+		return nil
+	}
+	if previous, ok := a.sessions.Load(clientID); ok && previous.(string) != playerID {
+		a.unbindPlayer(previous.(string), clientID)
+	}
+	// 玩家在新连接上登录后，原连接不再代表该玩家
+	if previous, ok := a.players.Load(playerID); ok && previous.(string) != clientID {
+		a.sessions.Delete(previous.(string))
+		log.Printf("[GatewayActor] Player %s moved from client %s to %s", playerID, previous, clientID)
+	}
+	a.players.Store(playerID, clientID)
+	a.sessions.Store(clientID, playerID)
+	log.Printf("[GatewayActor] Mapped player %s to client %s", playerID, clientID)
+
+	detached, ok := a.detached[playerID]
+	if !ok {
+		return nil
+	}
+	delete(a.detached, playerID)
+	if detached.dropped > 0 {
+		log.Printf("[GatewayActor] Player %s reconnected, %d buffered messages were dropped", playerID, detached.dropped)
+	}
+	return detached.buffered
+}
+
+// unbindPlayer removes the player's mapping if it still points at clientID and notifies GameActor
+func (a *GatewayActor) unbindPlayer(playerID, clientID string) {
+	if current, ok := a.players.Load(playerID); !ok || current.(string) != clientID {
+		return
+	}
+	a.players.Delete(playerID)
+	a.notifyOffline(playerID)
+}
+
+// notifyOffline tells GameActor that the player no longer has a connection
+func (a *GatewayActor) notifyOffline(playerID string) {
+	if a.gameActor != nil {
+		a.engine.Send(a.gameActor, &game.PlayerOfflineMessage{PlayerID: playerID})
 	}
 }
 
 // handleWebSocketMessage processes messages received from WebSocket
-func (a *GatewayActor) handleWebSocketMessage(ctx *actor.Context, data []byte) {
+func (a *GatewayActor) handleWebSocketMessage(ctx *actor.Context, clientID string, data []byte) {
 	if a.gameActor == nil {
 		log.Printf("[GatewayActor] No GameActor available")
 		return
@@ -108,71 +328,140 @@ func (a *GatewayActor) handleWebSocketMessage(ctx *actor.Context, data []byte) {
 		return
 	}
 
+	// 标记消息来源的连接，客户端填写的 session_id 会被覆盖
+	gameMsg.SessionId = clientID
+
+	// 发送者身份以连接绑定的玩家为准，客户端填写的 id 会被覆盖；
+	// 未绑定玩家的连接只能发送加入和登录请求，已绑定的连接只能发送客户端请求
+	gameMsg.Id = ""
+	if playerID, ok := a.sessions.Load(clientID); ok {
+		gameMsg.Id = playerID.(string)
+		if !preJoinTypes[gameMsg.Type] && !clientTypes[gameMsg.Type] {
+			log.Printf("[GatewayActor] Rejected %s from client %s: not a client request", gameMsg.Type, clientID)
+			a.rejectMessage(ctx, clientID, gameMsg.Type, "message type not allowed: "+gameMsg.Type)
+			return
+		}
+	} else if !preJoinTypes[gameMsg.Type] {
+		log.Printf("[GatewayActor] Rejected %s from client %s: no player bound", gameMsg.Type, clientID)
+		a.rejectMessage(ctx, clientID, gameMsg.Type, "join or login before sending "+gameMsg.Type)
+		return
+	}
+
 	// 转发消息到 GameActor
 	a.engine.Send(a.gameActor, &gameMsg)
 }
 
+// rejectMessage replies not_authorized to a connection that sent a request it may not send
+func (a *GatewayActor) rejectMessage(ctx *actor.Context, clientID, msgType, message string) {
+	data, err := json.Marshal(&pb.ErrorResponse{
+		Code:        game.ErrCodeNotAuthorized,
+		Message:     message,
+		RequestType: msgType,
+	})
+	if err != nil {
+		log.Printf("[GatewayActor] Error encoding error response: %v", err)
+		return
+	}
+	a.writeMessage(ctx, clientID, &pb.GameMessage{Type: "error", Payload: data})
+}
+
 // handleGameMessage processes messages from GameActor
 func (a *GatewayActor) handleGameMessage(ctx *actor.Context, msg *pb.GameMessage) {
-	// 对于 player_join_response 消息，需要建立 playerID 到 clientID 的映射
-	if msg.Type == "player_join_response" {
-		// 解析响应消息获取玩家ID
-		var response struct {
-			ID string `json:"id"`
-		}
-		if err := json.Unmarshal(msg.Payload, &response); err != nil {
-			log.Printf("[GatewayActor] Failed to unmarshal player join response: %v", err)
+	// player_join_response 和登录成功的 login_response 带回发起请求的连接ID，将玩家绑定到该连接
+	var buffered []*pb.GameMessage
+	if (msg.Type == "player_join_response" || msg.Type == "login_response") && msg.Id != "" && msg.SessionId != "" {
+		buffered = a.bindPlayer(msg.Id, msg.SessionId)
+	}
+
+	// 优先回复到发起请求的连接，其余消息通过 playerID 查找连接
+	clientID := msg.SessionId
+	if clientID == "" {
+		value, ok := a.players.Load(msg.Id)
+		if !ok {
+			// 等待重连的玩家先缓存消息
+			if !a.bufferMessage(msg) {
+				log.Printf("[GatewayActor] Player not found: %s", msg.Id)
+			}
 			return
 		}
+		clientID = value.(string)
+	}
+	a.writeMessage(ctx, clientID, msg)
 
-		// 遍历所有客户端连接，找到未映射的连接
-		var foundClientID string
-		a.clients.Range(func(key, _ interface{}) bool {
-			clientID := key.(string)
-			// 检查这个clientID是否已经被映射到其他playerID
-			var isMapped bool
-			a.players.Range(func(_, value interface{}) bool {
-				if value.(string) == clientID {
-					isMapped = true
-					return false
-				}
-				return true
-			})
-			if !isMapped {
-				foundClientID = clientID
-				return false
-			}
-			return true
-		})
+	// 断线期间缓存的消息在重连响应之后按顺序发给新连接
+	for _, m := range buffered {
+		a.writeMessage(ctx, clientID, m)
+	}
+}
 
-		if foundClientID != "" {
-			// 建立 playerID 到 clientID 的映射
-			a.players.Store(response.ID, foundClientID)
-			log.Printf("[GatewayActor] Mapped player %s to client %s", response.ID, foundClientID)
-		}
+// writeMessage queues a message for the client's writer. If the connection is already gone,
+// the player is detached and the message is buffered until it reconnects
+func (a *GatewayActor) writeMessage(ctx *actor.Context, clientID string, msg *pb.GameMessage) {
+	value, ok := a.clients.Load(clientID)
+	if !ok || value.(*clientConn).closed() {
+		// 连接已关闭或已被 readPump 移除，但断开消息还未处理
+		a.handleDisconnect(ctx, clientID)
+		a.bufferMessage(msg)
+		return
 	}
+	client := value.(*clientConn)
 
-	// 通过 playerID 查找 clientID
-	if clientID, ok := a.players.Load(msg.Id); ok {
-		// 通过 clientID 查找连接
-		if conn, ok := a.clients.Load(clientID); ok {
-			wsConn := conn.(*websocket.Conn)
-			// 序列化消息
-			data, err := proto.Marshal(msg)
-			if err != nil {
-				log.Printf("[GatewayActor] Error encoding message: %v", err)
-				return
-			}
+	// 连接ID只在服务器内部使用，不发送给客户端
+	msg.SessionId = ""
+	// 序列化消息
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		log.Printf("[GatewayActor] Error encoding message: %v", err)
+		return
+	}
 
-			// 发送消息给客户端
-			if err := wsConn.WriteMessage(websocket.BinaryMessage, data); err != nil {
-				log.Printf("[GatewayActor] Error sending message to client %s: %v", clientID, err)
-				// 连接可能已断开，清理映射
-				a.clients.Delete(clientID)
-				a.players.Delete(msg.Id)
-			}
-		}
-	} else {
-		log.Printf("[GatewayActor] Player not found: %s", msg.Id)
+	// 放入出站队列，由连接的 writePump 发送
+	if client.enqueue(msg, data) {
+		return
 	}
+	a.dropped++
+	client.dropped++
+	if a.slowPolicy == SlowClientDrop {
+		log.Printf("[GatewayActor] Send queue of client %s is full, dropped %s", clientID, msg.Type)
+		return
+	}
+	// 断开慢速客户端，玩家转入等待重连，队列中未发送的消息、这条消息和之后的消息由重连缓存保留
+	a.slowDisconnects++
+	log.Printf("[GatewayActor] Send queue of client %s is full (%d), disconnecting", clientID, cap(client.send))
+	a.detachClient(ctx, client)
+	a.bufferMessage(msg)
+}
+
+// queueStats collects the send queue depth of every connection
+func (a *GatewayActor) queueStats() *QueueStats {
+	stats := &QueueStats{
+		Dropped:         a.dropped,
+		SlowDisconnects: a.slowDisconnects,
+		Clients:         []*ClientQueueStats{},
+	}
+	a.clients.Range(func(key, value interface{}) bool {
+		client := value.(*clientConn)
+		entry := &ClientQueueStats{
+			ClientID:  client.id,
+			Depth:     len(client.send),
+			Capacity:  cap(client.send),
+			HighWater: client.highWater,
+			Dropped:   client.dropped,
+		}
+		if playerID, ok := a.sessions.Load(client.id); ok {
+			entry.PlayerID = playerID.(string)
+		}
+		stats.Connections++
+		stats.TotalDepth += entry.Depth
+		if entry.Depth > stats.MaxDepth {
+			stats.MaxDepth = entry.Depth
+		}
+		stats.Clients = append(stats.Clients, entry)
+		return true
+	})
+	// 队列最深的连接排在前面
+	sort.Slice(stats.Clients, func(i, j int) bool {
+		return stats.Clients[i].Depth > stats.Clients[j].Depth
+	})
+	return stats
 }
@@ -0,0 +1,498 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anthdm/hollywood/actor"
+	"github.com/cowpeatechnology/slg-game-server/internal/game"
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+)
+
+// testGateway 运行中的 GatewayActor，GameActor 收到的消息转入 toGame，下线通知转入 offline
+type testGateway struct {
+	engine  *actor.Engine
+	gateway *actor.PID
+	toGame  chan *pb.GameMessage
+	offline chan string
+	server  *httptest.Server
+}
+
+// newTestGateway 启动使用给定重连等待时间、出站队列长度和慢速客户端策略的 GatewayActor
+func newTestGateway(t *testing.T, reconnectGrace time.Duration, queueSize int, slowPolicy SlowClientPolicy) *testGateway {
+	t.Helper()
+	engine, err := actor.NewEngine(actor.NewEngineConfig())
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	g := &testGateway{engine: engine, toGame: make(chan *pb.GameMessage, 16), offline: make(chan string, 16)}
+	gamePID := engine.SpawnFunc(func(ctx *actor.Context) {
+		switch msg := ctx.Message().(type) {
+		case *pb.GameMessage:
+			g.toGame <- msg
+		case *game.PlayerOfflineMessage:
+			g.offline <- msg.PlayerID
+		}
+	}, "game")
+	g.gateway = engine.Spawn(NewGatewayActor(reconnectGrace, queueSize, slowPolicy), "gateway")
+	engine.Send(g.gateway, gamePID)
+
+	var seq int64
+	upgrader := &websocket.Upgrader{}
+	g.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		engine.Send(g.gateway, &ConnectMessage{
+			ClientID: fmt.Sprintf("client-%d", atomic.AddInt64(&seq, 1)),
+			Conn:     conn,
+		})
+	}))
+	t.Cleanup(g.server.Close)
+	return g
+}
+
+// dial 建立一个客户端连接
+func (g *testGateway) dial(t *testing.T) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(g.server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, msg *pb.GameMessage) {
+	t.Helper()
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if err := conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+}
+
+func receive(t *testing.T, conn *websocket.Conn) *pb.GameMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	var msg pb.GameMessage
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	return &msg
+}
+
+// forwarded 等待 GatewayActor 转发给 GameActor 的消息
+func (g *testGateway) forwarded(t *testing.T) *pb.GameMessage {
+	t.Helper()
+	select {
+	case msg := <-g.toGame:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message forwarded to GameActor")
+		return nil
+	}
+}
+
+// join 以 player_join 将连接绑定到 playerID，由测试代替 GameActor 回复
+func (g *testGateway) join(t *testing.T, conn *websocket.Conn, playerID string) {
+	t.Helper()
+	send(t, conn, &pb.GameMessage{Type: "player_join"})
+	req := g.forwarded(t)
+	g.engine.Send(g.gateway, &pb.GameMessage{Type: "player_join_response", Id: playerID, SessionId: req.SessionId})
+	if resp := receive(t, conn); resp.Type != "player_join_response" || resp.Id != playerID {
+		t.Fatalf("join response = %v", resp)
+	}
+}
+
+func TestGatewayAllowlist(t *testing.T) {
+	tests := []struct {
+		name    string
+		bound   bool
+		msgType string
+		allowed bool
+	}{
+		{"join before binding", false, "player_join", true},
+		{"login before binding", false, "login", true},
+		{"resume before binding", false, "resume", true},
+		{"request before binding", false, "battle_request", false},
+		{"client request", true, "battle_request", true},
+		{"alliance request", true, "alliance_create", true},
+		{"login again", true, "login", true},
+		{"server response", true, "battle_report", false},
+		{"server notification", true, "player_update", false},
+		{"unknown type", true, "make_me_rich", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGateway(t, 0, 0, SlowClientDisconnect)
+			conn := g.dial(t)
+			if tt.bound {
+				g.join(t, conn, "player_1")
+			}
+
+			send(t, conn, &pb.GameMessage{Type: tt.msgType, Id: "spoofed", SessionId: "spoofed"})
+			if tt.allowed {
+				msg := g.forwarded(t)
+				if msg.Type != tt.msgType {
+					t.Fatalf("forwarded %s, want %s", msg.Type, tt.msgType)
+				}
+				wantID := ""
+				if tt.bound {
+					wantID = "player_1"
+				}
+				if msg.Id != wantID || msg.SessionId == "spoofed" {
+					t.Errorf("forwarded id %q session %q, want id %q and the real connection", msg.Id, msg.SessionId, wantID)
+				}
+				return
+			}
+
+			resp := receive(t, conn)
+			var errResp pb.ErrorResponse
+			if err := json.Unmarshal(resp.Payload, &errResp); err != nil || resp.Type != "error" {
+				t.Fatalf("response %v, want an error", resp)
+			}
+			if errResp.Code != game.ErrCodeNotAuthorized || errResp.RequestType != tt.msgType {
+				t.Errorf("error %+v, want not_authorized for %s", &errResp, tt.msgType)
+			}
+			select {
+			case msg := <-g.toGame:
+				t.Errorf("rejected message forwarded to GameActor: %v", msg)
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestGatewayBinding(t *testing.T) {
+	g := newTestGateway(t, 0, 0, SlowClientDisconnect)
+	first := g.dial(t)
+	second := g.dial(t)
+	g.join(t, first, "player_1")
+	g.join(t, second, "player_2")
+
+	// 按玩家ID推送的消息发给各自绑定的连接，不带连接ID
+	g.engine.Send(g.gateway, &pb.GameMessage{Type: "player_update", Id: "player_2"})
+	g.engine.Send(g.gateway, &pb.GameMessage{Type: "player_update", Id: "player_1"})
+	if msg := receive(t, first); msg.Id != "player_1" || msg.SessionId != "" {
+		t.Errorf("first connection received %v", msg)
+	}
+	if msg := receive(t, second); msg.Id != "player_2" || msg.SessionId != "" {
+		t.Errorf("second connection received %v", msg)
+	}
+
+	// 请求的发送者以连接绑定的玩家为准
+	send(t, second, &pb.GameMessage{Type: "chat", Id: "player_1"})
+	if msg := g.forwarded(t); msg.Id != "player_2" {
+		t.Errorf("chat forwarded as %q, want player_2", msg.Id)
+	}
+
+	// 失败的登录只回复发起请求的连接，不改变绑定
+	send(t, first, &pb.GameMessage{Type: "login"})
+	req := g.forwarded(t)
+	g.engine.Send(g.gateway, &pb.GameMessage{Type: "login_response", SessionId: req.SessionId})
+	if msg := receive(t, first); msg.Type != "login_response" {
+		t.Errorf("first connection received %v", msg)
+	}
+	send(t, first, &pb.GameMessage{Type: "chat"})
+	if msg := g.forwarded(t); msg.Id != "player_1" {
+		t.Errorf("chat after failed login forwarded as %q, want player_1", msg.Id)
+	}
+
+	// 在另一个连接上登录 player_1：该连接原来的玩家下线，player_1 的消息改发新连接，
+	// 原连接不再代表任何玩家
+	send(t, second, &pb.GameMessage{Type: "login"})
+	req = g.forwarded(t)
+	g.engine.Send(g.gateway, &pb.GameMessage{Type: "login_response", Id: "player_1", SessionId: req.SessionId})
+	if msg := receive(t, second); msg.Type != "login_response" || msg.Id != "player_1" {
+		t.Fatalf("second connection received %v", msg)
+	}
+	select {
+	case id := <-g.offline:
+		if id != "player_2" {
+			t.Errorf("offline notification for %s, want player_2", id)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("previous player of the connection not released")
+	}
+	g.engine.Send(g.gateway, &pb.GameMessage{Type: "player_update", Id: "player_1"})
+	if msg := receive(t, second); msg.Type != "player_update" || msg.Id != "player_1" {
+		t.Errorf("second connection received %v", msg)
+	}
+	send(t, first, &pb.GameMessage{Type: "chat"})
+	if msg := receive(t, first); msg.Type != "error" {
+		t.Errorf("request from the old connection answered with %v, want an error", msg)
+	}
+}
+
+// waitConnections 等待 GatewayActor 中的连接数量变为 want
+func (g *testGateway) waitConnections(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		result, err := g.engine.Request(g.gateway, &QueueStatsRequest{}, time.Second).Result()
+		if err != nil {
+			t.Fatalf("QueueStatsRequest: %v", err)
+		}
+		if result.(*QueueStats).Connections == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("connections = %d, want %d", result.(*QueueStats).Connections, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestGatewayResume 断线期间发给玩家的消息在重连响应之后按顺序发给新连接，超出缓存上限时丢弃最早的消息
+func TestGatewayResume(t *testing.T) {
+	tests := []struct {
+		name    string
+		sent    int
+		dropped int
+	}{
+		{"nothing buffered", 0, 0},
+		{"within buffer", 5, 0},
+		{"buffer full", maxBufferedMessages, 0},
+		{"buffer overflow", maxBufferedMessages + 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 出站队列容纳全部缓存消息，重连时不会触发慢速客户端断开
+			g := newTestGateway(t, time.Minute, 2*maxBufferedMessages, SlowClientDisconnect)
+			first := g.dial(t)
+			g.join(t, first, "player_1")
+			first.Close()
+			g.waitConnections(t, 0)
+
+			for i := 0; i < tt.sent; i++ {
+				g.engine.Send(g.gateway, &pb.GameMessage{Type: "player_update", Id: "player_1", Payload: []byte(fmt.Sprint(i))})
+			}
+
+			second := g.dial(t)
+			send(t, second, &pb.GameMessage{Type: "resume"})
+			req := g.forwarded(t)
+			if req.Type != "resume" || req.Id != "" {
+				t.Fatalf("forwarded %v, want an unbound resume", req)
+			}
+			g.engine.Send(g.gateway, &pb.GameMessage{Type: "login_response", Id: "player_1", SessionId: req.SessionId})
+			if msg := receive(t, second); msg.Type != "login_response" || msg.Id != "player_1" {
+				t.Fatalf("second connection received %v, want the login response first", msg)
+			}
+			for i := tt.dropped; i < tt.sent; i++ {
+				msg := receive(t, second)
+				if msg.Type != "player_update" || string(msg.Payload) != fmt.Sprint(i) {
+					t.Fatalf("buffered message %d = %s %q", i, msg.Type, msg.Payload)
+				}
+			}
+
+			// 重连后的消息直接发给新连接
+			g.engine.Send(g.gateway, &pb.GameMessage{Type: "player_update", Id: "player_1", Payload: []byte("live")})
+			if msg := receive(t, second); string(msg.Payload) != "live" {
+				t.Errorf("second connection received %v, want the live update", msg)
+			}
+			select {
+			case id := <-g.offline:
+				t.Errorf("player %s went offline within the reconnect window", id)
+			default:
+			}
+		})
+	}
+}
+
+// TestGatewayGraceExpired 重连等待时间结束后玩家下线，缓存的消息被丢弃
+func TestGatewayGraceExpired(t *testing.T) {
+	g := newTestGateway(t, 50*time.Millisecond, 0, SlowClientDisconnect)
+	first := g.dial(t)
+	g.join(t, first, "player_1")
+	first.Close()
+	g.waitConnections(t, 0)
+	g.engine.Send(g.gateway, &pb.GameMessage{Type: "player_update", Id: "player_1"})
+
+	select {
+	case id := <-g.offline:
+		if id != "player_1" {
+			t.Errorf("offline notification for %s, want player_1", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("player not released after the reconnect window")
+	}
+
+	second := g.dial(t)
+	g.join(t, second, "player_1")
+	g.engine.Send(g.gateway, &pb.GameMessage{Type: "player_update", Id: "player_1", Payload: []byte("live")})
+	if msg := receive(t, second); string(msg.Payload) != "live" {
+		t.Errorf("second connection received %v, want only the live update", msg)
+	}
+}
+
+// queueStats 查询 GatewayActor 的出站队列统计
+func (g *testGateway) queueStats(t *testing.T) *QueueStats {
+	t.Helper()
+	result, err := g.engine.Request(g.gateway, &QueueStatsRequest{}, time.Second).Result()
+	if err != nil {
+		t.Fatalf("QueueStatsRequest: %v", err)
+	}
+	return result.(*QueueStats)
+}
+
+// TestGatewaySlowClient 客户端不读取消息时出站队列被填满，按策略断开连接或丢弃消息
+func TestGatewaySlowClient(t *testing.T) {
+	const queueSize = 4
+	tests := []struct {
+		name   string
+		policy SlowClientPolicy
+	}{
+		{"disconnect", SlowClientDisconnect},
+		{"drop", SlowClientDrop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGateway(t, time.Minute, queueSize, tt.policy)
+			slow := g.dial(t)
+			g.join(t, slow, "player_1")
+			other := g.dial(t)
+			g.join(t, other, "player_2")
+
+			// 大消息填满套接字缓冲区后 writePump 阻塞，之后的消息留在出站队列中
+			payload := make([]byte, 1<<20)
+			var stats *QueueStats
+			for i := 0; i < 200; i++ {
+				g.engine.Send(g.gateway, &pb.GameMessage{Type: "player_update", Id: "player_1", Payload: payload})
+				if i%10 != 9 {
+					continue
+				}
+				if stats = g.queueStats(t); stats.Dropped > 0 {
+					break
+				}
+			}
+			if stats.Dropped == 0 {
+				t.Fatal("send queue of the slow client never filled")
+			}
+
+			switch tt.policy {
+			case SlowClientDisconnect:
+				if stats.SlowDisconnects != 1 {
+					t.Errorf("SlowDisconnects = %d, want 1", stats.SlowDisconnects)
+				}
+				g.waitConnections(t, 1)
+			case SlowClientDrop:
+				if stats.SlowDisconnects != 0 || stats.Connections != 2 {
+					t.Errorf("stats = %+v, want both connections kept", stats)
+				}
+				if slowest := stats.Clients[0]; slowest.PlayerID != "player_1" || slowest.HighWater != queueSize || slowest.Dropped == 0 {
+					t.Errorf("slowest client = %+v, want player_1 with a full queue", slowest)
+				}
+			}
+
+			// 其他玩家不受慢速客户端影响，慢速客户端的玩家保留等待重连
+			g.engine.Send(g.gateway, &pb.GameMessage{Type: "player_update", Id: "player_2"})
+			if msg := receive(t, other); msg.Id != "player_2" {
+				t.Errorf("other connection received %v", msg)
+			}
+			select {
+			case id := <-g.offline:
+				t.Errorf("player %s went offline", id)
+			default:
+			}
+		})
+	}
+}
+
+// runInActor 在一个临时 Actor 中执行 fn，用于测试需要 actor.Context 的方法
+func runInActor(t *testing.T, fn func(ctx *actor.Context)) {
+	t.Helper()
+	engine, err := actor.NewEngine(actor.NewEngineConfig())
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	done := make(chan struct{})
+	pid := engine.SpawnFunc(func(ctx *actor.Context) {
+		if f, ok := ctx.Message().(func(*actor.Context)); ok {
+			f(ctx)
+			close(done)
+		}
+	}, "test")
+	engine.Send(pid, fn)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("actor did not run the function")
+	}
+}
+
+// stalledClient 服务器端的连接，不启动 writePump，放入出站队列的消息不会被发送
+func stalledClient(t *testing.T, id string, queueSize int) *clientConn {
+	t.Helper()
+	conns := make(chan *websocket.Conn, 1)
+	upgrader := &websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+			conns <- conn
+		}
+	}))
+	t.Cleanup(server.Close)
+	peer, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { peer.Close() })
+	return &clientConn{id: id, conn: <-conns, send: make(chan queuedMessage, queueSize), done: make(chan struct{})}
+}
+
+// TestSlowClientQueuedMessagesBuffered 慢速客户端被断开时，出站队列中未发送的消息和触发断开的消息
+// 按顺序移入重连缓存；丢弃策略下只丢弃队列放不下的消息
+func TestSlowClientQueuedMessagesBuffered(t *testing.T) {
+	const queueSize = 2
+	tests := []struct {
+		name     string
+		policy   SlowClientPolicy
+		buffered []string
+		queued   int
+	}{
+		{"disconnect", SlowClientDisconnect, []string{"0", "1", "2", "3"}, 0},
+		{"drop", SlowClientDrop, nil, queueSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewGatewayActor(time.Minute, queueSize, tt.policy)().(*GatewayActor)
+			client := stalledClient(t, "client-1", queueSize)
+			runInActor(t, func(ctx *actor.Context) {
+				a.engine = ctx.Engine()
+				a.clients.Store(client.id, client)
+				a.bindPlayer("player_1", client.id)
+				for i := 0; i < 4; i++ {
+					a.writeMessage(ctx, client.id, &pb.GameMessage{Type: "player_update", Id: "player_1", Payload: []byte(fmt.Sprint(i))})
+				}
+			})
+
+			var buffered []string
+			if detached, ok := a.detached["player_1"]; ok {
+				for _, msg := range detached.buffered {
+					buffered = append(buffered, string(msg.Payload))
+				}
+			}
+			if strings.Join(buffered, ",") != strings.Join(tt.buffered, ",") {
+				t.Errorf("buffered %v, want %v", buffered, tt.buffered)
+			}
+			if len(client.send) != tt.queued || client.closed() != (tt.policy == SlowClientDisconnect) {
+				t.Errorf("queue depth %d, closed %v", len(client.send), client.closed())
+			}
+		})
+	}
+}
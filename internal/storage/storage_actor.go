@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -18,10 +19,26 @@ type StorageActor struct {
 	redis  *redis.Client
 }
 
+const (
+	battleTTL         = 7 * 24 * time.Hour // 战报的保存时间
+	maxBattleHistory  = 100                // 每个玩家保留的历史战斗数量
+	maxBattlePageSize = 50                 // 历史查询每页最大数量
+	allianceSetKey    = "alliances"        // 全部联盟ID的集合
+)
+
+var (
+	// ErrNotFound 查询的玩家、战报或账号不存在，响应的 Error 可用 errors.Is 判断
+	ErrNotFound = errors.New("not found")
+	// ErrAccountExists 注册的用户名已被使用
+	ErrAccountExists = errors.New("account already exists")
+)
+
 // NewStorageActor 创建 Storage Actor
-func NewStorageActor(redisClient *redis.Client) actor.Receiver {
-	return &StorageActor{
-		redis: redisClient,
+func NewStorageActor(redisClient *redis.Client) actor.Producer {
+	return func() actor.Receiver {
+		return &StorageActor{
+			redis: redisClient,
+		}
 	}
 }
 
@@ -40,22 +57,27 @@ func (a *StorageActor) Receive(ctx *actor.Context) {
 
 // StorageRequestMessage 存储请求消息
 type StorageRequestMessage struct {
-	Type string      // 操作类型：get_player, save_player, delete_player
-	Key  string      // 玩家ID
-	Data interface{} // 玩家数据
+	Type      string      // 操作类型：get_player, save_player, delete_player, get_battle, save_battle, update_battle, list_battles, save_alliance, delete_alliance, list_alliances, get_account, create_account
+	Key       string      // 玩家ID、战斗ID、联盟ID或用户名
+	Data      interface{} // 玩家数据、战报或查询条件
+	RequestID string      // 请求方自定义的关联ID，原样带回响应
 }
 
 // StorageResponseMessage 存储响应消息
 type StorageResponseMessage struct {
-	Type  string      // 操作类型
-	Data  interface{} // 返回的数据
-	Error error       // 错误信息
+	Type      string      // 操作类型
+	Key       string      // 请求的Key
+	RequestID string      // 请求中的关联ID
+	Data      interface{} // 返回的数据
+	Error     error       // 错误信息
 }
 
 // 处理存储请求
 func (a *StorageActor) handleStorageRequest(ctx *actor.Context, msg *StorageRequestMessage) {
 	var response StorageResponseMessage
 	response.Type = msg.Type
+	response.Key = msg.Key
+	response.RequestID = msg.RequestID
 
 	switch msg.Type {
 	case "get_player":
@@ -63,7 +85,7 @@ func (a *StorageActor) handleStorageRequest(ctx *actor.Context, msg *StorageRequ
 		data, err := a.redis.Get(context.Background(), a.getPlayerKey(msg.Key)).Bytes()
 		if err != nil {
 			if err == redis.Nil {
-				response.Error = fmt.Errorf("玩家不存在: %s", msg.Key)
+				response.Error = fmt.Errorf("玩家不存在: %s: %w", msg.Key, ErrNotFound)
 			} else {
 				response.Error = fmt.Errorf("获取玩家数据失败: %v", err)
 			}
@@ -83,7 +105,8 @@ func (a *StorageActor) handleStorageRequest(ctx *actor.Context, msg *StorageRequ
 			if err != nil {
 				response.Error = fmt.Errorf("序列化玩家数据失败: %v", err)
 			} else {
-				err = a.redis.Set(context.Background(), a.getPlayerKey(msg.Key), data, 24*time.Hour).Err()
+				// 玩家数据属于账号，不设置过期时间
+				err = a.redis.Set(context.Background(), a.getPlayerKey(msg.Key), data, 0).Err()
 				if err != nil {
 					response.Error = fmt.Errorf("保存玩家数据失败: %v", err)
 				}
@@ -99,6 +122,112 @@ func (a *StorageActor) handleStorageRequest(ctx *actor.Context, msg *StorageRequ
 			response.Error = fmt.Errorf("删除玩家数据失败: %v", err)
 		}
 
+	case "get_battle":
+		// 获取战报
+		data, err := a.redis.Get(context.Background(), a.getBattleKey(msg.Key)).Bytes()
+		if err != nil {
+			if err == redis.Nil {
+				response.Error = fmt.Errorf("战报不存在: %s: %w", msg.Key, ErrNotFound)
+			} else {
+				response.Error = fmt.Errorf("获取战报失败: %v", err)
+			}
+		} else {
+			var battle pb.BattleResult
+			if err := protobuf.Unmarshal(data, &battle); err != nil {
+				response.Error = fmt.Errorf("解析战报失败: %v", err)
+			} else {
+				response.Data = &battle
+			}
+		}
+
+	case "save_battle":
+		// 保存战报，并写入双方和守城援军所属玩家的战斗历史索引
+		if battle, ok := msg.Data.(*pb.BattleResult); ok {
+			response.Error = a.saveBattle(battle)
+		} else {
+			response.Error = fmt.Errorf("无效的战报数据类型")
+		}
+
+	case "update_battle":
+		// 更新战报内容，保留原有过期时间
+		if battle, ok := msg.Data.(*pb.BattleResult); ok {
+			data, err := protobuf.Marshal(battle)
+			if err != nil {
+				response.Error = fmt.Errorf("序列化战报失败: %v", err)
+			} else {
+				err = a.redis.SetXX(context.Background(), a.getBattleKey(msg.Key), data, redis.KeepTTL).Err()
+				if err != nil {
+					response.Error = fmt.Errorf("更新战报失败: %v", err)
+				}
+			}
+		} else {
+			response.Error = fmt.Errorf("无效的战报数据类型")
+		}
+
+	case "list_battles":
+		// 分页查询玩家的战斗历史
+		if query, ok := msg.Data.(*pb.BattleHistoryRequest); ok {
+			history, err := a.listBattles(msg.Key, query)
+			if err != nil {
+				response.Error = err
+			} else {
+				response.Data = history
+			}
+		} else {
+			response.Error = fmt.Errorf("无效的查询条件类型")
+		}
+
+	case "save_alliance":
+		if alliance, ok := msg.Data.(*pb.Alliance); ok {
+			response.Error = a.saveAlliance(alliance)
+		} else {
+			response.Error = fmt.Errorf("无效的联盟数据类型")
+		}
+
+	case "delete_alliance":
+		// 删除解散的联盟
+		pipe := a.redis.TxPipeline()
+		pipe.Del(context.Background(), a.getAllianceKey(msg.Key))
+		pipe.SRem(context.Background(), allianceSetKey, msg.Key)
+		if _, err := pipe.Exec(context.Background()); err != nil {
+			response.Error = fmt.Errorf("删除联盟失败: %v", err)
+		}
+
+	case "list_alliances":
+		// 读取全部联盟，AllianceActor 启动时加载
+		alliances, err := a.listAlliances()
+		if err != nil {
+			response.Error = err
+		} else {
+			response.Data = alliances
+		}
+
+	case "get_account":
+		// 按用户名获取账号
+		data, err := a.redis.Get(context.Background(), a.getAccountKey(msg.Key)).Bytes()
+		if err != nil {
+			if err == redis.Nil {
+				response.Error = fmt.Errorf("账号不存在: %s: %w", msg.Key, ErrNotFound)
+			} else {
+				response.Error = fmt.Errorf("获取账号失败: %v", err)
+			}
+		} else {
+			var account pb.Account
+			if err := protobuf.Unmarshal(data, &account); err != nil {
+				response.Error = fmt.Errorf("解析账号数据失败: %v", err)
+			} else {
+				response.Data = &account
+			}
+		}
+
+	case "create_account":
+		// 创建账号，用户名已存在时返回 ErrAccountExists
+		if account, ok := msg.Data.(*pb.Account); ok {
+			response.Error = a.createAccount(msg.Key, account)
+		} else {
+			response.Error = fmt.Errorf("无效的账号数据类型")
+		}
+
 	default:
 		response.Error = fmt.Errorf("未知的操作类型: %s", msg.Type)
 	}
@@ -120,3 +249,171 @@ func (a *StorageActor) handleStorageRequest(ctx *actor.Context, msg *StorageRequ
 func (a *StorageActor) getPlayerKey(id string) string {
 	return fmt.Sprintf("player:%s", id)
 }
+
+// saveBattle 保存战报并更新双方和守城援军所属玩家的战斗历史列表
+func (a *StorageActor) saveBattle(battle *pb.BattleResult) error {
+	data, err := protobuf.Marshal(battle)
+	if err != nil {
+		return fmt.Errorf("序列化战报失败: %v", err)
+	}
+
+	ctx := context.Background()
+	pipe := a.redis.TxPipeline()
+	pipe.Set(ctx, a.getBattleKey(battle.BattleId), data, battleTTL)
+	participants := append([]string{battle.AttackerId, battle.DefenderId}, battle.ReinforcementIds...)
+	for _, playerID := range participants {
+		key := a.getBattleHistoryKey(playerID)
+		pipe.LPush(ctx, key, battle.BattleId)
+		pipe.LTrim(ctx, key, 0, maxBattleHistory-1)
+		pipe.Expire(ctx, key, battleTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("保存战报失败: %v", err)
+	}
+	return nil
+}
+
+// listBattles 按时间倒序分页查询玩家的战斗摘要
+func (a *StorageActor) listBattles(playerID string, query *pb.BattleHistoryRequest) (*pb.BattleHistoryResponse, error) {
+	offset, limit := query.Offset, query.Limit
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || limit > maxBattlePageSize {
+		limit = maxBattlePageSize
+	}
+
+	ctx := context.Background()
+	key := a.getBattleHistoryKey(playerID)
+	total, err := a.redis.LLen(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询战斗历史失败: %v", err)
+	}
+	ids, err := a.redis.LRange(ctx, key, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询战斗历史失败: %v", err)
+	}
+
+	history := &pb.BattleHistoryResponse{Offset: offset, Total: int32(total)}
+	if len(ids) == 0 {
+		return history, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = a.getBattleKey(id)
+	}
+	values, err := a.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询战报失败: %v", err)
+	}
+	for _, value := range values {
+		// 战报可能已过期
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var battle pb.BattleResult
+		if err := protobuf.Unmarshal([]byte(raw), &battle); err != nil {
+			log.Printf("[StorageActor] 解析战报失败: %v", err)
+			continue
+		}
+		// 列表只返回摘要，详情通过战斗ID单独查询
+		battle.RoundReports = nil
+		battle.Input = nil
+		history.Battles = append(history.Battles, &battle)
+	}
+	return history, nil
+}
+
+// getBattleKey generates a Redis key for a battle report
+func (a *StorageActor) getBattleKey(id string) string {
+	return BattleKey(id)
+}
+
+// BattleKey 战报的 Redis Key，离线工具直接读取战报时使用
+func BattleKey(id string) string {
+	return fmt.Sprintf("battle:%s", id)
+}
+
+// saveAlliance 保存联盟，并记录在联盟ID集合中
+func (a *StorageActor) saveAlliance(alliance *pb.Alliance) error {
+	data, err := protobuf.Marshal(alliance)
+	if err != nil {
+		return fmt.Errorf("序列化联盟数据失败: %v", err)
+	}
+	ctx := context.Background()
+	pipe := a.redis.TxPipeline()
+	pipe.Set(ctx, a.getAllianceKey(alliance.Id), data, 0)
+	pipe.SAdd(ctx, allianceSetKey, alliance.Id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("保存联盟失败: %v", err)
+	}
+	return nil
+}
+
+// listAlliances 读取联盟ID集合中的全部联盟
+func (a *StorageActor) listAlliances() ([]*pb.Alliance, error) {
+	ctx := context.Background()
+	ids, err := a.redis.SMembers(ctx, allianceSetKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询联盟列表失败: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = a.getAllianceKey(id)
+	}
+	values, err := a.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询联盟失败: %v", err)
+	}
+	var alliances []*pb.Alliance
+	for _, value := range values {
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var alliance pb.Alliance
+		if err := protobuf.Unmarshal([]byte(raw), &alliance); err != nil {
+			log.Printf("[StorageActor] 解析联盟数据失败: %v", err)
+			continue
+		}
+		alliances = append(alliances, &alliance)
+	}
+	return alliances, nil
+}
+
+// createAccount 仅在用户名未被使用时保存账号
+func (a *StorageActor) createAccount(username string, account *pb.Account) error {
+	data, err := protobuf.Marshal(account)
+	if err != nil {
+		return fmt.Errorf("序列化账号数据失败: %v", err)
+	}
+	created, err := a.redis.SetNX(context.Background(), a.getAccountKey(username), data, 0).Result()
+	if err != nil {
+		return fmt.Errorf("创建账号失败: %v", err)
+	}
+	if !created {
+		return fmt.Errorf("用户名已存在: %s: %w", username, ErrAccountExists)
+	}
+	return nil
+}
+
+// getAccountKey generates a Redis key for an account
+func (a *StorageActor) getAccountKey(username string) string {
+	return fmt.Sprintf("account:%s", username)
+}
+
+// getAllianceKey generates a Redis key for an alliance
+func (a *StorageActor) getAllianceKey(id string) string {
+	return fmt.Sprintf("alliance:%s", id)
+}
+
+// getBattleHistoryKey generates a Redis key for a player's battle history
+func (a *StorageActor) getBattleHistoryKey(playerID string) string {
+	return fmt.Sprintf("player_battles:%s", playerID)
+}
@@ -0,0 +1,187 @@
+package world
+
+import (
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// 地图实体类型
+const (
+	EntityCity = "city" // 玩家城市
+	EntityArmy = "army" // 部队
+	EntityNode = "node" // 资源点
+)
+
+// spatialCellSize 空间索引的分桶大小
+const spatialCellSize = 16
+
+// Rect 闭区间矩形区域
+type Rect struct {
+	MinX, MinY, MaxX, MaxY int32
+}
+
+// Contains 坐标是否在区域内
+func (r Rect) Contains(x, y int32) bool {
+	return x >= r.MinX && x <= r.MaxX && y >= r.MinY && y <= r.MaxY
+}
+
+// Width 区域宽度
+func (r Rect) Width() int32 {
+	return r.MaxX - r.MinX + 1
+}
+
+// Height 区域高度
+func (r Rect) Height() int32 {
+	return r.MaxY - r.MinY + 1
+}
+
+// cellKey 空间分桶的坐标
+type cellKey struct {
+	x, y int32
+}
+
+func cellOf(x, y int32) cellKey {
+	return cellKey{floorDiv(x, spatialCellSize), floorDiv(y, spatialCellSize)}
+}
+
+// forEachCell 遍历区域覆盖的所有分桶
+func forEachCell(r Rect, fn func(cellKey)) {
+	min, max := cellOf(r.MinX, r.MinY), cellOf(r.MaxX, r.MaxY)
+	for cy := min.y; cy <= max.y; cy++ {
+		for cx := min.x; cx <= max.x; cx++ {
+			fn(cellKey{cx, cy})
+		}
+	}
+}
+
+func floorDiv(v, d int32) int32 {
+	if v < 0 {
+		return (v - d + 1) / d
+	}
+	return v / d
+}
+
+// SpatialIndex 按坐标分桶索引地图上的实体（城市、部队等）
+type SpatialIndex struct {
+	entities map[string]*pb.MapEntity
+	cells    map[cellKey]map[string]*pb.MapEntity
+}
+
+// NewSpatialIndex 创建空间索引
+func NewSpatialIndex() *SpatialIndex {
+	return &SpatialIndex{
+		entities: make(map[string]*pb.MapEntity),
+		cells:    make(map[cellKey]map[string]*pb.MapEntity),
+	}
+}
+
+// Get 按ID查找实体
+func (s *SpatialIndex) Get(id string) *pb.MapEntity {
+	return s.entities[id]
+}
+
+// Upsert 添加或更新实体，返回更新前的实体（不存在时为 nil）
+func (s *SpatialIndex) Upsert(entity *pb.MapEntity) *pb.MapEntity {
+	old := s.Remove(entity.Id)
+	s.entities[entity.Id] = entity
+	key := cellOf(entity.X, entity.Y)
+	cell, ok := s.cells[key]
+	if !ok {
+		cell = make(map[string]*pb.MapEntity)
+		s.cells[key] = cell
+	}
+	cell[entity.Id] = entity
+	return old
+}
+
+// Remove 删除实体，返回被删除的实体（不存在时为 nil）
+func (s *SpatialIndex) Remove(id string) *pb.MapEntity {
+	entity, ok := s.entities[id]
+	if !ok {
+		return nil
+	}
+	delete(s.entities, id)
+	key := cellOf(entity.X, entity.Y)
+	if cell, ok := s.cells[key]; ok {
+		delete(cell, id)
+		if len(cell) == 0 {
+			delete(s.cells, key)
+		}
+	}
+	return entity
+}
+
+// Query 返回区域内的所有实体
+func (s *SpatialIndex) Query(r Rect) []*pb.MapEntity {
+	var result []*pb.MapEntity
+	forEachCell(r, func(key cellKey) {
+		for _, entity := range s.cells[key] {
+			if r.Contains(entity.X, entity.Y) {
+				result = append(result, entity)
+			}
+		}
+	})
+	return result
+}
+
+// Subscriptions 记录每个订阅者关注的地图区域，按坐标分桶以便快速找到关注某个坐标的订阅者
+type Subscriptions struct {
+	rects map[string]Rect
+	cells map[cellKey]map[string]bool
+}
+
+// NewSubscriptions 创建订阅索引
+func NewSubscriptions() *Subscriptions {
+	return &Subscriptions{
+		rects: make(map[string]Rect),
+		cells: make(map[cellKey]map[string]bool),
+	}
+}
+
+// Subscribe 设置订阅者关注的区域，替换之前的订阅
+func (s *Subscriptions) Subscribe(subscriberID string, r Rect) {
+	s.Unsubscribe(subscriberID)
+	s.rects[subscriberID] = r
+	forEachCell(r, func(key cellKey) {
+		cell, ok := s.cells[key]
+		if !ok {
+			cell = make(map[string]bool)
+			s.cells[key] = cell
+		}
+		cell[subscriberID] = true
+	})
+}
+
+// Unsubscribe 取消订阅，订阅者不存在时返回 false
+func (s *Subscriptions) Unsubscribe(subscriberID string) bool {
+	r, ok := s.rects[subscriberID]
+	if !ok {
+		return false
+	}
+	delete(s.rects, subscriberID)
+	forEachCell(r, func(key cellKey) {
+		if cell, ok := s.cells[key]; ok {
+			delete(cell, subscriberID)
+			if len(cell) == 0 {
+				delete(s.cells, key)
+			}
+		}
+	})
+	return true
+}
+
+// Get 返回订阅者关注的区域
+func (s *Subscriptions) Get(subscriberID string) (Rect, bool) {
+	r, ok := s.rects[subscriberID]
+	return r, ok
+}
+
+// Watchers 返回关注该坐标的订阅者
+func (s *Subscriptions) Watchers(x, y int32) []string {
+	var result []string
+	for subscriberID := range s.cells[cellOf(x, y)] {
+		if s.rects[subscriberID].Contains(x, y) {
+			result = append(result, subscriberID)
+		}
+	}
+	return result
+}
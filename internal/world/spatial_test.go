@@ -0,0 +1,131 @@
+package world
+
+import (
+	"sort"
+	"testing"
+
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+func queryIDs(s *SpatialIndex, r Rect) []string {
+	var ids []string
+	for _, entity := range s.Query(r) {
+		ids = append(ids, entity.Id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSpatialIndexQuery(t *testing.T) {
+	s := NewSpatialIndex()
+	for _, entity := range []*pb.MapEntity{
+		{Id: "origin", X: 0, Y: 0},
+		{Id: "cell_edge", X: spatialCellSize - 1, Y: spatialCellSize - 1},
+		{Id: "next_cell", X: spatialCellSize, Y: spatialCellSize},
+		{Id: "negative", X: -1, Y: -1},
+		{Id: "far_negative", X: -spatialCellSize - 1, Y: 3},
+		{Id: "far", X: 100, Y: 200},
+	} {
+		s.Upsert(entity)
+	}
+
+	tests := []struct {
+		name string
+		rect Rect
+		want []string
+	}{
+		{"single point", Rect{0, 0, 0, 0}, []string{"origin"}},
+		{"whole first cell", Rect{0, 0, spatialCellSize - 1, spatialCellSize - 1}, []string{"cell_edge", "origin"}},
+		{"across cell boundary", Rect{spatialCellSize - 1, spatialCellSize - 1, spatialCellSize, spatialCellSize}, []string{"cell_edge", "next_cell"}},
+		{"negative coordinates", Rect{-spatialCellSize - 1, -1, -1, 3}, []string{"far_negative", "negative"}},
+		{"around origin", Rect{-1, -1, 1, 1}, []string{"negative", "origin"}},
+		{"inside cell but outside rect", Rect{1, 1, spatialCellSize - 2, spatialCellSize - 2}, nil},
+		{"empty area", Rect{50, 50, 60, 60}, nil},
+		{"closed bounds", Rect{100, 200, 100, 200}, []string{"far"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryIDs(s, tt.rect); !equalIDs(got, tt.want) {
+				t.Errorf("Query(%+v) = %v, want %v", tt.rect, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpatialIndexUpsertAndRemove(t *testing.T) {
+	s := NewSpatialIndex()
+	if old := s.Upsert(&pb.MapEntity{Id: "army", X: 1, Y: 1}); old != nil {
+		t.Fatalf("Upsert of a new entity returned %v", old)
+	}
+
+	// 移动到其他分桶后，原位置不再能查到
+	old := s.Upsert(&pb.MapEntity{Id: "army", X: 40, Y: 40})
+	if old == nil || old.X != 1 || old.Y != 1 {
+		t.Fatalf("Upsert returned %v, want the previous position", old)
+	}
+	if got := queryIDs(s, Rect{0, 0, 10, 10}); got != nil {
+		t.Errorf("old position still returns %v", got)
+	}
+	if got := queryIDs(s, Rect{40, 40, 40, 40}); !equalIDs(got, []string{"army"}) {
+		t.Errorf("new position returns %v", got)
+	}
+
+	if removed := s.Remove("army"); removed == nil || removed.Id != "army" {
+		t.Fatalf("Remove returned %v", removed)
+	}
+	if s.Get("army") != nil || len(s.cells) != 0 {
+		t.Errorf("entity or its cell left behind after Remove")
+	}
+	if removed := s.Remove("army"); removed != nil {
+		t.Errorf("second Remove returned %v", removed)
+	}
+}
+
+func TestSubscriptionsWatchers(t *testing.T) {
+	s := NewSubscriptions()
+	s.Subscribe("a", Rect{0, 0, 10, 10})
+	s.Subscribe("b", Rect{-20, -20, 0, 0})
+	s.Subscribe("c", Rect{100, 100, 110, 110})
+
+	tests := []struct {
+		name string
+		x, y int32
+		want []string
+	}{
+		{"shared corner", 0, 0, []string{"a", "b"}},
+		{"only a", 5, 5, []string{"a"}},
+		{"only b", -20, -1, []string{"b"}},
+		{"same cell outside rect", 11, 11, nil},
+		{"nobody", 50, 50, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.Watchers(tt.x, tt.y)
+			sort.Strings(got)
+			if !equalIDs(got, tt.want) {
+				t.Errorf("Watchers(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+
+	// 新的订阅替换旧的订阅
+	s.Subscribe("a", Rect{100, 100, 100, 100})
+	if got := s.Watchers(5, 5); got != nil {
+		t.Errorf("replaced subscription still watches (5, 5): %v", got)
+	}
+	if !s.Unsubscribe("a") || s.Unsubscribe("a") {
+		t.Errorf("Unsubscribe should succeed once")
+	}
+}
@@ -0,0 +1,223 @@
+package world
+
+import (
+	"math"
+	"math/rand"
+
+	pb "github.com/cowpeatechnology/slg-game-server/proto"
+)
+
+// 地形
+const (
+	TerrainPlain    = "plain"    // 平原
+	TerrainForest   = "forest"   // 森林
+	TerrainMountain = "mountain" // 山地，不可通行
+	TerrainWater    = "water"    // 水域，不可通行
+)
+
+const (
+	DefaultWidth  = 200 // 默认地图宽度
+	DefaultHeight = 200 // 默认地图高度
+
+	MaxResourceLevel = 5  // 最高资源等级
+	noiseCellSize    = 16 // 地形噪声的网格大小，越大地形块越大
+)
+
+// Tile 地图格子
+type Tile struct {
+	Terrain       string
+	ResourceLevel int32
+	OwnerID       string // 占领者ID
+	ArmyID        string // 驻扎的部队ID
+}
+
+// Passable 格子是否可以通行和驻扎
+func (t *Tile) Passable() bool {
+	return t.Terrain != TerrainMountain && t.Terrain != TerrainWater
+}
+
+// Map 由格子组成的世界地图，按 y*width+x 存储。
+// Map 不是并发安全的，只能由 MapActor 访问
+type Map struct {
+	width  int32
+	height int32
+	tiles  []Tile
+}
+
+// NewMap 按种子生成地图，相同的尺寸和种子总是生成相同的地形
+func NewMap(width, height int32, seed int64) *Map {
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	if height <= 0 {
+		height = DefaultHeight
+	}
+	m := &Map{
+		width:  width,
+		height: height,
+		tiles:  make([]Tile, int(width)*int(height)),
+	}
+	m.generate(seed)
+	return m
+}
+
+// Width 地图宽度
+func (m *Map) Width() int32 {
+	return m.width
+}
+
+// Height 地图高度
+func (m *Map) Height() int32 {
+	return m.height
+}
+
+// InBounds 坐标是否在地图内
+func (m *Map) InBounds(x, y int32) bool {
+	return x >= 0 && y >= 0 && x < m.width && y < m.height
+}
+
+// Tile 返回坐标处的格子，坐标超出地图时返回 nil
+func (m *Map) Tile(x, y int32) *Tile {
+	if !m.InBounds(x, y) {
+		return nil
+	}
+	return &m.tiles[int(y)*int(m.width)+int(x)]
+}
+
+// SetOwner 设置格子的占领者
+func (m *Map) SetOwner(x, y int32, ownerID string) bool {
+	tile := m.Tile(x, y)
+	if tile == nil {
+		return false
+	}
+	tile.OwnerID = ownerID
+	return true
+}
+
+// SetArmy 设置驻扎在格子上的部队
+func (m *Map) SetArmy(x, y int32, armyID string) bool {
+	tile := m.Tile(x, y)
+	if tile == nil {
+		return false
+	}
+	tile.ArmyID = armyID
+	return true
+}
+
+// View 返回以 (x, y) 为中心、radius 为半径的正方形区域内的格子，按行优先排列
+func (m *Map) View(x, y, radius int32) []*pb.Tile {
+	if x+radius < 0 || y+radius < 0 || x-radius >= m.width || y-radius >= m.height {
+		return nil
+	}
+	minX, maxX := clamp(x-radius, 0, m.width-1), clamp(x+radius, 0, m.width-1)
+	minY, maxY := clamp(y-radius, 0, m.height-1), clamp(y+radius, 0, m.height-1)
+
+	tiles := make([]*pb.Tile, 0, int(maxX-minX+1)*int(maxY-minY+1))
+	for ty := minY; ty <= maxY; ty++ {
+		for tx := minX; tx <= maxX; tx++ {
+			tiles = append(tiles, m.TileProto(tx, ty))
+		}
+	}
+	return tiles
+}
+
+// TileProto 返回坐标处格子的协议结构
+func (m *Map) TileProto(x, y int32) *pb.Tile {
+	tile := m.Tile(x, y)
+	if tile == nil {
+		return nil
+	}
+	return &pb.Tile{
+		X:             x,
+		Y:             y,
+		Terrain:       tile.Terrain,
+		ResourceLevel: tile.ResourceLevel,
+		OwnerId:       tile.OwnerID,
+		ArmyId:        tile.ArmyID,
+	}
+}
+
+// generate 生成地形和资源：用插值噪声决定地形，越靠近地图中心资源等级越高
+func (m *Map) generate(seed int64) {
+	rng := rand.New(rand.NewSource(seed))
+	gridW := int(m.width)/noiseCellSize + 2
+	gridH := int(m.height)/noiseCellSize + 2
+	grid := make([]float64, gridW*gridH)
+	for i := range grid {
+		grid[i] = rng.Float64()
+	}
+
+	centerX, centerY := float64(m.width-1)/2, float64(m.height-1)/2
+	maxDist := math.Hypot(centerX, centerY)
+	for y := int32(0); y < m.height; y++ {
+		for x := int32(0); x < m.width; x++ {
+			tile := m.Tile(x, y)
+			elevation := noiseAt(grid, gridW, float64(x)/noiseCellSize, float64(y)/noiseCellSize)
+			tile.Terrain = terrainOf(elevation)
+			if !tile.Passable() {
+				continue
+			}
+
+			// 中心区域资源最丰富，叠加少量随机波动
+			dist := math.Hypot(float64(x)-centerX, float64(y)-centerY) / maxDist
+			level := int32(math.Round((1-dist)*(MaxResourceLevel-1) + rng.Float64()))
+			tile.ResourceLevel = clamp(level, 1, MaxResourceLevel)
+		}
+	}
+}
+
+// noiseAt 对噪声网格做双线性插值
+func noiseAt(grid []float64, gridW int, fx, fy float64) float64 {
+	x0, y0 := int(fx), int(fy)
+	tx, ty := smooth(fx-float64(x0)), smooth(fy-float64(y0))
+	at := func(x, y int) float64 { return grid[y*gridW+x] }
+	top := at(x0, y0)*(1-tx) + at(x0+1, y0)*tx
+	bottom := at(x0, y0+1)*(1-tx) + at(x0+1, y0+1)*tx
+	return top*(1-ty) + bottom*ty
+}
+
+func smooth(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// terrainOf 按高度划分地形
+func terrainOf(elevation float64) string {
+	switch {
+	case elevation < 0.22:
+		return TerrainWater
+	case elevation < 0.6:
+		return TerrainPlain
+	case elevation < 0.8:
+		return TerrainForest
+	default:
+		return TerrainMountain
+	}
+}
+
+func clamp(v, min, max int32) int32 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// Distance 两个坐标之间的直线距离
+func Distance(x1, y1, x2, y2 int32) float64 {
+	return math.Hypot(float64(x2-x1), float64(y2-y1))
+}
+
+// Interpolate 按行程进度（0~1）计算两个坐标之间的位置
+func Interpolate(x1, y1, x2, y2 int32, progress float64) (int32, int32) {
+	if progress <= 0 {
+		return x1, y1
+	}
+	if progress >= 1 {
+		return x2, y2
+	}
+	x := float64(x1) + float64(x2-x1)*progress
+	y := float64(y1) + float64(y2-y1)*progress
+	return int32(math.Round(x)), int32(math.Round(y))
+}
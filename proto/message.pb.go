@@ -21,12 +21,219 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// 玩家状态
+type PlayerStatus int32
+
+const (
+	PlayerStatus_PLAYER_STATUS_ACTIVE     PlayerStatus = 0 // 正常
+	PlayerStatus_PLAYER_STATUS_RECOVERING PlayerStatus = 1 // 战败恢复中
+)
+
+// Enum value maps for PlayerStatus.
+var (
+	PlayerStatus_name = map[int32]string{
+		0: "PLAYER_STATUS_ACTIVE",
+		1: "PLAYER_STATUS_RECOVERING",
+	}
+	PlayerStatus_value = map[string]int32{
+		"PLAYER_STATUS_ACTIVE":     0,
+		"PLAYER_STATUS_RECOVERING": 1,
+	}
+)
+
+func (x PlayerStatus) Enum() *PlayerStatus {
+	p := new(PlayerStatus)
+	*p = x
+	return p
+}
+
+func (x PlayerStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PlayerStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_message_proto_enumTypes[0].Descriptor()
+}
+
+func (PlayerStatus) Type() protoreflect.EnumType {
+	return &file_proto_message_proto_enumTypes[0]
+}
+
+func (x PlayerStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PlayerStatus.Descriptor instead.
+func (PlayerStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{0}
+}
+
+// 战斗状态
+type BattleStatus int32
+
+const (
+	BattleStatus_BATTLE_STATUS_PENDING  BattleStatus = 0 // 已受理，等待结算
+	BattleStatus_BATTLE_STATUS_RESOLVED BattleStatus = 1 // 已结算，等待 GameActor 应用结果
+	BattleStatus_BATTLE_STATUS_SETTLED  BattleStatus = 2 // 结果已应用到双方玩家数据
+	BattleStatus_BATTLE_STATUS_TIMEOUT  BattleStatus = 3 // 超时未完成
+)
+
+// Enum value maps for BattleStatus.
+var (
+	BattleStatus_name = map[int32]string{
+		0: "BATTLE_STATUS_PENDING",
+		1: "BATTLE_STATUS_RESOLVED",
+		2: "BATTLE_STATUS_SETTLED",
+		3: "BATTLE_STATUS_TIMEOUT",
+	}
+	BattleStatus_value = map[string]int32{
+		"BATTLE_STATUS_PENDING":  0,
+		"BATTLE_STATUS_RESOLVED": 1,
+		"BATTLE_STATUS_SETTLED":  2,
+		"BATTLE_STATUS_TIMEOUT":  3,
+	}
+)
+
+func (x BattleStatus) Enum() *BattleStatus {
+	p := new(BattleStatus)
+	*p = x
+	return p
+}
+
+func (x BattleStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BattleStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_message_proto_enumTypes[1].Descriptor()
+}
+
+func (BattleStatus) Type() protoreflect.EnumType {
+	return &file_proto_message_proto_enumTypes[1]
+}
+
+func (x BattleStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BattleStatus.Descriptor instead.
+func (BattleStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{1}
+}
+
+// 行军状态
+type MarchStatus int32
+
+const (
+	MarchStatus_MARCH_STATUS_OUTBOUND  MarchStatus = 0 // 前往目标
+	MarchStatus_MARCH_STATUS_FIGHTING  MarchStatus = 1 // 已到达，等待战斗结算
+	MarchStatus_MARCH_STATUS_RETURNING MarchStatus = 2 // 返回主城
+	MarchStatus_MARCH_STATUS_GATHERING MarchStatus = 3 // 在资源点采集
+	MarchStatus_MARCH_STATUS_STATIONED MarchStatus = 4 // 驻守在盟友主城
+)
+
+// Enum value maps for MarchStatus.
+var (
+	MarchStatus_name = map[int32]string{
+		0: "MARCH_STATUS_OUTBOUND",
+		1: "MARCH_STATUS_FIGHTING",
+		2: "MARCH_STATUS_RETURNING",
+		3: "MARCH_STATUS_GATHERING",
+		4: "MARCH_STATUS_STATIONED",
+	}
+	MarchStatus_value = map[string]int32{
+		"MARCH_STATUS_OUTBOUND":  0,
+		"MARCH_STATUS_FIGHTING":  1,
+		"MARCH_STATUS_RETURNING": 2,
+		"MARCH_STATUS_GATHERING": 3,
+		"MARCH_STATUS_STATIONED": 4,
+	}
+)
+
+func (x MarchStatus) Enum() *MarchStatus {
+	p := new(MarchStatus)
+	*p = x
+	return p
+}
+
+func (x MarchStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MarchStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_message_proto_enumTypes[2].Descriptor()
+}
+
+func (MarchStatus) Type() protoreflect.EnumType {
+	return &file_proto_message_proto_enumTypes[2]
+}
+
+func (x MarchStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MarchStatus.Descriptor instead.
+func (MarchStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{2}
+}
+
+// 联盟职位
+type AllianceRank int32
+
+const (
+	AllianceRank_ALLIANCE_RANK_MEMBER  AllianceRank = 0 // 成员
+	AllianceRank_ALLIANCE_RANK_OFFICER AllianceRank = 1 // 官员，可以踢出成员、发布公告
+	AllianceRank_ALLIANCE_RANK_LEADER  AllianceRank = 2 // 盟主
+)
+
+// Enum value maps for AllianceRank.
+var (
+	AllianceRank_name = map[int32]string{
+		0: "ALLIANCE_RANK_MEMBER",
+		1: "ALLIANCE_RANK_OFFICER",
+		2: "ALLIANCE_RANK_LEADER",
+	}
+	AllianceRank_value = map[string]int32{
+		"ALLIANCE_RANK_MEMBER":  0,
+		"ALLIANCE_RANK_OFFICER": 1,
+		"ALLIANCE_RANK_LEADER":  2,
+	}
+)
+
+func (x AllianceRank) Enum() *AllianceRank {
+	p := new(AllianceRank)
+	*p = x
+	return p
+}
+
+func (x AllianceRank) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AllianceRank) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_message_proto_enumTypes[3].Descriptor()
+}
+
+func (AllianceRank) Type() protoreflect.EnumType {
+	return &file_proto_message_proto_enumTypes[3]
+}
+
+func (x AllianceRank) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AllianceRank.Descriptor instead.
+func (AllianceRank) EnumDescriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{3}
+}
+
 // 基础消息结构
 type GameMessage struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`       // 消息类型
-	Payload       []byte                 `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"` // 消息内容
-	Id            string                 `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`           // 用于消息路由，玩家ID
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`                            // 消息类型
+	Payload       []byte                 `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`                      // 消息内容
+	Id            string                 `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`                                // 用于消息路由，玩家ID
+	SessionId     string                 `protobuf:"bytes,4,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"` // 客户端连接ID，由 GatewayActor 填写，响应中原样带回以便回复到发起请求的连接
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -82,34 +289,3346 @@ func (x *GameMessage) GetId() string {
 	return ""
 }
 
+func (x *GameMessage) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
 // 玩家数据
 type PlayerData struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Id                 string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                                                                           // 玩家ID
+	Name               string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`                                                                                       // 玩家名称
+	Level              int32                  `protobuf:"varint,3,opt,name=level,proto3" json:"level,omitempty"`                                                                                    // 等级
+	Hp                 int32                  `protobuf:"varint,4,opt,name=hp,proto3" json:"hp,omitempty"`                                                                                          // 生命值
+	Attack             int32                  `protobuf:"varint,5,opt,name=attack,proto3" json:"attack,omitempty"`                                                                                  // 攻击力
+	Defense            int32                  `protobuf:"varint,6,opt,name=defense,proto3" json:"defense,omitempty"`                                                                                // 防御力
+	MaxHp              int32                  `protobuf:"varint,7,opt,name=max_hp,json=maxHp,proto3" json:"max_hp,omitempty"`                                                                       // 生命值上限
+	Experience         int64                  `protobuf:"varint,8,opt,name=experience,proto3" json:"experience,omitempty"`                                                                          // 当前等级已获得的经验
+	Status             PlayerStatus           `protobuf:"varint,9,opt,name=status,proto3,enum=pb.PlayerStatus" json:"status,omitempty"`                                                             // 玩家状态
+	RecoverAt          int64                  `protobuf:"varint,10,opt,name=recover_at,json=recoverAt,proto3" json:"recover_at,omitempty"`                                                          // 战败恢复完成时间（Unix秒）
+	HpUpdatedAt        int64                  `protobuf:"varint,11,opt,name=hp_updated_at,json=hpUpdatedAt,proto3" json:"hp_updated_at,omitempty"`                                                  // 生命值上次结算时间（Unix秒），用于计算自然恢复
+	ProtectedUntil     int64                  `protobuf:"varint,12,opt,name=protected_until,json=protectedUntil,proto3" json:"protected_until,omitempty"`                                           // 保护期结束时间（Unix秒），保护期内不会被攻击
+	Troops             map[string]int32       `protobuf:"bytes,13,rep,name=troops,proto3" json:"troops,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`       // 城内驻守的部队，key为兵种（infantry/cavalry/archer/siege）
+	Heroes             []*Hero                `protobuf:"bytes,14,rep,name=heroes,proto3" json:"heroes,omitempty"`                                                                                  // 拥有的英雄
+	Home               *Coord                 `protobuf:"bytes,15,opt,name=home,proto3" json:"home,omitempty"`                                                                                      // 主城坐标，由 MapActor 分配，分配前为空
+	City               *City                  `protobuf:"bytes,16,opt,name=city,proto3" json:"city,omitempty"`                                                                                      // 主城建筑和建造队列
+	Resources          map[string]int64       `protobuf:"bytes,17,rep,name=resources,proto3" json:"resources,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 资源数量，key为资源类型（food/wood/stone/iron/gold）
+	ResourcesUpdatedAt int64                  `protobuf:"varint,18,opt,name=resources_updated_at,json=resourcesUpdatedAt,proto3" json:"resources_updated_at,omitempty"`                             // 资源上次结算时间（Unix毫秒），产出按此时间惰性计算
+	AllianceId         string                 `protobuf:"bytes,19,opt,name=alliance_id,json=allianceId,proto3" json:"alliance_id,omitempty"`                                                        // 所属联盟ID，由 AllianceActor 通知 GameActor
+	Inventory          []*Equipment           `protobuf:"bytes,20,rep,name=inventory,proto3" json:"inventory,omitempty"`                                                                            // 背包中未穿戴的装备
+	Marches            []*March               `protobuf:"bytes,21,rep,name=marches,proto3" json:"marches,omitempty"`                                                                                // 保存时尚未返回的行军，只用于持久化，服务重启后部队和携带的资源直接返回主城
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *PlayerData) Reset() {
+	*x = PlayerData{}
+	mi := &file_proto_message_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayerData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayerData) ProtoMessage() {}
+
+func (x *PlayerData) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayerData.ProtoReflect.Descriptor instead.
+func (*PlayerData) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PlayerData) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PlayerData) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PlayerData) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *PlayerData) GetHp() int32 {
+	if x != nil {
+		return x.Hp
+	}
+	return 0
+}
+
+func (x *PlayerData) GetAttack() int32 {
+	if x != nil {
+		return x.Attack
+	}
+	return 0
+}
+
+func (x *PlayerData) GetDefense() int32 {
+	if x != nil {
+		return x.Defense
+	}
+	return 0
+}
+
+func (x *PlayerData) GetMaxHp() int32 {
+	if x != nil {
+		return x.MaxHp
+	}
+	return 0
+}
+
+func (x *PlayerData) GetExperience() int64 {
+	if x != nil {
+		return x.Experience
+	}
+	return 0
+}
+
+func (x *PlayerData) GetStatus() PlayerStatus {
+	if x != nil {
+		return x.Status
+	}
+	return PlayerStatus_PLAYER_STATUS_ACTIVE
+}
+
+func (x *PlayerData) GetRecoverAt() int64 {
+	if x != nil {
+		return x.RecoverAt
+	}
+	return 0
+}
+
+func (x *PlayerData) GetHpUpdatedAt() int64 {
+	if x != nil {
+		return x.HpUpdatedAt
+	}
+	return 0
+}
+
+func (x *PlayerData) GetProtectedUntil() int64 {
+	if x != nil {
+		return x.ProtectedUntil
+	}
+	return 0
+}
+
+func (x *PlayerData) GetTroops() map[string]int32 {
+	if x != nil {
+		return x.Troops
+	}
+	return nil
+}
+
+func (x *PlayerData) GetHeroes() []*Hero {
+	if x != nil {
+		return x.Heroes
+	}
+	return nil
+}
+
+func (x *PlayerData) GetHome() *Coord {
+	if x != nil {
+		return x.Home
+	}
+	return nil
+}
+
+func (x *PlayerData) GetCity() *City {
+	if x != nil {
+		return x.City
+	}
+	return nil
+}
+
+func (x *PlayerData) GetResources() map[string]int64 {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *PlayerData) GetResourcesUpdatedAt() int64 {
+	if x != nil {
+		return x.ResourcesUpdatedAt
+	}
+	return 0
+}
+
+func (x *PlayerData) GetAllianceId() string {
+	if x != nil {
+		return x.AllianceId
+	}
+	return ""
+}
+
+func (x *PlayerData) GetInventory() []*Equipment {
+	if x != nil {
+		return x.Inventory
+	}
+	return nil
+}
+
+func (x *PlayerData) GetMarches() []*March {
+	if x != nil {
+		return x.Marches
+	}
+	return nil
+}
+
+// 地图坐标
+type Coord struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`            // 玩家ID
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`        // 玩家名称
-	Level         int32                  `protobuf:"varint,3,opt,name=level,proto3" json:"level,omitempty"`     // 等级
-	Hp            int32                  `protobuf:"varint,4,opt,name=hp,proto3" json:"hp,omitempty"`           // 生命值
-	Attack        int32                  `protobuf:"varint,5,opt,name=attack,proto3" json:"attack,omitempty"`   // 攻击力
-	Defense       int32                  `protobuf:"varint,6,opt,name=defense,proto3" json:"defense,omitempty"` // 防御力
+	X             int32                  `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int32                  `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PlayerData) Reset() {
-	*x = PlayerData{}
-	mi := &file_proto_message_proto_msgTypes[1]
+func (x *Coord) Reset() {
+	*x = Coord{}
+	mi := &file_proto_message_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Coord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Coord) ProtoMessage() {}
+
+func (x *Coord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Coord.ProtoReflect.Descriptor instead.
+func (*Coord) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Coord) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Coord) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+// 错误响应
+type ErrorResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`                                  // 错误码，供客户端区分错误类型
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`                            // 错误描述
+	RequestType   string                 `protobuf:"bytes,3,opt,name=request_type,json=requestType,proto3" json:"request_type,omitempty"` // 出错的请求类型
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErrorResponse) Reset() {
+	*x = ErrorResponse{}
+	mi := &file_proto_message_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErrorResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorResponse) ProtoMessage() {}
+
+func (x *ErrorResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorResponse.ProtoReflect.Descriptor instead.
+func (*ErrorResponse) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ErrorResponse) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *ErrorResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ErrorResponse) GetRequestType() string {
+	if x != nil {
+		return x.RequestType
+	}
+	return ""
+}
+
+// 装备
+type Equipment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`            // 装备ID
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`        // 装备名称
+	Slot          string                 `protobuf:"bytes,3,opt,name=slot,proto3" json:"slot,omitempty"`        // 装备部位：weapon/armor/mount/accessory
+	Attack        int32                  `protobuf:"varint,4,opt,name=attack,proto3" json:"attack,omitempty"`   // 攻击加成
+	Defense       int32                  `protobuf:"varint,5,opt,name=defense,proto3" json:"defense,omitempty"` // 防御加成
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Equipment) Reset() {
+	*x = Equipment{}
+	mi := &file_proto_message_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Equipment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Equipment) ProtoMessage() {}
+
+func (x *Equipment) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Equipment.ProtoReflect.Descriptor instead.
+func (*Equipment) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Equipment) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Equipment) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Equipment) GetSlot() string {
+	if x != nil {
+		return x.Slot
+	}
+	return ""
+}
+
+func (x *Equipment) GetAttack() int32 {
+	if x != nil {
+		return x.Attack
+	}
+	return 0
+}
+
+func (x *Equipment) GetDefense() int32 {
+	if x != nil {
+		return x.Defense
+	}
+	return 0
+}
+
+// 英雄，作为统帅为部队提供属性加成和技能
+type Hero struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                                                                                         // 英雄ID，玩家内唯一
+	TemplateId    string                 `protobuf:"bytes,2,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`                                                       // 英雄模板ID
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`                                                                                     // 英雄名称
+	Rarity        string                 `protobuf:"bytes,4,opt,name=rarity,proto3" json:"rarity,omitempty"`                                                                                 // 稀有度：common/rare/epic/legendary
+	Level         int32                  `protobuf:"varint,5,opt,name=level,proto3" json:"level,omitempty"`                                                                                  // 等级
+	Experience    int64                  `protobuf:"varint,6,opt,name=experience,proto3" json:"experience,omitempty"`                                                                        // 当前等级已获得的经验
+	Equipment     map[string]*Equipment  `protobuf:"bytes,7,rep,name=equipment,proto3" json:"equipment,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 已穿戴的装备，key为装备部位
+	Skills        []string               `protobuf:"bytes,8,rep,name=skills,proto3" json:"skills,omitempty"`                                                                                 // 技能ID，主动和被动技能见服务端技能表
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hero) Reset() {
+	*x = Hero{}
+	mi := &file_proto_message_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hero) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hero) ProtoMessage() {}
+
+func (x *Hero) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hero.ProtoReflect.Descriptor instead.
+func (*Hero) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Hero) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Hero) GetTemplateId() string {
+	if x != nil {
+		return x.TemplateId
+	}
+	return ""
+}
+
+func (x *Hero) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Hero) GetRarity() string {
+	if x != nil {
+		return x.Rarity
+	}
+	return ""
+}
+
+func (x *Hero) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *Hero) GetExperience() int64 {
+	if x != nil {
+		return x.Experience
+	}
+	return 0
+}
+
+func (x *Hero) GetEquipment() map[string]*Equipment {
+	if x != nil {
+		return x.Equipment
+	}
+	return nil
+}
+
+func (x *Hero) GetSkills() []string {
+	if x != nil {
+		return x.Skills
+	}
+	return nil
+}
+
+// 玩家列表
+type PlayerList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Players       []*PlayerData          `protobuf:"bytes,1,rep,name=players,proto3" json:"players,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlayerList) Reset() {
+	*x = PlayerList{}
+	mi := &file_proto_message_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayerList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayerList) ProtoMessage() {}
+
+func (x *PlayerList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayerList.ProtoReflect.Descriptor instead.
+func (*PlayerList) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PlayerList) GetPlayers() []*PlayerData {
+	if x != nil {
+		return x.Players
+	}
+	return nil
+}
+
+// 登录请求
+type LoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginRequest) Reset() {
+	*x = LoginRequest{}
+	mi := &file_proto_message_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginRequest) ProtoMessage() {}
+
+func (x *LoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginRequest.ProtoReflect.Descriptor instead.
+func (*LoginRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LoginRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+// 登录响应
+type LoginResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	PlayerInfo    *PlayerData            `protobuf:"bytes,3,opt,name=player_info,json=playerInfo,proto3" json:"player_info,omitempty"`
+	SessionToken  string                 `protobuf:"bytes,4,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"` // 登录成功时签发的会话令牌，断线重连时通过 resume 出示
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginResponse) Reset() {
+	*x = LoginResponse{}
+	mi := &file_proto_message_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginResponse) ProtoMessage() {}
+
+func (x *LoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginResponse.ProtoReflect.Descriptor instead.
+func (*LoginResponse) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LoginResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *LoginResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *LoginResponse) GetPlayerInfo() *PlayerData {
+	if x != nil {
+		return x.PlayerInfo
+	}
+	return nil
+}
+
+func (x *LoginResponse) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+// 断线重连请求，使用登录时签发的会话令牌恢复玩家身份
+type ResumeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionToken  string                 `protobuf:"bytes,1,opt,name=session_token,json=sessionToken,proto3" json:"session_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeRequest) Reset() {
+	*x = ResumeRequest{}
+	mi := &file_proto_message_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeRequest) ProtoMessage() {}
+
+func (x *ResumeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeRequest.ProtoReflect.Descriptor instead.
+func (*ResumeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ResumeRequest) GetSessionToken() string {
+	if x != nil {
+		return x.SessionToken
+	}
+	return ""
+}
+
+// 玩家账号，密码只保存加盐哈希
+type Account struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Username       string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	PlayerId       string                 `protobuf:"bytes,2,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`                    // 账号对应的玩家ID
+	Salt           []byte                 `protobuf:"bytes,3,opt,name=salt,proto3" json:"salt,omitempty"`                                            // 随机盐
+	PasswordHash   []byte                 `protobuf:"bytes,4,opt,name=password_hash,json=passwordHash,proto3" json:"password_hash,omitempty"`        // PBKDF2-HMAC-SHA256(password, salt)
+	HashIterations int32                  `protobuf:"varint,5,opt,name=hash_iterations,json=hashIterations,proto3" json:"hash_iterations,omitempty"` // 计算哈希时的迭代次数
+	CreatedAt      int64                  `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`                // 注册时间（Unix秒）
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	mi := &file_proto_message_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Account) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Account) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+func (x *Account) GetSalt() []byte {
+	if x != nil {
+		return x.Salt
+	}
+	return nil
+}
+
+func (x *Account) GetPasswordHash() []byte {
+	if x != nil {
+		return x.PasswordHash
+	}
+	return nil
+}
+
+func (x *Account) GetHashIterations() int32 {
+	if x != nil {
+		return x.HashIterations
+	}
+	return 0
+}
+
+func (x *Account) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+// 聊天消息
+type ChatMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromId        string                 `protobuf:"bytes,1,opt,name=from_id,json=fromId,proto3" json:"from_id,omitempty"`
+	ToId          string                 `protobuf:"bytes,2,opt,name=to_id,json=toId,proto3" json:"to_id,omitempty"`
+	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatMessage) Reset() {
+	*x = ChatMessage{}
+	mi := &file_proto_message_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessage) ProtoMessage() {}
+
+func (x *ChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ChatMessage) GetFromId() string {
+	if x != nil {
+		return x.FromId
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetToId() string {
+	if x != nil {
+		return x.ToId
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// 战斗请求
+type BattleRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	AttackerId     string                 `protobuf:"bytes,1,opt,name=attacker_id,json=attackerId,proto3" json:"attacker_id,omitempty"`                                                                                  // 攻击者ID
+	DefenderId     string                 `protobuf:"bytes,2,opt,name=defender_id,json=defenderId,proto3" json:"defender_id,omitempty"`                                                                                  // 防守者ID
+	Army           map[string]int32       `protobuf:"bytes,3,rep,name=army,proto3" json:"army,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`                                     // 出征部队，key为兵种；为空时派出全部部队
+	HeroId         string                 `protobuf:"bytes,4,opt,name=hero_id,json=heroId,proto3" json:"hero_id,omitempty"`                                                                                              // 统帅英雄ID，可为空
+	DefenderHeroId string                 `protobuf:"bytes,5,opt,name=defender_hero_id,json=defenderHeroId,proto3" json:"defender_hero_id,omitempty"`                                                                    // 防守统帅英雄ID，由 GameActor 在行军到达时设置
+	DefenderArmy   map[string]int32       `protobuf:"bytes,6,rep,name=defender_army,json=defenderArmy,proto3" json:"defender_army,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 防守部队，为空时使用防守方的驻守部队
+	NodeId         string                 `protobuf:"bytes,7,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`                                                                                              // 争夺资源点时的资源点ID
+	Reinforcements []*Reinforcement       `protobuf:"bytes,8,rep,name=reinforcements,proto3" json:"reinforcements,omitempty"`                                                                                            // 驻守在防守方主城的盟友援军，由 GameActor 在行军到达时设置
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BattleRequest) Reset() {
+	*x = BattleRequest{}
+	mi := &file_proto_message_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BattleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BattleRequest) ProtoMessage() {}
+
+func (x *BattleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BattleRequest.ProtoReflect.Descriptor instead.
+func (*BattleRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *BattleRequest) GetAttackerId() string {
+	if x != nil {
+		return x.AttackerId
+	}
+	return ""
+}
+
+func (x *BattleRequest) GetDefenderId() string {
+	if x != nil {
+		return x.DefenderId
+	}
+	return ""
+}
+
+func (x *BattleRequest) GetArmy() map[string]int32 {
+	if x != nil {
+		return x.Army
+	}
+	return nil
+}
+
+func (x *BattleRequest) GetHeroId() string {
+	if x != nil {
+		return x.HeroId
+	}
+	return ""
+}
+
+func (x *BattleRequest) GetDefenderHeroId() string {
+	if x != nil {
+		return x.DefenderHeroId
+	}
+	return ""
+}
+
+func (x *BattleRequest) GetDefenderArmy() map[string]int32 {
+	if x != nil {
+		return x.DefenderArmy
+	}
+	return nil
+}
+
+func (x *BattleRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *BattleRequest) GetReinforcements() []*Reinforcement {
+	if x != nil {
+		return x.Reinforcements
+	}
+	return nil
+}
+
+// 驻守在盟友主城的援军，守城时加入防守方
+type Reinforcement struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MarchId       string                 `protobuf:"bytes,1,opt,name=march_id,json=marchId,proto3" json:"march_id,omitempty"`
+	OwnerId       string                 `protobuf:"bytes,2,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"`                                                       // 援军所属玩家ID
+	Army          map[string]int32       `protobuf:"bytes,3,rep,name=army,proto3" json:"army,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 援军部队
+	Attack        int32                  `protobuf:"varint,4,opt,name=attack,proto3" json:"attack,omitempty"`                                                                       // 所属玩家的攻击力，作为援军部队的加成
+	Defense       int32                  `protobuf:"varint,5,opt,name=defense,proto3" json:"defense,omitempty"`                                                                     // 所属玩家的防御力，作为援军部队的加成
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reinforcement) Reset() {
+	*x = Reinforcement{}
+	mi := &file_proto_message_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reinforcement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reinforcement) ProtoMessage() {}
+
+func (x *Reinforcement) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reinforcement.ProtoReflect.Descriptor instead.
+func (*Reinforcement) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Reinforcement) GetMarchId() string {
+	if x != nil {
+		return x.MarchId
+	}
+	return ""
+}
+
+func (x *Reinforcement) GetOwnerId() string {
+	if x != nil {
+		return x.OwnerId
+	}
+	return ""
+}
+
+func (x *Reinforcement) GetArmy() map[string]int32 {
+	if x != nil {
+		return x.Army
+	}
+	return nil
+}
+
+func (x *Reinforcement) GetAttack() int32 {
+	if x != nil {
+		return x.Attack
+	}
+	return 0
+}
+
+func (x *Reinforcement) GetDefense() int32 {
+	if x != nil {
+		return x.Defense
+	}
+	return 0
+}
+
+// 战斗结算的输入，相同输入总能得到相同结果
+type BattleInput struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Attacker       *PlayerData            `protobuf:"bytes,1,opt,name=attacker,proto3" json:"attacker,omitempty"`                                                                                                        // 进攻方属性快照
+	Defender       *PlayerData            `protobuf:"bytes,2,opt,name=defender,proto3" json:"defender,omitempty"`                                                                                                        // 防守方属性快照，驻守部队作为防守部队
+	AttackerArmy   map[string]int32       `protobuf:"bytes,3,rep,name=attacker_army,json=attackerArmy,proto3" json:"attacker_army,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 进攻方出征部队
+	Seed           int64                  `protobuf:"varint,4,opt,name=seed,proto3" json:"seed,omitempty"`                                                                                                               // 随机种子
+	AttackerHero   *Hero                  `protobuf:"bytes,5,opt,name=attacker_hero,json=attackerHero,proto3" json:"attacker_hero,omitempty"`                                                                            // 进攻方统帅，可为空
+	DefenderHero   *Hero                  `protobuf:"bytes,6,opt,name=defender_hero,json=defenderHero,proto3" json:"defender_hero,omitempty"`                                                                            // 防守方统帅，可为空
+	DefenderArmy   map[string]int32       `protobuf:"bytes,7,rep,name=defender_army,json=defenderArmy,proto3" json:"defender_army,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 防守部队，为空时使用防守方快照中的驻守部队
+	Reinforcements []*Reinforcement       `protobuf:"bytes,8,rep,name=reinforcements,proto3" json:"reinforcements,omitempty"`                                                                                            // 加入防守方的盟友援军
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BattleInput) Reset() {
+	*x = BattleInput{}
+	mi := &file_proto_message_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BattleInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BattleInput) ProtoMessage() {}
+
+func (x *BattleInput) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BattleInput.ProtoReflect.Descriptor instead.
+func (*BattleInput) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BattleInput) GetAttacker() *PlayerData {
+	if x != nil {
+		return x.Attacker
+	}
+	return nil
+}
+
+func (x *BattleInput) GetDefender() *PlayerData {
+	if x != nil {
+		return x.Defender
+	}
+	return nil
+}
+
+func (x *BattleInput) GetAttackerArmy() map[string]int32 {
+	if x != nil {
+		return x.AttackerArmy
+	}
+	return nil
+}
+
+func (x *BattleInput) GetSeed() int64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+func (x *BattleInput) GetAttackerHero() *Hero {
+	if x != nil {
+		return x.AttackerHero
+	}
+	return nil
+}
+
+func (x *BattleInput) GetDefenderHero() *Hero {
+	if x != nil {
+		return x.DefenderHero
+	}
+	return nil
+}
+
+func (x *BattleInput) GetDefenderArmy() map[string]int32 {
+	if x != nil {
+		return x.DefenderArmy
+	}
+	return nil
+}
+
+func (x *BattleInput) GetReinforcements() []*Reinforcement {
+	if x != nil {
+		return x.Reinforcements
+	}
+	return nil
+}
+
+// 单个兵种部队的战斗统计
+type BattleStack struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OwnerId       string                 `protobuf:"bytes,1,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"`       // 部队所属玩家ID
+	TroopType     string                 `protobuf:"bytes,2,opt,name=troop_type,json=troopType,proto3" json:"troop_type,omitempty"` // 兵种
+	Initial       int32                  `protobuf:"varint,3,opt,name=initial,proto3" json:"initial,omitempty"`                     // 战斗开始时数量
+	Lost          int32                  `protobuf:"varint,4,opt,name=lost,proto3" json:"lost,omitempty"`                           // 损失数量
+	Remaining     int32                  `protobuf:"varint,5,opt,name=remaining,proto3" json:"remaining,omitempty"`                 // 剩余数量
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BattleStack) Reset() {
+	*x = BattleStack{}
+	mi := &file_proto_message_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BattleStack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BattleStack) ProtoMessage() {}
+
+func (x *BattleStack) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BattleStack.ProtoReflect.Descriptor instead.
+func (*BattleStack) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BattleStack) GetOwnerId() string {
+	if x != nil {
+		return x.OwnerId
+	}
+	return ""
+}
+
+func (x *BattleStack) GetTroopType() string {
+	if x != nil {
+		return x.TroopType
+	}
+	return ""
+}
+
+func (x *BattleStack) GetInitial() int32 {
+	if x != nil {
+		return x.Initial
+	}
+	return 0
+}
+
+func (x *BattleStack) GetLost() int32 {
+	if x != nil {
+		return x.Lost
+	}
+	return 0
+}
+
+func (x *BattleStack) GetRemaining() int32 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+// 战斗中的一次行动
+type BattleAction struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ActorId         string                 `protobuf:"bytes,1,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`                           // 行动方ID
+	TargetId        string                 `protobuf:"bytes,2,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`                        // 目标ID
+	Damage          int32                  `protobuf:"varint,3,opt,name=damage,proto3" json:"damage,omitempty"`                                           // 造成的伤害
+	TargetHp        int32                  `protobuf:"varint,4,opt,name=target_hp,json=targetHp,proto3" json:"target_hp,omitempty"`                       // 目标部队剩余生命值
+	Modifiers       []string               `protobuf:"bytes,5,rep,name=modifiers,proto3" json:"modifiers,omitempty"`                                      // 触发的效果，如 critical、counter、countered
+	TroopType       string                 `protobuf:"bytes,6,opt,name=troop_type,json=troopType,proto3" json:"troop_type,omitempty"`                     // 行动部队的兵种
+	TargetTroopType string                 `protobuf:"bytes,7,opt,name=target_troop_type,json=targetTroopType,proto3" json:"target_troop_type,omitempty"` // 目标部队的兵种
+	Kills           int32                  `protobuf:"varint,8,opt,name=kills,proto3" json:"kills,omitempty"`                                             // 消灭的目标数量
+	TargetRemaining int32                  `protobuf:"varint,9,opt,name=target_remaining,json=targetRemaining,proto3" json:"target_remaining,omitempty"`  // 目标部队剩余数量
+	SkillId         string                 `protobuf:"bytes,10,opt,name=skill_id,json=skillId,proto3" json:"skill_id,omitempty"`                          // 触发的英雄技能，普通攻击时为空
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *BattleAction) Reset() {
+	*x = BattleAction{}
+	mi := &file_proto_message_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BattleAction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BattleAction) ProtoMessage() {}
+
+func (x *BattleAction) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BattleAction.ProtoReflect.Descriptor instead.
+func (*BattleAction) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BattleAction) GetActorId() string {
+	if x != nil {
+		return x.ActorId
+	}
+	return ""
+}
+
+func (x *BattleAction) GetTargetId() string {
+	if x != nil {
+		return x.TargetId
+	}
+	return ""
+}
+
+func (x *BattleAction) GetDamage() int32 {
+	if x != nil {
+		return x.Damage
+	}
+	return 0
+}
+
+func (x *BattleAction) GetTargetHp() int32 {
+	if x != nil {
+		return x.TargetHp
+	}
+	return 0
+}
+
+func (x *BattleAction) GetModifiers() []string {
+	if x != nil {
+		return x.Modifiers
+	}
+	return nil
+}
+
+func (x *BattleAction) GetTroopType() string {
+	if x != nil {
+		return x.TroopType
+	}
+	return ""
+}
+
+func (x *BattleAction) GetTargetTroopType() string {
+	if x != nil {
+		return x.TargetTroopType
+	}
+	return ""
+}
+
+func (x *BattleAction) GetKills() int32 {
+	if x != nil {
+		return x.Kills
+	}
+	return 0
+}
+
+func (x *BattleAction) GetTargetRemaining() int32 {
+	if x != nil {
+		return x.TargetRemaining
+	}
+	return 0
+}
+
+func (x *BattleAction) GetSkillId() string {
+	if x != nil {
+		return x.SkillId
+	}
+	return ""
+}
+
+// 战斗回合记录
+type BattleRound struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Round         int32                  `protobuf:"varint,1,opt,name=round,proto3" json:"round,omitempty"`                             // 回合序号，从1开始
+	Actions       []*BattleAction        `protobuf:"bytes,2,rep,name=actions,proto3" json:"actions,omitempty"`                          // 本回合内的行动，按先后顺序
+	AttackerHp    int32                  `protobuf:"varint,3,opt,name=attacker_hp,json=attackerHp,proto3" json:"attacker_hp,omitempty"` // 回合结束时进攻方部队剩余总生命值
+	DefenderHp    int32                  `protobuf:"varint,4,opt,name=defender_hp,json=defenderHp,proto3" json:"defender_hp,omitempty"` // 回合结束时防守方部队剩余总生命值
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BattleRound) Reset() {
+	*x = BattleRound{}
+	mi := &file_proto_message_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BattleRound) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BattleRound) ProtoMessage() {}
+
+func (x *BattleRound) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BattleRound.ProtoReflect.Descriptor instead.
+func (*BattleRound) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BattleRound) GetRound() int32 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *BattleRound) GetActions() []*BattleAction {
+	if x != nil {
+		return x.Actions
+	}
+	return nil
+}
+
+func (x *BattleRound) GetAttackerHp() int32 {
+	if x != nil {
+		return x.AttackerHp
+	}
+	return 0
+}
+
+func (x *BattleRound) GetDefenderHp() int32 {
+	if x != nil {
+		return x.DefenderHp
+	}
+	return 0
+}
+
+// 战斗结果
+type BattleResult struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	WinnerId         string                 `protobuf:"bytes,1,opt,name=winner_id,json=winnerId,proto3" json:"winner_id,omitempty"`                          // 胜利者ID
+	LoserId          string                 `protobuf:"bytes,2,opt,name=loser_id,json=loserId,proto3" json:"loser_id,omitempty"`                             // 失败者ID
+	DamageDealt      int32                  `protobuf:"varint,3,opt,name=damage_dealt,json=damageDealt,proto3" json:"damage_dealt,omitempty"`                // 造成的伤害
+	Seed             int64                  `protobuf:"varint,4,opt,name=seed,proto3" json:"seed,omitempty"`                                                 // 战斗随机种子，相同输入和种子可复现战斗
+	Rounds           int32                  `protobuf:"varint,5,opt,name=rounds,proto3" json:"rounds,omitempty"`                                             // 实际进行的回合数
+	BattleId         string                 `protobuf:"bytes,6,opt,name=battle_id,json=battleId,proto3" json:"battle_id,omitempty"`                          // 战斗ID
+	AttackerId       string                 `protobuf:"bytes,7,opt,name=attacker_id,json=attackerId,proto3" json:"attacker_id,omitempty"`                    // 进攻方ID
+	DefenderId       string                 `protobuf:"bytes,8,opt,name=defender_id,json=defenderId,proto3" json:"defender_id,omitempty"`                    // 防守方ID
+	RoundReports     []*BattleRound         `protobuf:"bytes,9,rep,name=round_reports,json=roundReports,proto3" json:"round_reports,omitempty"`              // 逐回合战报
+	Timestamp        int64                  `protobuf:"varint,10,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                                      // 战斗结算时间（Unix秒）
+	AttackerHp       int32                  `protobuf:"varint,11,opt,name=attacker_hp,json=attackerHp,proto3" json:"attacker_hp,omitempty"`                  // 战斗结束时进攻方剩余生命值
+	DefenderHp       int32                  `protobuf:"varint,12,opt,name=defender_hp,json=defenderHp,proto3" json:"defender_hp,omitempty"`                  // 战斗结束时防守方剩余生命值
+	Status           BattleStatus           `protobuf:"varint,13,opt,name=status,proto3,enum=pb.BattleStatus" json:"status,omitempty"`                       // 战斗状态
+	AttackerStacks   []*BattleStack         `protobuf:"bytes,14,rep,name=attacker_stacks,json=attackerStacks,proto3" json:"attacker_stacks,omitempty"`       // 进攻方各兵种伤亡
+	DefenderStacks   []*BattleStack         `protobuf:"bytes,15,rep,name=defender_stacks,json=defenderStacks,proto3" json:"defender_stacks,omitempty"`       // 防守方各兵种伤亡
+	AttackerHeroId   string                 `protobuf:"bytes,16,opt,name=attacker_hero_id,json=attackerHeroId,proto3" json:"attacker_hero_id,omitempty"`     // 进攻方统帅英雄ID
+	DefenderHeroId   string                 `protobuf:"bytes,17,opt,name=defender_hero_id,json=defenderHeroId,proto3" json:"defender_hero_id,omitempty"`     // 防守方统帅英雄ID
+	Input            *BattleInput           `protobuf:"bytes,18,opt,name=input,proto3" json:"input,omitempty"`                                               // 战斗输入快照，用于复盘
+	NodeId           string                 `protobuf:"bytes,19,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`                               // 资源点争夺战的资源点ID
+	ReinforcementIds []string               `protobuf:"bytes,20,rep,name=reinforcement_ids,json=reinforcementIds,proto3" json:"reinforcement_ids,omitempty"` // 参与守城的援军所属玩家ID
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *BattleResult) Reset() {
+	*x = BattleResult{}
+	mi := &file_proto_message_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BattleResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BattleResult) ProtoMessage() {}
+
+func (x *BattleResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BattleResult.ProtoReflect.Descriptor instead.
+func (*BattleResult) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BattleResult) GetWinnerId() string {
+	if x != nil {
+		return x.WinnerId
+	}
+	return ""
+}
+
+func (x *BattleResult) GetLoserId() string {
+	if x != nil {
+		return x.LoserId
+	}
+	return ""
+}
+
+func (x *BattleResult) GetDamageDealt() int32 {
+	if x != nil {
+		return x.DamageDealt
+	}
+	return 0
+}
+
+func (x *BattleResult) GetSeed() int64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+func (x *BattleResult) GetRounds() int32 {
+	if x != nil {
+		return x.Rounds
+	}
+	return 0
+}
+
+func (x *BattleResult) GetBattleId() string {
+	if x != nil {
+		return x.BattleId
+	}
+	return ""
+}
+
+func (x *BattleResult) GetAttackerId() string {
+	if x != nil {
+		return x.AttackerId
+	}
+	return ""
+}
+
+func (x *BattleResult) GetDefenderId() string {
+	if x != nil {
+		return x.DefenderId
+	}
+	return ""
+}
+
+func (x *BattleResult) GetRoundReports() []*BattleRound {
+	if x != nil {
+		return x.RoundReports
+	}
+	return nil
+}
+
+func (x *BattleResult) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *BattleResult) GetAttackerHp() int32 {
+	if x != nil {
+		return x.AttackerHp
+	}
+	return 0
+}
+
+func (x *BattleResult) GetDefenderHp() int32 {
+	if x != nil {
+		return x.DefenderHp
+	}
+	return 0
+}
+
+func (x *BattleResult) GetStatus() BattleStatus {
+	if x != nil {
+		return x.Status
+	}
+	return BattleStatus_BATTLE_STATUS_PENDING
+}
+
+func (x *BattleResult) GetAttackerStacks() []*BattleStack {
+	if x != nil {
+		return x.AttackerStacks
+	}
+	return nil
+}
+
+func (x *BattleResult) GetDefenderStacks() []*BattleStack {
+	if x != nil {
+		return x.DefenderStacks
+	}
+	return nil
+}
+
+func (x *BattleResult) GetAttackerHeroId() string {
+	if x != nil {
+		return x.AttackerHeroId
+	}
+	return ""
+}
+
+func (x *BattleResult) GetDefenderHeroId() string {
+	if x != nil {
+		return x.DefenderHeroId
+	}
+	return ""
+}
+
+func (x *BattleResult) GetInput() *BattleInput {
+	if x != nil {
+		return x.Input
+	}
+	return nil
+}
+
+func (x *BattleResult) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *BattleResult) GetReinforcementIds() []string {
+	if x != nil {
+		return x.ReinforcementIds
+	}
+	return nil
+}
+
+// 战斗历史查询请求
+type BattleHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BattleId      string                 `protobuf:"bytes,1,opt,name=battle_id,json=battleId,proto3" json:"battle_id,omitempty"` // 指定时查询单场战报详情
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`                    // 分页偏移，从最近一场开始计数
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`                      // 每页数量
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BattleHistoryRequest) Reset() {
+	*x = BattleHistoryRequest{}
+	mi := &file_proto_message_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BattleHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BattleHistoryRequest) ProtoMessage() {}
+
+func (x *BattleHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BattleHistoryRequest.ProtoReflect.Descriptor instead.
+func (*BattleHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BattleHistoryRequest) GetBattleId() string {
+	if x != nil {
+		return x.BattleId
+	}
+	return ""
+}
+
+func (x *BattleHistoryRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *BattleHistoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// 战斗历史查询响应
+type BattleHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Battles       []*BattleResult        `protobuf:"bytes,1,rep,name=battles,proto3" json:"battles,omitempty"` // 战斗摘要，不含逐回合战报
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`  // 本页偏移
+	Total         int32                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`    // 历史战斗总数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BattleHistoryResponse) Reset() {
+	*x = BattleHistoryResponse{}
+	mi := &file_proto_message_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BattleHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BattleHistoryResponse) ProtoMessage() {}
+
+func (x *BattleHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BattleHistoryResponse.ProtoReflect.Descriptor instead.
+func (*BattleHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *BattleHistoryResponse) GetBattles() []*BattleResult {
+	if x != nil {
+		return x.Battles
+	}
+	return nil
+}
+
+func (x *BattleHistoryResponse) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *BattleHistoryResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+// 战斗复盘请求
+type BattleReplayRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BattleId      string                 `protobuf:"bytes,1,opt,name=battle_id,json=battleId,proto3" json:"battle_id,omitempty"` // 要复盘的战斗ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BattleReplayRequest) Reset() {
+	*x = BattleReplayRequest{}
+	mi := &file_proto_message_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BattleReplayRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BattleReplayRequest) ProtoMessage() {}
+
+func (x *BattleReplayRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BattleReplayRequest.ProtoReflect.Descriptor instead.
+func (*BattleReplayRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *BattleReplayRequest) GetBattleId() string {
+	if x != nil {
+		return x.BattleId
+	}
+	return ""
+}
+
+// 战斗复盘响应
+type BattleReplayResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BattleId      string                 `protobuf:"bytes,1,opt,name=battle_id,json=battleId,proto3" json:"battle_id,omitempty"` // 战斗ID
+	Identical     bool                   `protobuf:"varint,2,opt,name=identical,proto3" json:"identical,omitempty"`              // 复盘结果是否与保存的战报一致
+	Replay        *BattleResult          `protobuf:"bytes,3,opt,name=replay,proto3" json:"replay,omitempty"`                     // 复盘得到的战报
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BattleReplayResponse) Reset() {
+	*x = BattleReplayResponse{}
+	mi := &file_proto_message_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BattleReplayResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BattleReplayResponse) ProtoMessage() {}
+
+func (x *BattleReplayResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BattleReplayResponse.ProtoReflect.Descriptor instead.
+func (*BattleReplayResponse) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *BattleReplayResponse) GetBattleId() string {
+	if x != nil {
+		return x.BattleId
+	}
+	return ""
+}
+
+func (x *BattleReplayResponse) GetIdentical() bool {
+	if x != nil {
+		return x.Identical
+	}
+	return false
+}
+
+func (x *BattleReplayResponse) GetReplay() *BattleResult {
+	if x != nil {
+		return x.Replay
+	}
+	return nil
+}
+
+// 地图格子
+type Tile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             int32                  `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int32                  `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Terrain       string                 `protobuf:"bytes,3,opt,name=terrain,proto3" json:"terrain,omitempty"`                                   // 地形：plain, forest, mountain, water
+	ResourceLevel int32                  `protobuf:"varint,4,opt,name=resource_level,json=resourceLevel,proto3" json:"resource_level,omitempty"` // 资源等级，0 表示没有资源
+	OwnerId       string                 `protobuf:"bytes,5,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"`                    // 占领者ID
+	ArmyId        string                 `protobuf:"bytes,6,opt,name=army_id,json=armyId,proto3" json:"army_id,omitempty"`                       // 驻扎在格子上的部队ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tile) Reset() {
+	*x = Tile{}
+	mi := &file_proto_message_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tile) ProtoMessage() {}
+
+func (x *Tile) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tile.ProtoReflect.Descriptor instead.
+func (*Tile) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Tile) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Tile) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *Tile) GetTerrain() string {
+	if x != nil {
+		return x.Terrain
+	}
+	return ""
+}
+
+func (x *Tile) GetResourceLevel() int32 {
+	if x != nil {
+		return x.ResourceLevel
+	}
+	return 0
+}
+
+func (x *Tile) GetOwnerId() string {
+	if x != nil {
+		return x.OwnerId
+	}
+	return ""
+}
+
+func (x *Tile) GetArmyId() string {
+	if x != nil {
+		return x.ArmyId
+	}
+	return ""
+}
+
+// 地图视野请求：以 (x, y) 为中心、radius 为半径的正方形区域
+type MapViewRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             int32                  `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int32                  `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Radius        int32                  `protobuf:"varint,3,opt,name=radius,proto3" json:"radius,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapViewRequest) Reset() {
+	*x = MapViewRequest{}
+	mi := &file_proto_message_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapViewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapViewRequest) ProtoMessage() {}
+
+func (x *MapViewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapViewRequest.ProtoReflect.Descriptor instead.
+func (*MapViewRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *MapViewRequest) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *MapViewRequest) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *MapViewRequest) GetRadius() int32 {
+	if x != nil {
+		return x.Radius
+	}
+	return 0
+}
+
+// 地图视野响应
+type MapViewResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             int32                  `protobuf:"varint,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int32                  `protobuf:"varint,2,opt,name=y,proto3" json:"y,omitempty"`
+	Radius        int32                  `protobuf:"varint,3,opt,name=radius,proto3" json:"radius,omitempty"` // 实际使用的半径
+	Width         int32                  `protobuf:"varint,4,opt,name=width,proto3" json:"width,omitempty"`   // 地图宽度
+	Height        int32                  `protobuf:"varint,5,opt,name=height,proto3" json:"height,omitempty"` // 地图高度
+	Tiles         []*Tile                `protobuf:"bytes,6,rep,name=tiles,proto3" json:"tiles,omitempty"`    // 区域内的格子，超出地图边界的部分不返回
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapViewResponse) Reset() {
+	*x = MapViewResponse{}
+	mi := &file_proto_message_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapViewResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapViewResponse) ProtoMessage() {}
+
+func (x *MapViewResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapViewResponse.ProtoReflect.Descriptor instead.
+func (*MapViewResponse) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *MapViewResponse) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *MapViewResponse) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *MapViewResponse) GetRadius() int32 {
+	if x != nil {
+		return x.Radius
+	}
+	return 0
+}
+
+func (x *MapViewResponse) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *MapViewResponse) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *MapViewResponse) GetTiles() []*Tile {
+	if x != nil {
+		return x.Tiles
+	}
+	return nil
+}
+
+// 地图实体：城市、部队等
+type MapEntity struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Kind          string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"` // city, army, node
+	X             int32                  `protobuf:"varint,3,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int32                  `protobuf:"varint,4,opt,name=y,proto3" json:"y,omitempty"`
+	OwnerId       string                 `protobuf:"bytes,5,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"` // 所属玩家ID，资源点为正在采集的玩家
+	Name          string                 `protobuf:"bytes,6,opt,name=name,proto3" json:"name,omitempty"`
+	Level         int32                  `protobuf:"varint,7,opt,name=level,proto3" json:"level,omitempty"`
+	Target        *Coord                 `protobuf:"bytes,8,opt,name=target,proto3" json:"target,omitempty"`                       // 行军目标，静止的实体为空
+	DepartAt      int64                  `protobuf:"varint,9,opt,name=depart_at,json=departAt,proto3" json:"depart_at,omitempty"`  // 出发时间（Unix毫秒）
+	ArriveAt      int64                  `protobuf:"varint,10,opt,name=arrive_at,json=arriveAt,proto3" json:"arrive_at,omitempty"` // 到达时间（Unix毫秒）
+	Resource      string                 `protobuf:"bytes,11,opt,name=resource,proto3" json:"resource,omitempty"`                  // 资源点的资源类型
+	Amount        int64                  `protobuf:"varint,12,opt,name=amount,proto3" json:"amount,omitempty"`                     // 资源点的剩余数量
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapEntity) Reset() {
+	*x = MapEntity{}
+	mi := &file_proto_message_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapEntity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapEntity) ProtoMessage() {}
+
+func (x *MapEntity) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapEntity.ProtoReflect.Descriptor instead.
+func (*MapEntity) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *MapEntity) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *MapEntity) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *MapEntity) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *MapEntity) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *MapEntity) GetOwnerId() string {
+	if x != nil {
+		return x.OwnerId
+	}
+	return ""
+}
+
+func (x *MapEntity) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MapEntity) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *MapEntity) GetTarget() *Coord {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *MapEntity) GetDepartAt() int64 {
+	if x != nil {
+		return x.DepartAt
+	}
+	return 0
+}
+
+func (x *MapEntity) GetArriveAt() int64 {
+	if x != nil {
+		return x.ArriveAt
+	}
+	return 0
+}
+
+func (x *MapEntity) GetResource() string {
+	if x != nil {
+		return x.Resource
+	}
+	return ""
+}
+
+func (x *MapEntity) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+// 地图区域订阅请求，区域为闭区间矩形
+type MapSubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MinX          int32                  `protobuf:"varint,1,opt,name=min_x,json=minX,proto3" json:"min_x,omitempty"`
+	MinY          int32                  `protobuf:"varint,2,opt,name=min_y,json=minY,proto3" json:"min_y,omitempty"`
+	MaxX          int32                  `protobuf:"varint,3,opt,name=max_x,json=maxX,proto3" json:"max_x,omitempty"`
+	MaxY          int32                  `protobuf:"varint,4,opt,name=max_y,json=maxY,proto3" json:"max_y,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapSubscribeRequest) Reset() {
+	*x = MapSubscribeRequest{}
+	mi := &file_proto_message_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapSubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapSubscribeRequest) ProtoMessage() {}
+
+func (x *MapSubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapSubscribeRequest.ProtoReflect.Descriptor instead.
+func (*MapSubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *MapSubscribeRequest) GetMinX() int32 {
+	if x != nil {
+		return x.MinX
+	}
+	return 0
+}
+
+func (x *MapSubscribeRequest) GetMinY() int32 {
+	if x != nil {
+		return x.MinY
+	}
+	return 0
+}
+
+func (x *MapSubscribeRequest) GetMaxX() int32 {
+	if x != nil {
+		return x.MaxX
+	}
+	return 0
+}
+
+func (x *MapSubscribeRequest) GetMaxY() int32 {
+	if x != nil {
+		return x.MaxY
+	}
+	return 0
+}
+
+// 地图增量推送：订阅区域内发生变化的实体和格子
+type MapDelta struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshot      bool                   `protobuf:"varint,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"` // 为 true 时是订阅后的全量快照
+	Updated       []*MapEntity           `protobuf:"bytes,2,rep,name=updated,proto3" json:"updated,omitempty"`    // 新增或变化的实体
+	Removed       []string               `protobuf:"bytes,3,rep,name=removed,proto3" json:"removed,omitempty"`    // 离开区域或被删除的实体ID
+	Tiles         []*Tile                `protobuf:"bytes,4,rep,name=tiles,proto3" json:"tiles,omitempty"`        // 变化的格子
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapDelta) Reset() {
+	*x = MapDelta{}
+	mi := &file_proto_message_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapDelta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapDelta) ProtoMessage() {}
+
+func (x *MapDelta) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapDelta.ProtoReflect.Descriptor instead.
+func (*MapDelta) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *MapDelta) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+func (x *MapDelta) GetUpdated() []*MapEntity {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *MapDelta) GetRemoved() []string {
+	if x != nil {
+		return x.Removed
+	}
+	return nil
+}
+
+func (x *MapDelta) GetTiles() []*Tile {
+	if x != nil {
+		return x.Tiles
+	}
+	return nil
+}
+
+// 行军
+type March struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	OwnerId       string                 `protobuf:"bytes,2,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"`                                                       // 出征玩家ID
+	TargetId      string                 `protobuf:"bytes,3,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`                                                    // 目标玩家ID
+	Army          map[string]int32       `protobuf:"bytes,4,rep,name=army,proto3" json:"army,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 行军中的部队，战斗后为幸存部队
+	HeroId        string                 `protobuf:"bytes,5,opt,name=hero_id,json=heroId,proto3" json:"hero_id,omitempty"`                                                          // 统帅英雄ID
+	From          *Coord                 `protobuf:"bytes,6,opt,name=from,proto3" json:"from,omitempty"`                                                                            // 本段行程的起点
+	To            *Coord                 `protobuf:"bytes,7,opt,name=to,proto3" json:"to,omitempty"`                                                                                // 本段行程的终点
+	DepartAt      int64                  `protobuf:"varint,8,opt,name=depart_at,json=departAt,proto3" json:"depart_at,omitempty"`                                                   // 本段行程的出发时间（Unix毫秒）
+	ArriveAt      int64                  `protobuf:"varint,9,opt,name=arrive_at,json=arriveAt,proto3" json:"arrive_at,omitempty"`                                                   // 本段行程的到达时间（Unix毫秒）
+	Status        MarchStatus            `protobuf:"varint,10,opt,name=status,proto3,enum=pb.MarchStatus" json:"status,omitempty"`
+	BattleId      string                 `protobuf:"bytes,11,opt,name=battle_id,json=battleId,proto3" json:"battle_id,omitempty"`                                                    // 到达后发生的战斗ID
+	NodeId        string                 `protobuf:"bytes,12,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`                                                          // 采集行军的目标资源点ID
+	Gathered      int64                  `protobuf:"varint,13,opt,name=gathered,proto3" json:"gathered,omitempty"`                                                                   // 已采集的资源数量
+	Loot          map[string]int64       `protobuf:"bytes,14,rep,name=loot,proto3" json:"loot,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 掠夺的资源，回城后计入玩家资源
+	Reinforce     bool                   `protobuf:"varint,15,opt,name=reinforce,proto3" json:"reinforce,omitempty"`                                                                 // 增援行军，到达后驻守在目标玩家的主城
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *March) Reset() {
+	*x = March{}
+	mi := &file_proto_message_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *March) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*March) ProtoMessage() {}
+
+func (x *March) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use March.ProtoReflect.Descriptor instead.
+func (*March) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *March) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *March) GetOwnerId() string {
+	if x != nil {
+		return x.OwnerId
+	}
+	return ""
+}
+
+func (x *March) GetTargetId() string {
+	if x != nil {
+		return x.TargetId
+	}
+	return ""
+}
+
+func (x *March) GetArmy() map[string]int32 {
+	if x != nil {
+		return x.Army
+	}
+	return nil
+}
+
+func (x *March) GetHeroId() string {
+	if x != nil {
+		return x.HeroId
+	}
+	return ""
+}
+
+func (x *March) GetFrom() *Coord {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *March) GetTo() *Coord {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *March) GetDepartAt() int64 {
+	if x != nil {
+		return x.DepartAt
+	}
+	return 0
+}
+
+func (x *March) GetArriveAt() int64 {
+	if x != nil {
+		return x.ArriveAt
+	}
+	return 0
+}
+
+func (x *March) GetStatus() MarchStatus {
+	if x != nil {
+		return x.Status
+	}
+	return MarchStatus_MARCH_STATUS_OUTBOUND
+}
+
+func (x *March) GetBattleId() string {
+	if x != nil {
+		return x.BattleId
+	}
+	return ""
+}
+
+func (x *March) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *March) GetGathered() int64 {
+	if x != nil {
+		return x.Gathered
+	}
+	return 0
+}
+
+func (x *March) GetLoot() map[string]int64 {
+	if x != nil {
+		return x.Loot
+	}
+	return nil
+}
+
+func (x *March) GetReinforce() bool {
+	if x != nil {
+		return x.Reinforce
+	}
+	return false
+}
+
+// 增援请求：派出部队驻守在同一联盟玩家的主城
+type ReinforceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HostId        string                 `protobuf:"bytes,1,opt,name=host_id,json=hostId,proto3" json:"host_id,omitempty"`                                                          // 接受增援的玩家ID
+	Army          map[string]int32       `protobuf:"bytes,2,rep,name=army,proto3" json:"army,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 增援部队，为空时派出全部驻守部队
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReinforceRequest) Reset() {
+	*x = ReinforceRequest{}
+	mi := &file_proto_message_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReinforceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReinforceRequest) ProtoMessage() {}
+
+func (x *ReinforceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReinforceRequest.ProtoReflect.Descriptor instead.
+func (*ReinforceRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ReinforceRequest) GetHostId() string {
+	if x != nil {
+		return x.HostId
+	}
+	return ""
+}
+
+func (x *ReinforceRequest) GetArmy() map[string]int32 {
+	if x != nil {
+		return x.Army
+	}
+	return nil
+}
+
+// 召回行军请求
+type MarchRecallRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MarchId       string                 `protobuf:"bytes,1,opt,name=march_id,json=marchId,proto3" json:"march_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarchRecallRequest) Reset() {
+	*x = MarchRecallRequest{}
+	mi := &file_proto_message_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarchRecallRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarchRecallRequest) ProtoMessage() {}
+
+func (x *MarchRecallRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarchRecallRequest.ProtoReflect.Descriptor instead.
+func (*MarchRecallRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *MarchRecallRequest) GetMarchId() string {
+	if x != nil {
+		return x.MarchId
+	}
+	return ""
+}
+
+// 行军列表：玩家发出的和针对该玩家的行军
+type MarchList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Marches       []*March               `protobuf:"bytes,1,rep,name=marches,proto3" json:"marches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarchList) Reset() {
+	*x = MarchList{}
+	mi := &file_proto_message_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarchList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarchList) ProtoMessage() {}
+
+func (x *MarchList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarchList.ProtoReflect.Descriptor instead.
+func (*MarchList) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *MarchList) GetMarches() []*March {
+	if x != nil {
+		return x.Marches
+	}
+	return nil
+}
+
+// 建造任务
+type BuildTask struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Building      string                 `protobuf:"bytes,2,opt,name=building,proto3" json:"building,omitempty"`                  // 建筑类型
+	Level         int32                  `protobuf:"varint,3,opt,name=level,proto3" json:"level,omitempty"`                       // 完成后达到的等级
+	StartAt       int64                  `protobuf:"varint,4,opt,name=start_at,json=startAt,proto3" json:"start_at,omitempty"`    // 开始时间（Unix毫秒）
+	FinishAt      int64                  `protobuf:"varint,5,opt,name=finish_at,json=finishAt,proto3" json:"finish_at,omitempty"` // 完成时间（Unix毫秒）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildTask) Reset() {
+	*x = BuildTask{}
+	mi := &file_proto_message_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildTask) ProtoMessage() {}
+
+func (x *BuildTask) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildTask.ProtoReflect.Descriptor instead.
+func (*BuildTask) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *BuildTask) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BuildTask) GetBuilding() string {
+	if x != nil {
+		return x.Building
+	}
+	return ""
+}
+
+func (x *BuildTask) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *BuildTask) GetStartAt() int64 {
+	if x != nil {
+		return x.StartAt
+	}
+	return 0
+}
+
+func (x *BuildTask) GetFinishAt() int64 {
+	if x != nil {
+		return x.FinishAt
+	}
+	return 0
+}
+
+// 玩家主城
+type City struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Buildings           map[string]int32       `protobuf:"bytes,1,rep,name=buildings,proto3" json:"buildings,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 建筑等级，key为建筑类型，未建造的建筑不在其中
+	Queue               []*BuildTask           `protobuf:"bytes,2,rep,name=queue,proto3" json:"queue,omitempty"`                                                                                    // 进行中的建造任务
+	SpeedupSeconds      int64                  `protobuf:"varint,3,opt,name=speedup_seconds,json=speedupSeconds,proto3" json:"speedup_seconds,omitempty"`                                           // 可用的加速时间（秒）
+	Training            []*TrainTask           `protobuf:"bytes,4,rep,name=training,proto3" json:"training,omitempty"`                                                                              // 兵营中进行中的训练任务
+	Durability          int32                  `protobuf:"varint,5,opt,name=durability,proto3" json:"durability,omitempty"`                                                                         // 城防耐久，上限由城墙等级决定
+	DurabilityUpdatedAt int64                  `protobuf:"varint,6,opt,name=durability_updated_at,json=durabilityUpdatedAt,proto3" json:"durability_updated_at,omitempty"`                          // 耐久上次结算时间（Unix毫秒），耐久随时间恢复
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *City) Reset() {
+	*x = City{}
+	mi := &file_proto_message_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *City) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*City) ProtoMessage() {}
+
+func (x *City) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use City.ProtoReflect.Descriptor instead.
+func (*City) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *City) GetBuildings() map[string]int32 {
+	if x != nil {
+		return x.Buildings
+	}
+	return nil
+}
+
+func (x *City) GetQueue() []*BuildTask {
+	if x != nil {
+		return x.Queue
+	}
+	return nil
+}
+
+func (x *City) GetSpeedupSeconds() int64 {
+	if x != nil {
+		return x.SpeedupSeconds
+	}
+	return 0
+}
+
+func (x *City) GetTraining() []*TrainTask {
+	if x != nil {
+		return x.Training
+	}
+	return nil
+}
+
+func (x *City) GetDurability() int32 {
+	if x != nil {
+		return x.Durability
+	}
+	return 0
+}
+
+func (x *City) GetDurabilityUpdatedAt() int64 {
+	if x != nil {
+		return x.DurabilityUpdatedAt
+	}
+	return 0
+}
+
+// 训练任务
+type TrainTask struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TroopType     string                 `protobuf:"bytes,2,opt,name=troop_type,json=troopType,proto3" json:"troop_type,omitempty"`
+	Count         int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	StartAt       int64                  `protobuf:"varint,4,opt,name=start_at,json=startAt,proto3" json:"start_at,omitempty"`    // 开始时间（Unix毫秒）
+	FinishAt      int64                  `protobuf:"varint,5,opt,name=finish_at,json=finishAt,proto3" json:"finish_at,omitempty"` // 完成时间（Unix毫秒）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrainTask) Reset() {
+	*x = TrainTask{}
+	mi := &file_proto_message_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrainTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrainTask) ProtoMessage() {}
+
+func (x *TrainTask) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrainTask.ProtoReflect.Descriptor instead.
+func (*TrainTask) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *TrainTask) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TrainTask) GetTroopType() string {
+	if x != nil {
+		return x.TroopType
+	}
+	return ""
+}
+
+func (x *TrainTask) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *TrainTask) GetStartAt() int64 {
+	if x != nil {
+		return x.StartAt
+	}
+	return 0
+}
+
+func (x *TrainTask) GetFinishAt() int64 {
+	if x != nil {
+		return x.FinishAt
+	}
+	return 0
+}
+
+// 训练请求：train_start 使用 troop_type 和 count，train_cancel 使用 task_id
+type TrainRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TroopType     string                 `protobuf:"bytes,1,opt,name=troop_type,json=troopType,proto3" json:"troop_type,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	TaskId        string                 `protobuf:"bytes,3,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrainRequest) Reset() {
+	*x = TrainRequest{}
+	mi := &file_proto_message_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrainRequest) ProtoMessage() {}
+
+func (x *TrainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrainRequest.ProtoReflect.Descriptor instead.
+func (*TrainRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *TrainRequest) GetTroopType() string {
+	if x != nil {
+		return x.TroopType
+	}
+	return ""
+}
+
+func (x *TrainRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *TrainRequest) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+// 英雄请求：hero_recruit 和 equipment_forge 使用 template_id，
+// hero_equip 使用 hero_id 和 equipment_id，hero_unequip 使用 hero_id 和 slot
+type HeroRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	HeroId        string                 `protobuf:"bytes,2,opt,name=hero_id,json=heroId,proto3" json:"hero_id,omitempty"`
+	EquipmentId   string                 `protobuf:"bytes,3,opt,name=equipment_id,json=equipmentId,proto3" json:"equipment_id,omitempty"`
+	Slot          string                 `protobuf:"bytes,4,opt,name=slot,proto3" json:"slot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeroRequest) Reset() {
+	*x = HeroRequest{}
+	mi := &file_proto_message_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeroRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeroRequest) ProtoMessage() {}
+
+func (x *HeroRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeroRequest.ProtoReflect.Descriptor instead.
+func (*HeroRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *HeroRequest) GetTemplateId() string {
+	if x != nil {
+		return x.TemplateId
+	}
+	return ""
+}
+
+func (x *HeroRequest) GetHeroId() string {
+	if x != nil {
+		return x.HeroId
+	}
+	return ""
+}
+
+func (x *HeroRequest) GetEquipmentId() string {
+	if x != nil {
+		return x.EquipmentId
+	}
+	return ""
+}
+
+func (x *HeroRequest) GetSlot() string {
+	if x != nil {
+		return x.Slot
+	}
+	return ""
+}
+
+// 建造请求：build_start 使用 building，build_speedup 使用 task_id 和 seconds，build_cancel 使用 task_id
+type BuildRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Building      string                 `protobuf:"bytes,1,opt,name=building,proto3" json:"building,omitempty"`
+	TaskId        string                 `protobuf:"bytes,2,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	Seconds       int64                  `protobuf:"varint,3,opt,name=seconds,proto3" json:"seconds,omitempty"` // 加速的秒数，为0时加速到完成
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildRequest) Reset() {
+	*x = BuildRequest{}
+	mi := &file_proto_message_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildRequest) ProtoMessage() {}
+
+func (x *BuildRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildRequest.ProtoReflect.Descriptor instead.
+func (*BuildRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *BuildRequest) GetBuilding() string {
+	if x != nil {
+		return x.Building
+	}
+	return ""
+}
+
+func (x *BuildRequest) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *BuildRequest) GetSeconds() int64 {
+	if x != nil {
+		return x.Seconds
+	}
+	return 0
+}
+
+// 资源状态
+type ResourceState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Amounts       map[string]int64       `protobuf:"bytes,1,rep,name=amounts,proto3" json:"amounts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`       // 当前资源数量
+	Production    map[string]int64       `protobuf:"bytes,2,rep,name=production,proto3" json:"production,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 每小时产量
+	Capacity      int64                  `protobuf:"varint,3,opt,name=capacity,proto3" json:"capacity,omitempty"`                                                                               // 每种资源的仓库容量，超出后停止产出
+	Protected     int64                  `protobuf:"varint,4,opt,name=protected,proto3" json:"protected,omitempty"`                                                                             // 每种资源受仓库保护、不会被掠夺的数量
+	UpdatedAt     int64                  `protobuf:"varint,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`                                                            // 结算时间（Unix毫秒）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResourceState) Reset() {
+	*x = ResourceState{}
+	mi := &file_proto_message_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceState) ProtoMessage() {}
+
+func (x *ResourceState) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceState.ProtoReflect.Descriptor instead.
+func (*ResourceState) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ResourceState) GetAmounts() map[string]int64 {
+	if x != nil {
+		return x.Amounts
+	}
+	return nil
+}
+
+func (x *ResourceState) GetProduction() map[string]int64 {
+	if x != nil {
+		return x.Production
+	}
+	return nil
+}
+
+func (x *ResourceState) GetCapacity() int64 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+func (x *ResourceState) GetProtected() int64 {
+	if x != nil {
+		return x.Protected
+	}
+	return 0
+}
+
+func (x *ResourceState) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+// 地图上的资源点
+type ResourceNode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Resource      string                 `protobuf:"bytes,2,opt,name=resource,proto3" json:"resource,omitempty"` // 资源类型
+	Level         int32                  `protobuf:"varint,3,opt,name=level,proto3" json:"level,omitempty"`
+	X             int32                  `protobuf:"varint,4,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int32                  `protobuf:"varint,5,opt,name=y,proto3" json:"y,omitempty"`
+	Amount        int64                  `protobuf:"varint,6,opt,name=amount,proto3" json:"amount,omitempty"`                          // 剩余数量，采集完后资源点在其他位置重新生成
+	OccupantId    string                 `protobuf:"bytes,7,opt,name=occupant_id,json=occupantId,proto3" json:"occupant_id,omitempty"` // 正在采集的玩家ID
+	MarchId       string                 `protobuf:"bytes,8,opt,name=march_id,json=marchId,proto3" json:"march_id,omitempty"`          // 正在采集的行军ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResourceNode) Reset() {
+	*x = ResourceNode{}
+	mi := &file_proto_message_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceNode) ProtoMessage() {}
+
+func (x *ResourceNode) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceNode.ProtoReflect.Descriptor instead.
+func (*ResourceNode) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ResourceNode) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ResourceNode) GetResource() string {
+	if x != nil {
+		return x.Resource
+	}
+	return ""
+}
+
+func (x *ResourceNode) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *ResourceNode) GetX() int32 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *ResourceNode) GetY() int32 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *ResourceNode) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ResourceNode) GetOccupantId() string {
+	if x != nil {
+		return x.OccupantId
+	}
+	return ""
+}
+
+func (x *ResourceNode) GetMarchId() string {
+	if x != nil {
+		return x.MarchId
+	}
+	return ""
+}
+
+// 采集请求：派出部队前往资源点采集
+type GatherRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Army          map[string]int32       `protobuf:"bytes,2,rep,name=army,proto3" json:"army,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 采集部队，为空时派出全部驻守部队
+	HeroId        string                 `protobuf:"bytes,3,opt,name=hero_id,json=heroId,proto3" json:"hero_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GatherRequest) Reset() {
+	*x = GatherRequest{}
+	mi := &file_proto_message_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GatherRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GatherRequest) ProtoMessage() {}
+
+func (x *GatherRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GatherRequest.ProtoReflect.Descriptor instead.
+func (*GatherRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *GatherRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *GatherRequest) GetArmy() map[string]int32 {
+	if x != nil {
+		return x.Army
+	}
+	return nil
+}
+
+func (x *GatherRequest) GetHeroId() string {
+	if x != nil {
+		return x.HeroId
+	}
+	return ""
+}
+
+// 掠夺报告：进攻方攻城胜利后推送给双方
+type PlunderReport struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	BattleId         string                 `protobuf:"bytes,1,opt,name=battle_id,json=battleId,proto3" json:"battle_id,omitempty"`
+	AttackerId       string                 `protobuf:"bytes,2,opt,name=attacker_id,json=attackerId,proto3" json:"attacker_id,omitempty"`
+	DefenderId       string                 `protobuf:"bytes,3,opt,name=defender_id,json=defenderId,proto3" json:"defender_id,omitempty"`
+	Resources        map[string]int64       `protobuf:"bytes,4,rep,name=resources,proto3" json:"resources,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"` // 被掠夺的资源，由进攻方的行军带回
+	DurabilityDamage int32                  `protobuf:"varint,5,opt,name=durability_damage,json=durabilityDamage,proto3" json:"durability_damage,omitempty"`                                     // 城防耐久损失
+	Durability       int32                  `protobuf:"varint,6,opt,name=durability,proto3" json:"durability,omitempty"`                                                                         // 防守方剩余的城防耐久
+	MaxDurability    int32                  `protobuf:"varint,7,opt,name=max_durability,json=maxDurability,proto3" json:"max_durability,omitempty"`
+	Breached         bool                   `protobuf:"varint,8,opt,name=breached,proto3" json:"breached,omitempty"`                          // 城防耐久归零，掠夺比例提高
+	ShieldUntil      int64                  `protobuf:"varint,9,opt,name=shield_until,json=shieldUntil,proto3" json:"shield_until,omitempty"` // 防守方的掠夺保护结束时间（Unix秒）
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *PlunderReport) Reset() {
+	*x = PlunderReport{}
+	mi := &file_proto_message_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PlayerData) String() string {
+func (x *PlunderReport) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PlayerData) ProtoMessage() {}
+func (*PlunderReport) ProtoMessage() {}
 
-func (x *PlayerData) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_message_proto_msgTypes[1]
+func (x *PlunderReport) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -120,76 +3639,99 @@ func (x *PlayerData) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PlayerData.ProtoReflect.Descriptor instead.
-func (*PlayerData) Descriptor() ([]byte, []int) {
-	return file_proto_message_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use PlunderReport.ProtoReflect.Descriptor instead.
+func (*PlunderReport) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{42}
 }
 
-func (x *PlayerData) GetId() string {
+func (x *PlunderReport) GetBattleId() string {
 	if x != nil {
-		return x.Id
+		return x.BattleId
 	}
 	return ""
 }
 
-func (x *PlayerData) GetName() string {
+func (x *PlunderReport) GetAttackerId() string {
 	if x != nil {
-		return x.Name
+		return x.AttackerId
 	}
 	return ""
 }
 
-func (x *PlayerData) GetLevel() int32 {
+func (x *PlunderReport) GetDefenderId() string {
 	if x != nil {
-		return x.Level
+		return x.DefenderId
+	}
+	return ""
+}
+
+func (x *PlunderReport) GetResources() map[string]int64 {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *PlunderReport) GetDurabilityDamage() int32 {
+	if x != nil {
+		return x.DurabilityDamage
 	}
 	return 0
 }
 
-func (x *PlayerData) GetHp() int32 {
+func (x *PlunderReport) GetDurability() int32 {
 	if x != nil {
-		return x.Hp
+		return x.Durability
 	}
 	return 0
 }
 
-func (x *PlayerData) GetAttack() int32 {
+func (x *PlunderReport) GetMaxDurability() int32 {
 	if x != nil {
-		return x.Attack
+		return x.MaxDurability
 	}
 	return 0
 }
 
-func (x *PlayerData) GetDefense() int32 {
+func (x *PlunderReport) GetBreached() bool {
 	if x != nil {
-		return x.Defense
+		return x.Breached
+	}
+	return false
+}
+
+func (x *PlunderReport) GetShieldUntil() int64 {
+	if x != nil {
+		return x.ShieldUntil
 	}
 	return 0
 }
 
-// 玩家列表
-type PlayerList struct {
+type AllianceMember struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Players       []*PlayerData          `protobuf:"bytes,1,rep,name=players,proto3" json:"players,omitempty"`
+	PlayerId      string                 `protobuf:"bytes,1,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Rank          AllianceRank           `protobuf:"varint,3,opt,name=rank,proto3,enum=pb.AllianceRank" json:"rank,omitempty"`
+	JoinedAt      int64                  `protobuf:"varint,4,opt,name=joined_at,json=joinedAt,proto3" json:"joined_at,omitempty"` // 加入时间（Unix秒）
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PlayerList) Reset() {
-	*x = PlayerList{}
-	mi := &file_proto_message_proto_msgTypes[2]
+func (x *AllianceMember) Reset() {
+	*x = AllianceMember{}
+	mi := &file_proto_message_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PlayerList) String() string {
+func (x *AllianceMember) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PlayerList) ProtoMessage() {}
+func (*AllianceMember) ProtoMessage() {}
 
-func (x *PlayerList) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_message_proto_msgTypes[2]
+func (x *AllianceMember) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -200,42 +3742,69 @@ func (x *PlayerList) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PlayerList.ProtoReflect.Descriptor instead.
-func (*PlayerList) Descriptor() ([]byte, []int) {
-	return file_proto_message_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use AllianceMember.ProtoReflect.Descriptor instead.
+func (*AllianceMember) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{43}
 }
 
-func (x *PlayerList) GetPlayers() []*PlayerData {
+func (x *AllianceMember) GetPlayerId() string {
 	if x != nil {
-		return x.Players
+		return x.PlayerId
 	}
-	return nil
+	return ""
 }
 
-// 登录请求
-type LoginRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
-	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *AllianceMember) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
 }
 
-func (x *LoginRequest) Reset() {
-	*x = LoginRequest{}
-	mi := &file_proto_message_proto_msgTypes[3]
+func (x *AllianceMember) GetRank() AllianceRank {
+	if x != nil {
+		return x.Rank
+	}
+	return AllianceRank_ALLIANCE_RANK_MEMBER
+}
+
+func (x *AllianceMember) GetJoinedAt() int64 {
+	if x != nil {
+		return x.JoinedAt
+	}
+	return 0
+}
+
+type Alliance struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Tag            string                 `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"` // 联盟简称
+	LeaderId       string                 `protobuf:"bytes,4,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	Members        []*AllianceMember      `protobuf:"bytes,5,rep,name=members,proto3" json:"members,omitempty"`
+	Announcement   string                 `protobuf:"bytes,6,opt,name=announcement,proto3" json:"announcement,omitempty"`                            // 联盟公告
+	AnnouncementAt int64                  `protobuf:"varint,7,opt,name=announcement_at,json=announcementAt,proto3" json:"announcement_at,omitempty"` // 公告发布时间（Unix秒）
+	CreatedAt      int64                  `protobuf:"varint,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	MemberCount    int32                  `protobuf:"varint,9,opt,name=member_count,json=memberCount,proto3" json:"member_count,omitempty"` // 成员数量，联盟列表中不返回成员明细
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Alliance) Reset() {
+	*x = Alliance{}
+	mi := &file_proto_message_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LoginRequest) String() string {
+func (x *Alliance) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LoginRequest) ProtoMessage() {}
+func (*Alliance) ProtoMessage() {}
 
-func (x *LoginRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_message_proto_msgTypes[3]
+func (x *Alliance) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -246,112 +3815,104 @@ func (x *LoginRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LoginRequest.ProtoReflect.Descriptor instead.
-func (*LoginRequest) Descriptor() ([]byte, []int) {
-	return file_proto_message_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use Alliance.ProtoReflect.Descriptor instead.
+func (*Alliance) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{44}
 }
 
-func (x *LoginRequest) GetUsername() string {
+func (x *Alliance) GetId() string {
 	if x != nil {
-		return x.Username
+		return x.Id
 	}
 	return ""
 }
 
-func (x *LoginRequest) GetPassword() string {
+func (x *Alliance) GetName() string {
 	if x != nil {
-		return x.Password
+		return x.Name
 	}
 	return ""
 }
 
-// 登录响应
-type LoginResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	PlayerInfo    *PlayerData            `protobuf:"bytes,3,opt,name=player_info,json=playerInfo,proto3" json:"player_info,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *LoginResponse) Reset() {
-	*x = LoginResponse{}
-	mi := &file_proto_message_proto_msgTypes[4]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *Alliance) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
 }
 
-func (x *LoginResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Alliance) GetLeaderId() string {
+	if x != nil {
+		return x.LeaderId
+	}
+	return ""
 }
 
-func (*LoginResponse) ProtoMessage() {}
-
-func (x *LoginResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_message_proto_msgTypes[4]
+func (x *Alliance) GetMembers() []*AllianceMember {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Members
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use LoginResponse.ProtoReflect.Descriptor instead.
-func (*LoginResponse) Descriptor() ([]byte, []int) {
-	return file_proto_message_proto_rawDescGZIP(), []int{4}
+func (x *Alliance) GetAnnouncement() string {
+	if x != nil {
+		return x.Announcement
+	}
+	return ""
 }
 
-func (x *LoginResponse) GetSuccess() bool {
+func (x *Alliance) GetAnnouncementAt() int64 {
 	if x != nil {
-		return x.Success
+		return x.AnnouncementAt
 	}
-	return false
+	return 0
 }
 
-func (x *LoginResponse) GetMessage() string {
+func (x *Alliance) GetCreatedAt() int64 {
 	if x != nil {
-		return x.Message
+		return x.CreatedAt
 	}
-	return ""
+	return 0
 }
 
-func (x *LoginResponse) GetPlayerInfo() *PlayerData {
+func (x *Alliance) GetMemberCount() int32 {
 	if x != nil {
-		return x.PlayerInfo
+		return x.MemberCount
 	}
-	return nil
+	return 0
 }
 
-// 聊天消息
-type ChatMessage struct {
+// 联盟请求：alliance_create 使用 name 和 tag，alliance_join 使用 alliance_id，
+// alliance_kick 使用 player_id，alliance_promote 使用 player_id 和 rank，
+// alliance_announce 和 alliance_chat 使用 content
+type AllianceRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	FromId        string                 `protobuf:"bytes,1,opt,name=from_id,json=fromId,proto3" json:"from_id,omitempty"`
-	ToId          string                 `protobuf:"bytes,2,opt,name=to_id,json=toId,proto3" json:"to_id,omitempty"`
-	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
-	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Tag           string                 `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"`
+	AllianceId    string                 `protobuf:"bytes,3,opt,name=alliance_id,json=allianceId,proto3" json:"alliance_id,omitempty"`
+	PlayerId      string                 `protobuf:"bytes,4,opt,name=player_id,json=playerId,proto3" json:"player_id,omitempty"`
+	Rank          AllianceRank           `protobuf:"varint,5,opt,name=rank,proto3,enum=pb.AllianceRank" json:"rank,omitempty"`
+	Content       string                 `protobuf:"bytes,6,opt,name=content,proto3" json:"content,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ChatMessage) Reset() {
-	*x = ChatMessage{}
-	mi := &file_proto_message_proto_msgTypes[5]
+func (x *AllianceRequest) Reset() {
+	*x = AllianceRequest{}
+	mi := &file_proto_message_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ChatMessage) String() string {
+func (x *AllianceRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ChatMessage) ProtoMessage() {}
+func (*AllianceRequest) ProtoMessage() {}
 
-func (x *ChatMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_message_proto_msgTypes[5]
+func (x *AllianceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -362,63 +3923,75 @@ func (x *ChatMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
-func (*ChatMessage) Descriptor() ([]byte, []int) {
-	return file_proto_message_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use AllianceRequest.ProtoReflect.Descriptor instead.
+func (*AllianceRequest) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{45}
 }
 
-func (x *ChatMessage) GetFromId() string {
+func (x *AllianceRequest) GetName() string {
 	if x != nil {
-		return x.FromId
+		return x.Name
 	}
 	return ""
 }
 
-func (x *ChatMessage) GetToId() string {
+func (x *AllianceRequest) GetTag() string {
 	if x != nil {
-		return x.ToId
+		return x.Tag
 	}
 	return ""
 }
 
-func (x *ChatMessage) GetContent() string {
+func (x *AllianceRequest) GetAllianceId() string {
 	if x != nil {
-		return x.Content
+		return x.AllianceId
 	}
 	return ""
 }
 
-func (x *ChatMessage) GetTimestamp() int64 {
+func (x *AllianceRequest) GetPlayerId() string {
 	if x != nil {
-		return x.Timestamp
+		return x.PlayerId
 	}
-	return 0
+	return ""
 }
 
-// 战斗请求
-type BattleRequest struct {
+func (x *AllianceRequest) GetRank() AllianceRank {
+	if x != nil {
+		return x.Rank
+	}
+	return AllianceRank_ALLIANCE_RANK_MEMBER
+}
+
+func (x *AllianceRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type AllianceList struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	AttackerId    string                 `protobuf:"bytes,1,opt,name=attacker_id,json=attackerId,proto3" json:"attacker_id,omitempty"` // 攻击者ID
-	DefenderId    string                 `protobuf:"bytes,2,opt,name=defender_id,json=defenderId,proto3" json:"defender_id,omitempty"` // 防守者ID
+	Alliances     []*Alliance            `protobuf:"bytes,1,rep,name=alliances,proto3" json:"alliances,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BattleRequest) Reset() {
-	*x = BattleRequest{}
-	mi := &file_proto_message_proto_msgTypes[6]
+func (x *AllianceList) Reset() {
+	*x = AllianceList{}
+	mi := &file_proto_message_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BattleRequest) String() string {
+func (x *AllianceList) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BattleRequest) ProtoMessage() {}
+func (*AllianceList) ProtoMessage() {}
 
-func (x *BattleRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_message_proto_msgTypes[6]
+func (x *AllianceList) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -429,50 +4002,45 @@ func (x *BattleRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BattleRequest.ProtoReflect.Descriptor instead.
-func (*BattleRequest) Descriptor() ([]byte, []int) {
-	return file_proto_message_proto_rawDescGZIP(), []int{6}
-}
-
-func (x *BattleRequest) GetAttackerId() string {
-	if x != nil {
-		return x.AttackerId
-	}
-	return ""
+// Deprecated: Use AllianceList.ProtoReflect.Descriptor instead.
+func (*AllianceList) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{46}
 }
 
-func (x *BattleRequest) GetDefenderId() string {
+func (x *AllianceList) GetAlliances() []*Alliance {
 	if x != nil {
-		return x.DefenderId
+		return x.Alliances
 	}
-	return ""
+	return nil
 }
 
-// 战斗结果
-type BattleResult struct {
+// 联盟聊天和公告消息
+type AllianceChat struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	WinnerId      string                 `protobuf:"bytes,1,opt,name=winner_id,json=winnerId,proto3" json:"winner_id,omitempty"`           // 胜利者ID
-	LoserId       string                 `protobuf:"bytes,2,opt,name=loser_id,json=loserId,proto3" json:"loser_id,omitempty"`              // 失败者ID
-	DamageDealt   int32                  `protobuf:"varint,3,opt,name=damage_dealt,json=damageDealt,proto3" json:"damage_dealt,omitempty"` // 造成的伤害
+	AllianceId    string                 `protobuf:"bytes,1,opt,name=alliance_id,json=allianceId,proto3" json:"alliance_id,omitempty"`
+	SenderId      string                 `protobuf:"bytes,2,opt,name=sender_id,json=senderId,proto3" json:"sender_id,omitempty"`
+	SenderName    string                 `protobuf:"bytes,3,opt,name=sender_name,json=senderName,proto3" json:"sender_name,omitempty"`
+	Content       string                 `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BattleResult) Reset() {
-	*x = BattleResult{}
-	mi := &file_proto_message_proto_msgTypes[7]
+func (x *AllianceChat) Reset() {
+	*x = AllianceChat{}
+	mi := &file_proto_message_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BattleResult) String() string {
+func (x *AllianceChat) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BattleResult) ProtoMessage() {}
+func (*AllianceChat) ProtoMessage() {}
 
-func (x *BattleResult) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_message_proto_msgTypes[7]
+func (x *AllianceChat) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_message_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -483,28 +4051,42 @@ func (x *BattleResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BattleResult.ProtoReflect.Descriptor instead.
-func (*BattleResult) Descriptor() ([]byte, []int) {
-	return file_proto_message_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use AllianceChat.ProtoReflect.Descriptor instead.
+func (*AllianceChat) Descriptor() ([]byte, []int) {
+	return file_proto_message_proto_rawDescGZIP(), []int{47}
 }
 
-func (x *BattleResult) GetWinnerId() string {
+func (x *AllianceChat) GetAllianceId() string {
 	if x != nil {
-		return x.WinnerId
+		return x.AllianceId
 	}
 	return ""
 }
 
-func (x *BattleResult) GetLoserId() string {
+func (x *AllianceChat) GetSenderId() string {
 	if x != nil {
-		return x.LoserId
+		return x.SenderId
 	}
 	return ""
 }
 
-func (x *BattleResult) GetDamageDealt() int32 {
+func (x *AllianceChat) GetSenderName() string {
 	if x != nil {
-		return x.DamageDealt
+		return x.SenderName
+	}
+	return ""
+}
+
+func (x *AllianceChat) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *AllianceChat) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
 	}
 	return 0
 }
@@ -513,11 +4095,13 @@ var File_proto_message_proto protoreflect.FileDescriptor
 
 const file_proto_message_proto_rawDesc = "" +
 	"\n" +
-	"\x13proto/message.proto\x12\x02pb\"K\n" +
+	"\x13proto/message.proto\x12\x02pb\"j\n" +
 	"\vGameMessage\x12\x12\n" +
 	"\x04type\x18\x01 \x01(\tR\x04type\x12\x18\n" +
 	"\apayload\x18\x02 \x01(\fR\apayload\x12\x0e\n" +
-	"\x02id\x18\x03 \x01(\tR\x02id\"\x88\x01\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x04 \x01(\tR\tsessionId\"\xc3\x06\n" +
 	"\n" +
 	"PlayerData\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
@@ -525,32 +4109,412 @@ const file_proto_message_proto_rawDesc = "" +
 	"\x05level\x18\x03 \x01(\x05R\x05level\x12\x0e\n" +
 	"\x02hp\x18\x04 \x01(\x05R\x02hp\x12\x16\n" +
 	"\x06attack\x18\x05 \x01(\x05R\x06attack\x12\x18\n" +
-	"\adefense\x18\x06 \x01(\x05R\adefense\"6\n" +
+	"\adefense\x18\x06 \x01(\x05R\adefense\x12\x15\n" +
+	"\x06max_hp\x18\a \x01(\x05R\x05maxHp\x12\x1e\n" +
+	"\n" +
+	"experience\x18\b \x01(\x03R\n" +
+	"experience\x12(\n" +
+	"\x06status\x18\t \x01(\x0e2\x10.pb.PlayerStatusR\x06status\x12\x1d\n" +
+	"\n" +
+	"recover_at\x18\n" +
+	" \x01(\x03R\trecoverAt\x12\"\n" +
+	"\rhp_updated_at\x18\v \x01(\x03R\vhpUpdatedAt\x12'\n" +
+	"\x0fprotected_until\x18\f \x01(\x03R\x0eprotectedUntil\x122\n" +
+	"\x06troops\x18\r \x03(\v2\x1a.pb.PlayerData.TroopsEntryR\x06troops\x12 \n" +
+	"\x06heroes\x18\x0e \x03(\v2\b.pb.HeroR\x06heroes\x12\x1d\n" +
+	"\x04home\x18\x0f \x01(\v2\t.pb.CoordR\x04home\x12\x1c\n" +
+	"\x04city\x18\x10 \x01(\v2\b.pb.CityR\x04city\x12;\n" +
+	"\tresources\x18\x11 \x03(\v2\x1d.pb.PlayerData.ResourcesEntryR\tresources\x120\n" +
+	"\x14resources_updated_at\x18\x12 \x01(\x03R\x12resourcesUpdatedAt\x12\x1f\n" +
+	"\valliance_id\x18\x13 \x01(\tR\n" +
+	"allianceId\x12+\n" +
+	"\tinventory\x18\x14 \x03(\v2\r.pb.EquipmentR\tinventory\x12#\n" +
+	"\amarches\x18\x15 \x03(\v2\t.pb.MarchR\amarches\x1a9\n" +
+	"\vTroopsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a<\n" +
+	"\x0eResourcesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"#\n" +
+	"\x05Coord\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x05R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x05R\x01y\"`\n" +
+	"\rErrorResponse\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12!\n" +
+	"\frequest_type\x18\x03 \x01(\tR\vrequestType\"u\n" +
+	"\tEquipment\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04slot\x18\x03 \x01(\tR\x04slot\x12\x16\n" +
+	"\x06attack\x18\x04 \x01(\x05R\x06attack\x12\x18\n" +
+	"\adefense\x18\x05 \x01(\x05R\adefense\"\xb5\x02\n" +
+	"\x04Hero\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1f\n" +
+	"\vtemplate_id\x18\x02 \x01(\tR\n" +
+	"templateId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x16\n" +
+	"\x06rarity\x18\x04 \x01(\tR\x06rarity\x12\x14\n" +
+	"\x05level\x18\x05 \x01(\x05R\x05level\x12\x1e\n" +
+	"\n" +
+	"experience\x18\x06 \x01(\x03R\n" +
+	"experience\x125\n" +
+	"\tequipment\x18\a \x03(\v2\x17.pb.Hero.EquipmentEntryR\tequipment\x12\x16\n" +
+	"\x06skills\x18\b \x03(\tR\x06skills\x1aK\n" +
+	"\x0eEquipmentEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12#\n" +
+	"\x05value\x18\x02 \x01(\v2\r.pb.EquipmentR\x05value:\x028\x01\"6\n" +
 	"\n" +
 	"PlayerList\x12(\n" +
 	"\aplayers\x18\x01 \x03(\v2\x0e.pb.PlayerDataR\aplayers\"F\n" +
 	"\fLoginRequest\x12\x1a\n" +
 	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
-	"\bpassword\x18\x02 \x01(\tR\bpassword\"t\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"\x99\x01\n" +
 	"\rLoginResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12/\n" +
 	"\vplayer_info\x18\x03 \x01(\v2\x0e.pb.PlayerDataR\n" +
-	"playerInfo\"s\n" +
+	"playerInfo\x12#\n" +
+	"\rsession_token\x18\x04 \x01(\tR\fsessionToken\"4\n" +
+	"\rResumeRequest\x12#\n" +
+	"\rsession_token\x18\x01 \x01(\tR\fsessionToken\"\xc3\x01\n" +
+	"\aAccount\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1b\n" +
+	"\tplayer_id\x18\x02 \x01(\tR\bplayerId\x12\x12\n" +
+	"\x04salt\x18\x03 \x01(\fR\x04salt\x12#\n" +
+	"\rpassword_hash\x18\x04 \x01(\fR\fpasswordHash\x12'\n" +
+	"\x0fhash_iterations\x18\x05 \x01(\x05R\x0ehashIterations\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\x03R\tcreatedAt\"s\n" +
 	"\vChatMessage\x12\x17\n" +
 	"\afrom_id\x18\x01 \x01(\tR\x06fromId\x12\x13\n" +
 	"\x05to_id\x18\x02 \x01(\tR\x04toId\x12\x18\n" +
 	"\acontent\x18\x03 \x01(\tR\acontent\x12\x1c\n" +
-	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\"Q\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\"\xdd\x03\n" +
 	"\rBattleRequest\x12\x1f\n" +
 	"\vattacker_id\x18\x01 \x01(\tR\n" +
 	"attackerId\x12\x1f\n" +
 	"\vdefender_id\x18\x02 \x01(\tR\n" +
-	"defenderId\"i\n" +
+	"defenderId\x12/\n" +
+	"\x04army\x18\x03 \x03(\v2\x1b.pb.BattleRequest.ArmyEntryR\x04army\x12\x17\n" +
+	"\ahero_id\x18\x04 \x01(\tR\x06heroId\x12(\n" +
+	"\x10defender_hero_id\x18\x05 \x01(\tR\x0edefenderHeroId\x12H\n" +
+	"\rdefender_army\x18\x06 \x03(\v2#.pb.BattleRequest.DefenderArmyEntryR\fdefenderArmy\x12\x17\n" +
+	"\anode_id\x18\a \x01(\tR\x06nodeId\x129\n" +
+	"\x0ereinforcements\x18\b \x03(\v2\x11.pb.ReinforcementR\x0ereinforcements\x1a7\n" +
+	"\tArmyEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a?\n" +
+	"\x11DefenderArmyEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\xe1\x01\n" +
+	"\rReinforcement\x12\x19\n" +
+	"\bmarch_id\x18\x01 \x01(\tR\amarchId\x12\x19\n" +
+	"\bowner_id\x18\x02 \x01(\tR\aownerId\x12/\n" +
+	"\x04army\x18\x03 \x03(\v2\x1b.pb.Reinforcement.ArmyEntryR\x04army\x12\x16\n" +
+	"\x06attack\x18\x04 \x01(\x05R\x06attack\x12\x18\n" +
+	"\adefense\x18\x05 \x01(\x05R\adefense\x1a7\n" +
+	"\tArmyEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\xa4\x04\n" +
+	"\vBattleInput\x12*\n" +
+	"\battacker\x18\x01 \x01(\v2\x0e.pb.PlayerDataR\battacker\x12*\n" +
+	"\bdefender\x18\x02 \x01(\v2\x0e.pb.PlayerDataR\bdefender\x12F\n" +
+	"\rattacker_army\x18\x03 \x03(\v2!.pb.BattleInput.AttackerArmyEntryR\fattackerArmy\x12\x12\n" +
+	"\x04seed\x18\x04 \x01(\x03R\x04seed\x12-\n" +
+	"\rattacker_hero\x18\x05 \x01(\v2\b.pb.HeroR\fattackerHero\x12-\n" +
+	"\rdefender_hero\x18\x06 \x01(\v2\b.pb.HeroR\fdefenderHero\x12F\n" +
+	"\rdefender_army\x18\a \x03(\v2!.pb.BattleInput.DefenderArmyEntryR\fdefenderArmy\x129\n" +
+	"\x0ereinforcements\x18\b \x03(\v2\x11.pb.ReinforcementR\x0ereinforcements\x1a?\n" +
+	"\x11AttackerArmyEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a?\n" +
+	"\x11DefenderArmyEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\x93\x01\n" +
+	"\vBattleStack\x12\x19\n" +
+	"\bowner_id\x18\x01 \x01(\tR\aownerId\x12\x1d\n" +
+	"\n" +
+	"troop_type\x18\x02 \x01(\tR\ttroopType\x12\x18\n" +
+	"\ainitial\x18\x03 \x01(\x05R\ainitial\x12\x12\n" +
+	"\x04lost\x18\x04 \x01(\x05R\x04lost\x12\x1c\n" +
+	"\tremaining\x18\x05 \x01(\x05R\tremaining\"\xc0\x02\n" +
+	"\fBattleAction\x12\x19\n" +
+	"\bactor_id\x18\x01 \x01(\tR\aactorId\x12\x1b\n" +
+	"\ttarget_id\x18\x02 \x01(\tR\btargetId\x12\x16\n" +
+	"\x06damage\x18\x03 \x01(\x05R\x06damage\x12\x1b\n" +
+	"\ttarget_hp\x18\x04 \x01(\x05R\btargetHp\x12\x1c\n" +
+	"\tmodifiers\x18\x05 \x03(\tR\tmodifiers\x12\x1d\n" +
+	"\n" +
+	"troop_type\x18\x06 \x01(\tR\ttroopType\x12*\n" +
+	"\x11target_troop_type\x18\a \x01(\tR\x0ftargetTroopType\x12\x14\n" +
+	"\x05kills\x18\b \x01(\x05R\x05kills\x12)\n" +
+	"\x10target_remaining\x18\t \x01(\x05R\x0ftargetRemaining\x12\x19\n" +
+	"\bskill_id\x18\n" +
+	" \x01(\tR\askillId\"\x91\x01\n" +
+	"\vBattleRound\x12\x14\n" +
+	"\x05round\x18\x01 \x01(\x05R\x05round\x12*\n" +
+	"\aactions\x18\x02 \x03(\v2\x10.pb.BattleActionR\aactions\x12\x1f\n" +
+	"\vattacker_hp\x18\x03 \x01(\x05R\n" +
+	"attackerHp\x12\x1f\n" +
+	"\vdefender_hp\x18\x04 \x01(\x05R\n" +
+	"defenderHp\"\xe9\x05\n" +
 	"\fBattleResult\x12\x1b\n" +
 	"\twinner_id\x18\x01 \x01(\tR\bwinnerId\x12\x19\n" +
 	"\bloser_id\x18\x02 \x01(\tR\aloserId\x12!\n" +
-	"\fdamage_dealt\x18\x03 \x01(\x05R\vdamageDealtB3Z1github.com/cowpeatechnology/slg-game-server/protob\x06proto3"
+	"\fdamage_dealt\x18\x03 \x01(\x05R\vdamageDealt\x12\x12\n" +
+	"\x04seed\x18\x04 \x01(\x03R\x04seed\x12\x16\n" +
+	"\x06rounds\x18\x05 \x01(\x05R\x06rounds\x12\x1b\n" +
+	"\tbattle_id\x18\x06 \x01(\tR\bbattleId\x12\x1f\n" +
+	"\vattacker_id\x18\a \x01(\tR\n" +
+	"attackerId\x12\x1f\n" +
+	"\vdefender_id\x18\b \x01(\tR\n" +
+	"defenderId\x124\n" +
+	"\rround_reports\x18\t \x03(\v2\x0f.pb.BattleRoundR\froundReports\x12\x1c\n" +
+	"\ttimestamp\x18\n" +
+	" \x01(\x03R\ttimestamp\x12\x1f\n" +
+	"\vattacker_hp\x18\v \x01(\x05R\n" +
+	"attackerHp\x12\x1f\n" +
+	"\vdefender_hp\x18\f \x01(\x05R\n" +
+	"defenderHp\x12(\n" +
+	"\x06status\x18\r \x01(\x0e2\x10.pb.BattleStatusR\x06status\x128\n" +
+	"\x0fattacker_stacks\x18\x0e \x03(\v2\x0f.pb.BattleStackR\x0eattackerStacks\x128\n" +
+	"\x0fdefender_stacks\x18\x0f \x03(\v2\x0f.pb.BattleStackR\x0edefenderStacks\x12(\n" +
+	"\x10attacker_hero_id\x18\x10 \x01(\tR\x0eattackerHeroId\x12(\n" +
+	"\x10defender_hero_id\x18\x11 \x01(\tR\x0edefenderHeroId\x12%\n" +
+	"\x05input\x18\x12 \x01(\v2\x0f.pb.BattleInputR\x05input\x12\x17\n" +
+	"\anode_id\x18\x13 \x01(\tR\x06nodeId\x12+\n" +
+	"\x11reinforcement_ids\x18\x14 \x03(\tR\x10reinforcementIds\"a\n" +
+	"\x14BattleHistoryRequest\x12\x1b\n" +
+	"\tbattle_id\x18\x01 \x01(\tR\bbattleId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"q\n" +
+	"\x15BattleHistoryResponse\x12*\n" +
+	"\abattles\x18\x01 \x03(\v2\x10.pb.BattleResultR\abattles\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\"2\n" +
+	"\x13BattleReplayRequest\x12\x1b\n" +
+	"\tbattle_id\x18\x01 \x01(\tR\bbattleId\"{\n" +
+	"\x14BattleReplayResponse\x12\x1b\n" +
+	"\tbattle_id\x18\x01 \x01(\tR\bbattleId\x12\x1c\n" +
+	"\tidentical\x18\x02 \x01(\bR\tidentical\x12(\n" +
+	"\x06replay\x18\x03 \x01(\v2\x10.pb.BattleResultR\x06replay\"\x97\x01\n" +
+	"\x04Tile\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x05R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x05R\x01y\x12\x18\n" +
+	"\aterrain\x18\x03 \x01(\tR\aterrain\x12%\n" +
+	"\x0eresource_level\x18\x04 \x01(\x05R\rresourceLevel\x12\x19\n" +
+	"\bowner_id\x18\x05 \x01(\tR\aownerId\x12\x17\n" +
+	"\aarmy_id\x18\x06 \x01(\tR\x06armyId\"D\n" +
+	"\x0eMapViewRequest\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x05R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x05R\x01y\x12\x16\n" +
+	"\x06radius\x18\x03 \x01(\x05R\x06radius\"\x93\x01\n" +
+	"\x0fMapViewResponse\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x05R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x05R\x01y\x12\x16\n" +
+	"\x06radius\x18\x03 \x01(\x05R\x06radius\x12\x14\n" +
+	"\x05width\x18\x04 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x05 \x01(\x05R\x06height\x12\x1e\n" +
+	"\x05tiles\x18\x06 \x03(\v2\b.pb.TileR\x05tiles\"\xa1\x02\n" +
+	"\tMapEntity\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12\f\n" +
+	"\x01x\x18\x03 \x01(\x05R\x01x\x12\f\n" +
+	"\x01y\x18\x04 \x01(\x05R\x01y\x12\x19\n" +
+	"\bowner_id\x18\x05 \x01(\tR\aownerId\x12\x12\n" +
+	"\x04name\x18\x06 \x01(\tR\x04name\x12\x14\n" +
+	"\x05level\x18\a \x01(\x05R\x05level\x12!\n" +
+	"\x06target\x18\b \x01(\v2\t.pb.CoordR\x06target\x12\x1b\n" +
+	"\tdepart_at\x18\t \x01(\x03R\bdepartAt\x12\x1b\n" +
+	"\tarrive_at\x18\n" +
+	" \x01(\x03R\barriveAt\x12\x1a\n" +
+	"\bresource\x18\v \x01(\tR\bresource\x12\x16\n" +
+	"\x06amount\x18\f \x01(\x03R\x06amount\"i\n" +
+	"\x13MapSubscribeRequest\x12\x13\n" +
+	"\x05min_x\x18\x01 \x01(\x05R\x04minX\x12\x13\n" +
+	"\x05min_y\x18\x02 \x01(\x05R\x04minY\x12\x13\n" +
+	"\x05max_x\x18\x03 \x01(\x05R\x04maxX\x12\x13\n" +
+	"\x05max_y\x18\x04 \x01(\x05R\x04maxY\"\x89\x01\n" +
+	"\bMapDelta\x12\x1a\n" +
+	"\bsnapshot\x18\x01 \x01(\bR\bsnapshot\x12'\n" +
+	"\aupdated\x18\x02 \x03(\v2\r.pb.MapEntityR\aupdated\x12\x18\n" +
+	"\aremoved\x18\x03 \x03(\tR\aremoved\x12\x1e\n" +
+	"\x05tiles\x18\x04 \x03(\v2\b.pb.TileR\x05tiles\"\xb9\x04\n" +
+	"\x05March\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\bowner_id\x18\x02 \x01(\tR\aownerId\x12\x1b\n" +
+	"\ttarget_id\x18\x03 \x01(\tR\btargetId\x12'\n" +
+	"\x04army\x18\x04 \x03(\v2\x13.pb.March.ArmyEntryR\x04army\x12\x17\n" +
+	"\ahero_id\x18\x05 \x01(\tR\x06heroId\x12\x1d\n" +
+	"\x04from\x18\x06 \x01(\v2\t.pb.CoordR\x04from\x12\x19\n" +
+	"\x02to\x18\a \x01(\v2\t.pb.CoordR\x02to\x12\x1b\n" +
+	"\tdepart_at\x18\b \x01(\x03R\bdepartAt\x12\x1b\n" +
+	"\tarrive_at\x18\t \x01(\x03R\barriveAt\x12'\n" +
+	"\x06status\x18\n" +
+	" \x01(\x0e2\x0f.pb.MarchStatusR\x06status\x12\x1b\n" +
+	"\tbattle_id\x18\v \x01(\tR\bbattleId\x12\x17\n" +
+	"\anode_id\x18\f \x01(\tR\x06nodeId\x12\x1a\n" +
+	"\bgathered\x18\r \x01(\x03R\bgathered\x12'\n" +
+	"\x04loot\x18\x0e \x03(\v2\x13.pb.March.LootEntryR\x04loot\x12\x1c\n" +
+	"\treinforce\x18\x0f \x01(\bR\treinforce\x1a7\n" +
+	"\tArmyEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a7\n" +
+	"\tLootEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\x98\x01\n" +
+	"\x10ReinforceRequest\x12\x17\n" +
+	"\ahost_id\x18\x01 \x01(\tR\x06hostId\x122\n" +
+	"\x04army\x18\x02 \x03(\v2\x1e.pb.ReinforceRequest.ArmyEntryR\x04army\x1a7\n" +
+	"\tArmyEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"/\n" +
+	"\x12MarchRecallRequest\x12\x19\n" +
+	"\bmarch_id\x18\x01 \x01(\tR\amarchId\"0\n" +
+	"\tMarchList\x12#\n" +
+	"\amarches\x18\x01 \x03(\v2\t.pb.MarchR\amarches\"\x85\x01\n" +
+	"\tBuildTask\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bbuilding\x18\x02 \x01(\tR\bbuilding\x12\x14\n" +
+	"\x05level\x18\x03 \x01(\x05R\x05level\x12\x19\n" +
+	"\bstart_at\x18\x04 \x01(\x03R\astartAt\x12\x1b\n" +
+	"\tfinish_at\x18\x05 \x01(\x03R\bfinishAt\"\xc8\x02\n" +
+	"\x04City\x125\n" +
+	"\tbuildings\x18\x01 \x03(\v2\x17.pb.City.BuildingsEntryR\tbuildings\x12#\n" +
+	"\x05queue\x18\x02 \x03(\v2\r.pb.BuildTaskR\x05queue\x12'\n" +
+	"\x0fspeedup_seconds\x18\x03 \x01(\x03R\x0espeedupSeconds\x12)\n" +
+	"\btraining\x18\x04 \x03(\v2\r.pb.TrainTaskR\btraining\x12\x1e\n" +
+	"\n" +
+	"durability\x18\x05 \x01(\x05R\n" +
+	"durability\x122\n" +
+	"\x15durability_updated_at\x18\x06 \x01(\x03R\x13durabilityUpdatedAt\x1a<\n" +
+	"\x0eBuildingsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\x88\x01\n" +
+	"\tTrainTask\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"troop_type\x18\x02 \x01(\tR\ttroopType\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\x12\x19\n" +
+	"\bstart_at\x18\x04 \x01(\x03R\astartAt\x12\x1b\n" +
+	"\tfinish_at\x18\x05 \x01(\x03R\bfinishAt\"\\\n" +
+	"\fTrainRequest\x12\x1d\n" +
+	"\n" +
+	"troop_type\x18\x01 \x01(\tR\ttroopType\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12\x17\n" +
+	"\atask_id\x18\x03 \x01(\tR\x06taskId\"~\n" +
+	"\vHeroRequest\x12\x1f\n" +
+	"\vtemplate_id\x18\x01 \x01(\tR\n" +
+	"templateId\x12\x17\n" +
+	"\ahero_id\x18\x02 \x01(\tR\x06heroId\x12!\n" +
+	"\fequipment_id\x18\x03 \x01(\tR\vequipmentId\x12\x12\n" +
+	"\x04slot\x18\x04 \x01(\tR\x04slot\"]\n" +
+	"\fBuildRequest\x12\x1a\n" +
+	"\bbuilding\x18\x01 \x01(\tR\bbuilding\x12\x17\n" +
+	"\atask_id\x18\x02 \x01(\tR\x06taskId\x12\x18\n" +
+	"\aseconds\x18\x03 \x01(\x03R\aseconds\"\xe0\x02\n" +
+	"\rResourceState\x128\n" +
+	"\aamounts\x18\x01 \x03(\v2\x1e.pb.ResourceState.AmountsEntryR\aamounts\x12A\n" +
+	"\n" +
+	"production\x18\x02 \x03(\v2!.pb.ResourceState.ProductionEntryR\n" +
+	"production\x12\x1a\n" +
+	"\bcapacity\x18\x03 \x01(\x03R\bcapacity\x12\x1c\n" +
+	"\tprotected\x18\x04 \x01(\x03R\tprotected\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\x03R\tupdatedAt\x1a:\n" +
+	"\fAmountsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\x1a=\n" +
+	"\x0fProductionEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\xc0\x01\n" +
+	"\fResourceNode\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bresource\x18\x02 \x01(\tR\bresource\x12\x14\n" +
+	"\x05level\x18\x03 \x01(\x05R\x05level\x12\f\n" +
+	"\x01x\x18\x04 \x01(\x05R\x01x\x12\f\n" +
+	"\x01y\x18\x05 \x01(\x05R\x01y\x12\x16\n" +
+	"\x06amount\x18\x06 \x01(\x03R\x06amount\x12\x1f\n" +
+	"\voccupant_id\x18\a \x01(\tR\n" +
+	"occupantId\x12\x19\n" +
+	"\bmarch_id\x18\b \x01(\tR\amarchId\"\xab\x01\n" +
+	"\rGatherRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12/\n" +
+	"\x04army\x18\x02 \x03(\v2\x1b.pb.GatherRequest.ArmyEntryR\x04army\x12\x17\n" +
+	"\ahero_id\x18\x03 \x01(\tR\x06heroId\x1a7\n" +
+	"\tArmyEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\x9f\x03\n" +
+	"\rPlunderReport\x12\x1b\n" +
+	"\tbattle_id\x18\x01 \x01(\tR\bbattleId\x12\x1f\n" +
+	"\vattacker_id\x18\x02 \x01(\tR\n" +
+	"attackerId\x12\x1f\n" +
+	"\vdefender_id\x18\x03 \x01(\tR\n" +
+	"defenderId\x12>\n" +
+	"\tresources\x18\x04 \x03(\v2 .pb.PlunderReport.ResourcesEntryR\tresources\x12+\n" +
+	"\x11durability_damage\x18\x05 \x01(\x05R\x10durabilityDamage\x12\x1e\n" +
+	"\n" +
+	"durability\x18\x06 \x01(\x05R\n" +
+	"durability\x12%\n" +
+	"\x0emax_durability\x18\a \x01(\x05R\rmaxDurability\x12\x1a\n" +
+	"\bbreached\x18\b \x01(\bR\bbreached\x12!\n" +
+	"\fshield_until\x18\t \x01(\x03R\vshieldUntil\x1a<\n" +
+	"\x0eResourcesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\x84\x01\n" +
+	"\x0eAllianceMember\x12\x1b\n" +
+	"\tplayer_id\x18\x01 \x01(\tR\bplayerId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12$\n" +
+	"\x04rank\x18\x03 \x01(\x0e2\x10.pb.AllianceRankR\x04rank\x12\x1b\n" +
+	"\tjoined_at\x18\x04 \x01(\x03R\bjoinedAt\"\x9a\x02\n" +
+	"\bAlliance\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x10\n" +
+	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\x1b\n" +
+	"\tleader_id\x18\x04 \x01(\tR\bleaderId\x12,\n" +
+	"\amembers\x18\x05 \x03(\v2\x12.pb.AllianceMemberR\amembers\x12\"\n" +
+	"\fannouncement\x18\x06 \x01(\tR\fannouncement\x12'\n" +
+	"\x0fannouncement_at\x18\a \x01(\x03R\x0eannouncementAt\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\x03R\tcreatedAt\x12!\n" +
+	"\fmember_count\x18\t \x01(\x05R\vmemberCount\"\xb5\x01\n" +
+	"\x0fAllianceRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03tag\x18\x02 \x01(\tR\x03tag\x12\x1f\n" +
+	"\valliance_id\x18\x03 \x01(\tR\n" +
+	"allianceId\x12\x1b\n" +
+	"\tplayer_id\x18\x04 \x01(\tR\bplayerId\x12$\n" +
+	"\x04rank\x18\x05 \x01(\x0e2\x10.pb.AllianceRankR\x04rank\x12\x18\n" +
+	"\acontent\x18\x06 \x01(\tR\acontent\":\n" +
+	"\fAllianceList\x12*\n" +
+	"\talliances\x18\x01 \x03(\v2\f.pb.AllianceR\talliances\"\xa5\x01\n" +
+	"\fAllianceChat\x12\x1f\n" +
+	"\valliance_id\x18\x01 \x01(\tR\n" +
+	"allianceId\x12\x1b\n" +
+	"\tsender_id\x18\x02 \x01(\tR\bsenderId\x12\x1f\n" +
+	"\vsender_name\x18\x03 \x01(\tR\n" +
+	"senderName\x12\x18\n" +
+	"\acontent\x18\x04 \x01(\tR\acontent\x12\x1c\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp*F\n" +
+	"\fPlayerStatus\x12\x18\n" +
+	"\x14PLAYER_STATUS_ACTIVE\x10\x00\x12\x1c\n" +
+	"\x18PLAYER_STATUS_RECOVERING\x10\x01*{\n" +
+	"\fBattleStatus\x12\x19\n" +
+	"\x15BATTLE_STATUS_PENDING\x10\x00\x12\x1a\n" +
+	"\x16BATTLE_STATUS_RESOLVED\x10\x01\x12\x19\n" +
+	"\x15BATTLE_STATUS_SETTLED\x10\x02\x12\x19\n" +
+	"\x15BATTLE_STATUS_TIMEOUT\x10\x03*\x97\x01\n" +
+	"\vMarchStatus\x12\x19\n" +
+	"\x15MARCH_STATUS_OUTBOUND\x10\x00\x12\x19\n" +
+	"\x15MARCH_STATUS_FIGHTING\x10\x01\x12\x1a\n" +
+	"\x16MARCH_STATUS_RETURNING\x10\x02\x12\x1a\n" +
+	"\x16MARCH_STATUS_GATHERING\x10\x03\x12\x1a\n" +
+	"\x16MARCH_STATUS_STATIONED\x10\x04*]\n" +
+	"\fAllianceRank\x12\x18\n" +
+	"\x14ALLIANCE_RANK_MEMBER\x10\x00\x12\x19\n" +
+	"\x15ALLIANCE_RANK_OFFICER\x10\x01\x12\x18\n" +
+	"\x14ALLIANCE_RANK_LEADER\x10\x02B3Z1github.com/cowpeatechnology/slg-game-server/protob\x06proto3"
 
 var (
 	file_proto_message_proto_rawDescOnce sync.Once
@@ -564,25 +4528,137 @@ func file_proto_message_proto_rawDescGZIP() []byte {
 	return file_proto_message_proto_rawDescData
 }
 
-var file_proto_message_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_message_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_proto_message_proto_msgTypes = make([]protoimpl.MessageInfo, 64)
 var file_proto_message_proto_goTypes = []any{
-	(*GameMessage)(nil),   // 0: pb.GameMessage
-	(*PlayerData)(nil),    // 1: pb.PlayerData
-	(*PlayerList)(nil),    // 2: pb.PlayerList
-	(*LoginRequest)(nil),  // 3: pb.LoginRequest
-	(*LoginResponse)(nil), // 4: pb.LoginResponse
-	(*ChatMessage)(nil),   // 5: pb.ChatMessage
-	(*BattleRequest)(nil), // 6: pb.BattleRequest
-	(*BattleResult)(nil),  // 7: pb.BattleResult
+	(PlayerStatus)(0),             // 0: pb.PlayerStatus
+	(BattleStatus)(0),             // 1: pb.BattleStatus
+	(MarchStatus)(0),              // 2: pb.MarchStatus
+	(AllianceRank)(0),             // 3: pb.AllianceRank
+	(*GameMessage)(nil),           // 4: pb.GameMessage
+	(*PlayerData)(nil),            // 5: pb.PlayerData
+	(*Coord)(nil),                 // 6: pb.Coord
+	(*ErrorResponse)(nil),         // 7: pb.ErrorResponse
+	(*Equipment)(nil),             // 8: pb.Equipment
+	(*Hero)(nil),                  // 9: pb.Hero
+	(*PlayerList)(nil),            // 10: pb.PlayerList
+	(*LoginRequest)(nil),          // 11: pb.LoginRequest
+	(*LoginResponse)(nil),         // 12: pb.LoginResponse
+	(*ResumeRequest)(nil),         // 13: pb.ResumeRequest
+	(*Account)(nil),               // 14: pb.Account
+	(*ChatMessage)(nil),           // 15: pb.ChatMessage
+	(*BattleRequest)(nil),         // 16: pb.BattleRequest
+	(*Reinforcement)(nil),         // 17: pb.Reinforcement
+	(*BattleInput)(nil),           // 18: pb.BattleInput
+	(*BattleStack)(nil),           // 19: pb.BattleStack
+	(*BattleAction)(nil),          // 20: pb.BattleAction
+	(*BattleRound)(nil),           // 21: pb.BattleRound
+	(*BattleResult)(nil),          // 22: pb.BattleResult
+	(*BattleHistoryRequest)(nil),  // 23: pb.BattleHistoryRequest
+	(*BattleHistoryResponse)(nil), // 24: pb.BattleHistoryResponse
+	(*BattleReplayRequest)(nil),   // 25: pb.BattleReplayRequest
+	(*BattleReplayResponse)(nil),  // 26: pb.BattleReplayResponse
+	(*Tile)(nil),                  // 27: pb.Tile
+	(*MapViewRequest)(nil),        // 28: pb.MapViewRequest
+	(*MapViewResponse)(nil),       // 29: pb.MapViewResponse
+	(*MapEntity)(nil),             // 30: pb.MapEntity
+	(*MapSubscribeRequest)(nil),   // 31: pb.MapSubscribeRequest
+	(*MapDelta)(nil),              // 32: pb.MapDelta
+	(*March)(nil),                 // 33: pb.March
+	(*ReinforceRequest)(nil),      // 34: pb.ReinforceRequest
+	(*MarchRecallRequest)(nil),    // 35: pb.MarchRecallRequest
+	(*MarchList)(nil),             // 36: pb.MarchList
+	(*BuildTask)(nil),             // 37: pb.BuildTask
+	(*City)(nil),                  // 38: pb.City
+	(*TrainTask)(nil),             // 39: pb.TrainTask
+	(*TrainRequest)(nil),          // 40: pb.TrainRequest
+	(*HeroRequest)(nil),           // 41: pb.HeroRequest
+	(*BuildRequest)(nil),          // 42: pb.BuildRequest
+	(*ResourceState)(nil),         // 43: pb.ResourceState
+	(*ResourceNode)(nil),          // 44: pb.ResourceNode
+	(*GatherRequest)(nil),         // 45: pb.GatherRequest
+	(*PlunderReport)(nil),         // 46: pb.PlunderReport
+	(*AllianceMember)(nil),        // 47: pb.AllianceMember
+	(*Alliance)(nil),              // 48: pb.Alliance
+	(*AllianceRequest)(nil),       // 49: pb.AllianceRequest
+	(*AllianceList)(nil),          // 50: pb.AllianceList
+	(*AllianceChat)(nil),          // 51: pb.AllianceChat
+	nil,                           // 52: pb.PlayerData.TroopsEntry
+	nil,                           // 53: pb.PlayerData.ResourcesEntry
+	nil,                           // 54: pb.Hero.EquipmentEntry
+	nil,                           // 55: pb.BattleRequest.ArmyEntry
+	nil,                           // 56: pb.BattleRequest.DefenderArmyEntry
+	nil,                           // 57: pb.Reinforcement.ArmyEntry
+	nil,                           // 58: pb.BattleInput.AttackerArmyEntry
+	nil,                           // 59: pb.BattleInput.DefenderArmyEntry
+	nil,                           // 60: pb.March.ArmyEntry
+	nil,                           // 61: pb.March.LootEntry
+	nil,                           // 62: pb.ReinforceRequest.ArmyEntry
+	nil,                           // 63: pb.City.BuildingsEntry
+	nil,                           // 64: pb.ResourceState.AmountsEntry
+	nil,                           // 65: pb.ResourceState.ProductionEntry
+	nil,                           // 66: pb.GatherRequest.ArmyEntry
+	nil,                           // 67: pb.PlunderReport.ResourcesEntry
 }
 var file_proto_message_proto_depIdxs = []int32{
-	1, // 0: pb.PlayerList.players:type_name -> pb.PlayerData
-	1, // 1: pb.LoginResponse.player_info:type_name -> pb.PlayerData
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	0,  // 0: pb.PlayerData.status:type_name -> pb.PlayerStatus
+	52, // 1: pb.PlayerData.troops:type_name -> pb.PlayerData.TroopsEntry
+	9,  // 2: pb.PlayerData.heroes:type_name -> pb.Hero
+	6,  // 3: pb.PlayerData.home:type_name -> pb.Coord
+	38, // 4: pb.PlayerData.city:type_name -> pb.City
+	53, // 5: pb.PlayerData.resources:type_name -> pb.PlayerData.ResourcesEntry
+	8,  // 6: pb.PlayerData.inventory:type_name -> pb.Equipment
+	33, // 7: pb.PlayerData.marches:type_name -> pb.March
+	54, // 8: pb.Hero.equipment:type_name -> pb.Hero.EquipmentEntry
+	5,  // 9: pb.PlayerList.players:type_name -> pb.PlayerData
+	5,  // 10: pb.LoginResponse.player_info:type_name -> pb.PlayerData
+	55, // 11: pb.BattleRequest.army:type_name -> pb.BattleRequest.ArmyEntry
+	56, // 12: pb.BattleRequest.defender_army:type_name -> pb.BattleRequest.DefenderArmyEntry
+	17, // 13: pb.BattleRequest.reinforcements:type_name -> pb.Reinforcement
+	57, // 14: pb.Reinforcement.army:type_name -> pb.Reinforcement.ArmyEntry
+	5,  // 15: pb.BattleInput.attacker:type_name -> pb.PlayerData
+	5,  // 16: pb.BattleInput.defender:type_name -> pb.PlayerData
+	58, // 17: pb.BattleInput.attacker_army:type_name -> pb.BattleInput.AttackerArmyEntry
+	9,  // 18: pb.BattleInput.attacker_hero:type_name -> pb.Hero
+	9,  // 19: pb.BattleInput.defender_hero:type_name -> pb.Hero
+	59, // 20: pb.BattleInput.defender_army:type_name -> pb.BattleInput.DefenderArmyEntry
+	17, // 21: pb.BattleInput.reinforcements:type_name -> pb.Reinforcement
+	20, // 22: pb.BattleRound.actions:type_name -> pb.BattleAction
+	21, // 23: pb.BattleResult.round_reports:type_name -> pb.BattleRound
+	1,  // 24: pb.BattleResult.status:type_name -> pb.BattleStatus
+	19, // 25: pb.BattleResult.attacker_stacks:type_name -> pb.BattleStack
+	19, // 26: pb.BattleResult.defender_stacks:type_name -> pb.BattleStack
+	18, // 27: pb.BattleResult.input:type_name -> pb.BattleInput
+	22, // 28: pb.BattleHistoryResponse.battles:type_name -> pb.BattleResult
+	22, // 29: pb.BattleReplayResponse.replay:type_name -> pb.BattleResult
+	27, // 30: pb.MapViewResponse.tiles:type_name -> pb.Tile
+	6,  // 31: pb.MapEntity.target:type_name -> pb.Coord
+	30, // 32: pb.MapDelta.updated:type_name -> pb.MapEntity
+	27, // 33: pb.MapDelta.tiles:type_name -> pb.Tile
+	60, // 34: pb.March.army:type_name -> pb.March.ArmyEntry
+	6,  // 35: pb.March.from:type_name -> pb.Coord
+	6,  // 36: pb.March.to:type_name -> pb.Coord
+	2,  // 37: pb.March.status:type_name -> pb.MarchStatus
+	61, // 38: pb.March.loot:type_name -> pb.March.LootEntry
+	62, // 39: pb.ReinforceRequest.army:type_name -> pb.ReinforceRequest.ArmyEntry
+	33, // 40: pb.MarchList.marches:type_name -> pb.March
+	63, // 41: pb.City.buildings:type_name -> pb.City.BuildingsEntry
+	37, // 42: pb.City.queue:type_name -> pb.BuildTask
+	39, // 43: pb.City.training:type_name -> pb.TrainTask
+	64, // 44: pb.ResourceState.amounts:type_name -> pb.ResourceState.AmountsEntry
+	65, // 45: pb.ResourceState.production:type_name -> pb.ResourceState.ProductionEntry
+	66, // 46: pb.GatherRequest.army:type_name -> pb.GatherRequest.ArmyEntry
+	67, // 47: pb.PlunderReport.resources:type_name -> pb.PlunderReport.ResourcesEntry
+	3,  // 48: pb.AllianceMember.rank:type_name -> pb.AllianceRank
+	47, // 49: pb.Alliance.members:type_name -> pb.AllianceMember
+	3,  // 50: pb.AllianceRequest.rank:type_name -> pb.AllianceRank
+	48, // 51: pb.AllianceList.alliances:type_name -> pb.Alliance
+	8,  // 52: pb.Hero.EquipmentEntry.value:type_name -> pb.Equipment
+	53, // [53:53] is the sub-list for method output_type
+	53, // [53:53] is the sub-list for method input_type
+	53, // [53:53] is the sub-list for extension type_name
+	53, // [53:53] is the sub-list for extension extendee
+	0,  // [0:53] is the sub-list for field type_name
 }
 
 func init() { file_proto_message_proto_init() }
@@ -595,13 +4671,14 @@ func file_proto_message_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_message_proto_rawDesc), len(file_proto_message_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   8,
+			NumEnums:      4,
+			NumMessages:   64,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_proto_message_proto_goTypes,
 		DependencyIndexes: file_proto_message_proto_depIdxs,
+		EnumInfos:         file_proto_message_proto_enumTypes,
 		MessageInfos:      file_proto_message_proto_msgTypes,
 	}.Build()
 	File_proto_message_proto = out.File